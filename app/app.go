@@ -1,15 +1,18 @@
 package app
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/websocket"
 	router "github.com/julienschmidt/httprouter"
 	"github.com/valyala/fasthttp"
 )
@@ -132,21 +135,48 @@ type FastRouter struct {
 	// Ultra-fast path for simple handlers (no middleware, no params)
 	simple map[string]Handler
 
-	// Dynamic routes with parameters (fast radix tree)
-	dynamic *radixNode
+	// Dynamic routes with parameters (one radix tree per HTTP method)
+	dynamic map[string]*radixNode
+
+	// Registration metadata for every route, in registration order
+	routes []RouteInfo
+
+	// Handler and route middleware of every route, parallel to routes, used
+	// to recompile chains when global middleware changes
+	entries []routeEntry
+
+	// Named routes (name -> pattern) for reverse routing
+	names map[string]string
+
+	// Every method with at least one registered route, sorted
+	methods []string
+
+	// Global middleware around defaultOptionsHandler, for automatic OPTIONS
+	// responses (see optionsHandler); reset by recompile
+	options *FastChain
+
+	// Lowercased "METHOD:path" -> registered path for static routes, used by
+	// case-insensitive redirects
+	folded map[string]string
 
 	// Mutex for concurrent access during route registration
 	mu sync.RWMutex
 }
 
-// radixNode represents a node in the radix tree for parameter routes
+// radixNode represents a node in the radix tree for parameter routes.
+// Every node corresponds to one path segment; see tree.go for the matching rules.
 type radixNode struct {
-	path      string
-	indices   string
-	children  []*radixNode
-	handler   *FastChain
-	wildcard  bool
-	paramName string
+	path       string           // segment text (":name" / "*name" for parameter nodes)
+	indices    string           // first byte of each static child's segment
+	children   []*radixNode     // static children, parallel to indices
+	params     []*radixNode     // ":name" children, in matching priority order
+	catchAll   *radixNode       // "*name" child, if any
+	handler    *FastChain       // chain for routes terminating at this node
+	pattern    string           // full route pattern for routes terminating here
+	wildcard   bool             // true for ":name" and "*name" nodes
+	paramName  string           // parameter name without the ':' or '*' prefix
+	prefix     string           // literal text preceding ":name" in the segment ("v" in "v:version")
+	constraint *paramConstraint // optional "<...>" constraint on the parameter value
 }
 
 // DefaultApp is the main application/router for flash. It implements both
@@ -159,7 +189,7 @@ type radixNode struct {
 // - Pre-compiled middleware chains with direct function calls
 // - Ultra-efficient context pooling with pre-warmed contexts
 // - Memory-efficient parameter handling with stack allocation
-// - Route lookup caching for dynamic routes
+// - Bounded LRU route lookup caching for dynamic routes
 type DefaultApp struct {
 	// FastHTTP router for maximum performance
 	router *FastRouter
@@ -167,18 +197,66 @@ type DefaultApp struct {
 	// Global middleware (pre-compiled into chains)
 	middleware []Middleware
 
+	// Set when Use is called after routes were registered; cleared by Build
+	stale atomic.Bool
+
 	// Ultra-optimized context pool
 	pool sync.Pool
 
-	// Route lookup cache for dynamic routes (method:path -> result)
-	routeCache map[string]*routeResult
-	cacheMutex sync.RWMutex
+	// Bounded LRU cache of dynamic route lookups (method:path -> result)
+	routeCache *routeCache
+
+	// Host-specific apps registered with Host, in matching priority order
+	hosts    []*hostRoute
+	hasHosts atomic.Bool
+
+	// Set on apps created by Host: the app they were created from and the
+	// host pattern they serve
+	parent *DefaultApp
+	host   string
+
+	// NotFound and error handlers registered on groups, longest prefix first
+	scopes []groupScope
+
+	// Server lifecycle (see server.go)
+	serverConfig ServerConfig
+	inflight     atomic.Int64
+	lifecycleMu  sync.Mutex
+	servers      []func(context.Context) error
+	shuttingDown bool
+	stopping     chan struct{} // closed when Shutdown begins (see shutdownStarted)
+
+	// Lifecycle hooks (see hooks.go), shared with apps created by Host
+	hooks *hookRegistry
+
+	// Validator run by Bind* (nil = ctx.DefaultValidator)
+	validator ctx.Validator
+
+	// Codecs used by Ctx.Render (nil on apps created by Host)
+	codecs *ctx.Codecs
+
+	// Decoders used by the Bind* helpers (nil on apps created by Host)
+	decoders *ctx.Decoders
+
+	// Renderer used by Ctx.HTML (see render.go)
+	renderer ctx.Renderer
+
+	// Config of WS routes (see websocket.go); nil = defaults
+	wsConfig *websocket.Config
+
+	// Error mappings registered with MapError (see errormap.go)
+	errorMaps []func(error) (int, any, bool)
 
 	// Handlers and configuration
 	OnError  ErrorHandler
 	NotFound Handler
 	MethodNA Handler
 	logger   *slog.Logger
+
+	// Redirect modes for requests that match no route (see redirect.go)
+	RedirectTrailingSlash bool
+	RedirectFixedPath     bool
+	CaseInsensitive       bool
 }
 
 // newFastRouter creates a new high-performance router
@@ -186,7 +264,9 @@ func newFastRouter() *FastRouter {
 	return &FastRouter{
 		static:  make(map[string]*FastChain),
 		simple:  make(map[string]Handler),
-		dynamic: &radixNode{},
+		dynamic: make(map[string]*radixNode),
+		names:   make(map[string]string),
+		folded:  make(map[string]string),
 	}
 }
 
@@ -213,8 +293,12 @@ func newFastRouter() *FastRouter {
 //	}
 func New() App {
 	app := &DefaultApp{
-		router:     newFastRouter(),
-		routeCache: make(map[string]*routeResult),
+		router:       newFastRouter(),
+		routeCache:   newRouteCache(DefaultRouteCacheSize),
+		serverConfig: DefaultServerConfig,
+		hooks:        &hookRegistry{},
+		codecs:       ctx.NewCodecs(ctx.JSONCodec{}, ctx.XMLCodec{}),
+		decoders:     ctx.NewDecoders(),
 	}
 
 	// Ultra-optimized context pool with pre-warmed contexts
@@ -254,13 +338,86 @@ func (a *DefaultApp) Logger() *slog.Logger {
 	if a.logger != nil {
 		return a.logger
 	}
+	if a.parent != nil {
+		return a.parent.Logger()
+	}
 	return slog.Default()
 }
 
+// SetValidator sets the Validator run by the Bind* helpers after binding into
+// a struct. By default ctx.DefaultValidator checks `validate` struct tags.
+//
+// Example:
+//
+//	a.SetValidator(ctx.ValidatorFunc(func(v any) error {
+//		return playground.Struct(v)
+//	}))
+func (a *DefaultApp) SetValidator(v ctx.Validator) { a.validator = v }
+
+// Validator returns the Validator set with SetValidator, or nil if the
+// default is used. Apps created by Host fall back to the parent app's.
+func (a *DefaultApp) Validator() ctx.Validator {
+	if a.validator == nil && a.parent != nil {
+		return a.parent.Validator()
+	}
+	return a.validator
+}
+
+// RegisterCodec adds a Codec used by Ctx.Render, replacing the codec of the
+// same media type. JSON, the default when the request states no preference,
+// and XML are registered by New. Codecs do not decode request bodies; see
+// RegisterDecoder.
+//
+// Example (with github.com/goflash/flash/v2/codec/yaml):
+//
+//	a.RegisterCodec(yaml.Codec{})
+func (a *DefaultApp) RegisterCodec(c ctx.Codec) { a.Codecs().Register(c) }
+
+// Codecs returns the codecs used by Ctx.Render. Apps created by Host use
+// the parent app's.
+func (a *DefaultApp) Codecs() *ctx.Codecs {
+	if a.parent != nil {
+		return a.parent.Codecs()
+	}
+	if a.codecs == nil {
+		a.codecs = ctx.NewCodecs(ctx.JSONCodec{}, ctx.XMLCodec{})
+	}
+	return a.codecs
+}
+
+// RegisterDecoder adds a Decoder used by the Bind* helpers for request bodies
+// of the media type of contentType, replacing the decoder of the same media
+// type. JSON and XML are registered by New; replacing the JSON decoder also
+// changes Ctx.BindJSON.
+//
+// Example (with github.com/goflash/flash/v2/codec/yaml):
+//
+//	a.RegisterDecoder(yaml.MediaType, yaml.Codec{})
+func (a *DefaultApp) RegisterDecoder(contentType string, d ctx.Decoder) {
+	a.Decoders().Register(contentType, d)
+}
+
+// Decoders returns the decoders used by the Bind* helpers. Apps created by
+// Host use the parent app's.
+func (a *DefaultApp) Decoders() *ctx.Decoders {
+	if a.parent != nil {
+		return a.parent.Decoders()
+	}
+	if a.decoders == nil {
+		a.decoders = ctx.NewDecoders()
+	}
+	return a.decoders
+}
+
 // Use registers global middleware, applied to all routes in the order added.
 // Route-specific middleware passed at registration time is applied after global
 // middleware.
 //
+// Global middleware applies to every route regardless of when Use is called:
+// routes registered earlier are recompiled by Build, which runs before the
+// next request is served. Group middleware, by contrast, only applies to
+// routes registered on the group afterwards.
+//
 // Example:
 //
 //	a.Use(Log, Recover)
@@ -270,6 +427,18 @@ func (a *DefaultApp) Use(mw ...Middleware) {
 		return
 	}
 	a.middleware = append(a.middleware, mw...)
+
+	a.router.mu.RLock()
+	registered := len(a.router.entries) > 0 || len(a.hosts) > 0
+	a.router.mu.RUnlock()
+	if registered {
+		// Host apps are recompiled by the parent's Build
+		root := a
+		if a.parent != nil {
+			root = a.parent
+		}
+		root.stale.Store(true)
+	}
 }
 
 // ServeHTTP implements http.Handler for net/http compatibility.
@@ -279,6 +448,27 @@ func (a *DefaultApp) Use(mw ...Middleware) {
 //
 //	_ = http.ListenAndServe(":8080", a)
 func (a *DefaultApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.inflight.Add(1)
+	defer a.inflight.Add(-1)
+
+	// Recompile chains if global middleware was added after registration
+	if a.stale.Load() {
+		a.Build()
+	}
+
+	// Dispatch to the app registered for the request host, if any
+	if a.hasHosts.Load() {
+		if h, hostParams := a.matchHost(r.Host); h != nil {
+			h.serveHTTP(w, r, hostParams)
+			return
+		}
+	}
+	a.serveHTTP(w, r, nil)
+}
+
+// serveHTTP routes a net/http request within a. hostParams holds parameters
+// captured from the Host header and are prepended to the route parameters.
+func (a *DefaultApp) serveHTTP(w http.ResponseWriter, r *http.Request, hostParams []router.Param) {
 	// Find the route and execute it
 	method := r.Method
 	path := r.URL.Path
@@ -301,11 +491,8 @@ func (a *DefaultApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// Ultra-fast path: direct handler execution (no middleware chain)
 		a.router.mu.RUnlock()
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.Reset(w, r, nil, path, a)
-		if err := simpleHandler(c); err != nil {
-			a.ErrorHandler()(c, err)
-		}
-		c.Finish()
+		c.Reset(w, r, hostParams, path, a)
+		a.execute(c, simpleHandler, path)
 		a.pool.Put(c)
 		return
 	}
@@ -317,11 +504,8 @@ func (a *DefaultApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if chain != nil {
 		// Fast path: static route
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.Reset(w, r, nil, path, a)
-		if err := chain.Execute(c); err != nil {
-			a.ErrorHandler()(c, err)
-		}
-		c.Finish()
+		c.Reset(w, r, hostParams, path, a)
+		a.execute(c, chain.exec, path)
 		a.pool.Put(c)
 		return
 	}
@@ -329,34 +513,51 @@ func (a *DefaultApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Try dynamic routes with parameters
 	if params := a.findRoute(method, path); params != nil {
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.Reset(w, r, params.params, params.pattern, a)
-		if err := params.chain.Execute(c); err != nil {
-			a.ErrorHandler()(c, err)
+		c.Reset(w, r, joinParams(hostParams, params.params), params.pattern, a)
+		a.execute(c, params.chain.exec, path)
+		a.pool.Put(c)
+		return
+	}
+
+	// HEAD falls back to the GET route with the response body discarded
+	if method == http.MethodHead {
+		if exec, ps, pattern := a.resolve(http.MethodGet, path); exec != nil {
+			c := a.pool.Get().(*ctx.DefaultContext)
+			c.Reset(headResponseWriter{w}, r, joinParams(hostParams, ps), pattern, a)
+			a.execute(c, exec, path)
+			a.pool.Put(c)
+			return
 		}
-		c.Finish()
+	}
+
+	// Redirect to the trailing-slash, cleaned or correctly cased path
+	if loc, code := a.redirectLocation(method, path); loc != "" {
+		c := a.pool.Get().(*ctx.DefaultContext)
+		c.Reset(w, r, hostParams, path, a)
+		a.execute(c, func(c Ctx) error { return redirect(c, loc, r.URL.RawQuery, code) }, path)
 		a.pool.Put(c)
 		return
 	}
 
-	// Check if path exists with different method (405 Method Not Allowed)
-	if a.pathExistsWithDifferentMethod(method, path) {
+	// Path exists with other methods: answer OPTIONS or 405 Method Not Allowed
+	if allowed := a.AllowedMethods(path); len(allowed) > 0 {
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.Reset(w, r, nil, path, a)
-		if err := a.MethodNotAllowedHandler()(c); err != nil {
-			a.ErrorHandler()(c, err)
+		c.Reset(w, r, hostParams, path, a)
+		c.SetAllowedMethods(allowed)
+		c.Header("Allow", strings.Join(allowed, ", "))
+		h := a.MethodNotAllowedHandler()
+		if method == http.MethodOptions {
+			h = a.optionsHandler()
 		}
-		c.Finish()
+		a.execute(c, h, path)
 		a.pool.Put(c)
 		return
 	}
 
 	// Not found
 	c := a.pool.Get().(*ctx.DefaultContext)
-	c.Reset(w, r, nil, path, a)
-	if err := a.NotFound(c); err != nil {
-		a.ErrorHandler()(c, err)
-	}
-	c.Finish()
+	c.Reset(w, r, hostParams, path, a)
+	a.execute(c, a.notFoundFor(path), path)
 	a.pool.Put(c)
 }
 
@@ -368,6 +569,27 @@ func (a *DefaultApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 //
 //	_ = fasthttp.ListenAndServe(":8080", a.ServeFastHTTP)
 func (a *DefaultApp) ServeFastHTTP(fctx *fasthttp.RequestCtx) {
+	a.inflight.Add(1)
+	defer a.inflight.Add(-1)
+
+	// Recompile chains if global middleware was added after registration
+	if a.stale.Load() {
+		a.Build()
+	}
+
+	// Dispatch to the app registered for the request host, if any
+	if a.hasHosts.Load() {
+		hostBytes := fctx.Host()
+		if h, hostParams := a.matchHost(*(*string)(unsafe.Pointer(&hostBytes))); h != nil {
+			h.serveFastHTTP(fctx, hostParams)
+			return
+		}
+	}
+	a.serveFastHTTP(fctx, nil)
+}
+
+// serveFastHTTP is serveHTTP for fasthttp requests.
+func (a *DefaultApp) serveFastHTTP(fctx *fasthttp.RequestCtx, hostParams []router.Param) {
 	// Extract method and path using zero-copy string conversion
 	methodBytes := fctx.Method()
 	pathBytes := fctx.Path()
@@ -392,11 +614,8 @@ func (a *DefaultApp) ServeFastHTTP(fctx *fasthttp.RequestCtx) {
 		// Ultra-fast path: direct handler execution (no middleware chain)
 		a.router.mu.RUnlock()
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.ResetFastHTTP(fctx, nil, path, a)
-		if err := simpleHandler(c); err != nil {
-			a.ErrorHandler()(c, err)
-		}
-		c.Finish()
+		c.ResetFastHTTP(fctx, hostParams, path, a)
+		a.execute(c, simpleHandler, path)
 		a.pool.Put(c)
 		return
 	}
@@ -408,11 +627,8 @@ func (a *DefaultApp) ServeFastHTTP(fctx *fasthttp.RequestCtx) {
 	if chain != nil {
 		// Ultra-fast path: execute pre-compiled chain directly
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.ResetFastHTTP(fctx, nil, path, a)
-		if err := chain.Execute(c); err != nil {
-			a.ErrorHandler()(c, err)
-		}
-		c.Finish()
+		c.ResetFastHTTP(fctx, hostParams, path, a)
+		a.execute(c, chain.exec, path)
 		a.pool.Put(c)
 		return
 	}
@@ -420,34 +636,52 @@ func (a *DefaultApp) ServeFastHTTP(fctx *fasthttp.RequestCtx) {
 	// Dynamic route lookup with parameters
 	if params := a.findRoute(method, path); params != nil {
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.ResetFastHTTP(fctx, params.params, params.pattern, a)
-		if err := params.chain.Execute(c); err != nil {
-			a.ErrorHandler()(c, err)
+		c.ResetFastHTTP(fctx, joinParams(hostParams, params.params), params.pattern, a)
+		a.execute(c, params.chain.exec, path)
+		a.pool.Put(c)
+		return
+	}
+
+	// HEAD falls back to the GET route with the response body discarded
+	if method == http.MethodHead {
+		if exec, ps, pattern := a.resolve(http.MethodGet, path); exec != nil {
+			fctx.Response.SkipBody = true
+			c := a.pool.Get().(*ctx.DefaultContext)
+			c.ResetFastHTTP(fctx, joinParams(hostParams, ps), pattern, a)
+			a.execute(c, exec, path)
+			a.pool.Put(c)
+			return
 		}
-		c.Finish()
+	}
+
+	// Redirect to the trailing-slash, cleaned or correctly cased path
+	if loc, code := a.redirectLocation(method, path); loc != "" {
+		c := a.pool.Get().(*ctx.DefaultContext)
+		c.ResetFastHTTP(fctx, hostParams, path, a)
+		a.execute(c, func(c Ctx) error { return redirect(c, loc, string(fctx.URI().QueryString()), code) }, path)
 		a.pool.Put(c)
 		return
 	}
 
-	// Check if path exists with different method (405 Method Not Allowed)
-	if a.pathExistsWithDifferentMethod(method, path) {
+	// Path exists with other methods: answer OPTIONS or 405 Method Not Allowed
+	if allowed := a.AllowedMethods(path); len(allowed) > 0 {
 		c := a.pool.Get().(*ctx.DefaultContext)
-		c.ResetFastHTTP(fctx, nil, path, a)
-		if err := a.MethodNotAllowedHandler()(c); err != nil {
-			a.ErrorHandler()(c, err)
+		c.ResetFastHTTP(fctx, hostParams, path, a)
+		c.SetAllowedMethods(allowed)
+		c.Header("Allow", strings.Join(allowed, ", "))
+		h := a.MethodNotAllowedHandler()
+		if method == http.MethodOptions {
+			h = a.optionsHandler()
 		}
-		c.Finish()
+		a.execute(c, h, path)
 		a.pool.Put(c)
 		return
 	}
 
 	// Not found
 	c := a.pool.Get().(*ctx.DefaultContext)
-	c.ResetFastHTTP(fctx, nil, path, a)
-	if err := a.NotFound(c); err != nil {
-		a.ErrorHandler()(c, err)
-	}
-	c.Finish()
+	c.ResetFastHTTP(fctx, hostParams, path, a)
+	a.execute(c, a.notFoundFor(path), path)
 	a.pool.Put(c)
 }
 
@@ -458,178 +692,56 @@ type routeResult struct {
 	pattern string
 }
 
-// findRoute searches for a route in the dynamic router with caching
+// findRoute searches for a route in the dynamic router with caching.
+// Only successful lookups are cached; unknown paths always walk the tree.
 func (a *DefaultApp) findRoute(method, path string) *routeResult {
 	// Check cache first
 	cacheKey := method + ":" + path
-	a.cacheMutex.RLock()
-	if cached, ok := a.routeCache[cacheKey]; ok {
-		a.cacheMutex.RUnlock()
+	if cached, ok := a.routeCache.get(cacheKey); ok {
 		return cached
 	}
-	a.cacheMutex.RUnlock()
 
-	// Not in cache, do the lookup
+	// Not in cache, walk the method's radix tree
 	a.router.mu.RLock()
-	defer a.router.mu.RUnlock()
-
-	// Look for routes that match the method and have parameters
-	for routeKey, chain := range a.router.static {
-		if !strings.HasPrefix(routeKey, method+":") {
-			continue
-		}
-
-		routePattern := strings.TrimPrefix(routeKey, method+":")
-		if params := matchRoute(routePattern, path); params != nil {
-			result := &routeResult{
-				chain:   chain,
+	root := a.router.dynamic[method]
+	var result *routeResult
+	if root != nil {
+		if node, params := root.lookup(path, make([]router.Param, 0, 4)); node != nil {
+			result = &routeResult{
+				chain:   node.handler,
 				params:  params,
-				pattern: routePattern,
-			}
-
-			// Cache the result (limit cache size to prevent memory leaks)
-			a.cacheMutex.Lock()
-			if len(a.routeCache) < 1000 { // Limit cache size
-				a.routeCache[cacheKey] = result
+				pattern: node.pattern,
 			}
-			a.cacheMutex.Unlock()
-
-			return result
 		}
 	}
+	a.router.mu.RUnlock()
 
-	// Cache the miss too (with nil result)
-	a.cacheMutex.Lock()
-	if len(a.routeCache) < 1000 {
-		a.routeCache[cacheKey] = nil
-	}
-	a.cacheMutex.Unlock()
-
-	return nil
+	a.routeCache.put(cacheKey, result)
+	return result
 }
 
-// matchRoute checks if a route pattern matches a path and extracts parameters
-// Optimized to reduce string allocations
-func matchRoute(pattern, path string) []router.Param {
-	if pattern == path {
-		return nil // exact match, no params
-	}
-
-	// Quick check: if pattern has no parameters, do exact match
-	if !strings.ContainsAny(pattern, ":*") {
-		return nil
-	}
-
-	// Use stack-allocated buffer for small parameter sets
-	var paramBuf [8]router.Param // Most routes have < 8 params
-	params := paramBuf[:0]
-
-	// Parse without allocating slices when possible
-	patternLen := len(pattern)
-	pathLen := len(path)
-	patternIdx := 0
-	pathIdx := 0
-
-	// Skip leading slashes
-	if patternIdx < patternLen && pattern[patternIdx] == '/' {
-		patternIdx++
-	}
-	if pathIdx < pathLen && path[pathIdx] == '/' {
-		pathIdx++
-	}
-
-	for patternIdx < patternLen && pathIdx < pathLen {
-		// Find next segment in pattern
-		segStart := patternIdx
-		for patternIdx < patternLen && pattern[patternIdx] != '/' {
-			patternIdx++
-		}
-		patternSeg := pattern[segStart:patternIdx]
-
-		// Find next segment in path
-		pathSegStart := pathIdx
-		for pathIdx < pathLen && path[pathIdx] != '/' {
-			pathIdx++
-		}
-		pathSeg := path[pathSegStart:pathIdx]
-
-		if len(patternSeg) > 0 && patternSeg[0] == '*' {
-			// Wildcard parameter - captures the rest of the path
-			paramName := patternSeg[1:]
-			remainingPath := path[pathSegStart:]
-			params = append(params, router.Param{
-				Key:   paramName,
-				Value: remainingPath,
-			})
-			return params // wildcard consumes rest of path
-		} else if len(patternSeg) > 0 && patternSeg[0] == ':' {
-			// Parameter segment
-			paramName := patternSeg[1:]
-			params = append(params, router.Param{
-				Key:   paramName,
-				Value: pathSeg,
-			})
-		} else if patternSeg != pathSeg {
-			return nil // segment doesn't match
-		}
-
-		// Skip trailing slash
-		if patternIdx < patternLen && pattern[patternIdx] == '/' {
-			patternIdx++
-		}
-		if pathIdx < pathLen && path[pathIdx] == '/' {
-			pathIdx++
-		}
-	}
-
-	// Check if we consumed all segments correctly
-	if patternIdx != patternLen || pathIdx != pathLen {
-		return nil
+// SetRouteCacheSize sets how many dynamic route lookups are cached, replacing
+// the current cache and resetting its counters. A size of 0 disables the
+// cache. Call it during setup, before the app starts serving requests.
+//
+// Example:
+//
+//	a.SetRouteCacheSize(8192) // many distinct parameterized URLs
+func (a *DefaultApp) SetRouteCacheSize(n int) {
+	a.routeCache = newRouteCache(n)
+	for _, h := range a.hosts {
+		h.app.SetRouteCacheSize(n)
 	}
-
-	return params
 }
 
-// pathExistsWithDifferentMethod checks if a path exists with a different HTTP method
-func (a *DefaultApp) pathExistsWithDifferentMethod(method, path string) bool {
-	a.router.mu.RLock()
-	defer a.router.mu.RUnlock()
-
-	// Check simple handlers
-	for routeKey := range a.router.simple {
-		parts := strings.SplitN(routeKey, ":", 2)
-		if len(parts) == 2 {
-			routeMethod := parts[0]
-			routePath := parts[1]
-
-			// If path matches but method is different
-			if routePath == path && routeMethod != method {
-				return true
-			}
-		}
-	}
-
-	// Check static routes
-	for routeKey := range a.router.static {
-		parts := strings.SplitN(routeKey, ":", 2)
-		if len(parts) == 2 {
-			routeMethod := parts[0]
-			routePath := parts[1]
-
-			// If path matches but method is different
-			if routePath == path && routeMethod != method {
-				return true
-			}
-
-			// Check dynamic routes (with parameters)
-			if routeMethod != method && matchRoute(routePath, path) != nil {
-				return true
-			}
-		}
-	}
-
-	return false
-}
+// RouteCacheStats returns a snapshot of the dynamic route cache counters,
+// useful for tuning SetRouteCacheSize.
+//
+// Example:
+//
+//	s := a.RouteCacheStats()
+//	a.Logger().Info("route cache", "hits", s.Hits, "misses", s.Misses, "size", s.Size)
+func (a *DefaultApp) RouteCacheStats() RouteCacheStats { return a.routeCache.stats() }
 
 // Configuration setters.
 // These set the error, not found, and method-not-allowed handlers used by the app.
@@ -641,9 +753,27 @@ func (a *DefaultApp) SetMethodNotAllowedHandler(h Handler) {
 
 // Getters mirror the setters and are useful when holding App as an interface.
 // They expose the currently configured handlers without exporting struct fields.
-func (a *DefaultApp) ErrorHandler() ErrorHandler       { return a.OnError }
-func (a *DefaultApp) NotFoundHandler() Handler         { return a.NotFound }
-func (a *DefaultApp) MethodNotAllowedHandler() Handler { return a.MethodNA }
+// Apps created by Host fall back to the parent app's handlers.
+func (a *DefaultApp) ErrorHandler() ErrorHandler {
+	if a.OnError == nil && a.parent != nil {
+		return a.parent.ErrorHandler()
+	}
+	return a.OnError
+}
+
+func (a *DefaultApp) NotFoundHandler() Handler {
+	if a.NotFound == nil && a.parent != nil {
+		return a.parent.NotFoundHandler()
+	}
+	return a.NotFound
+}
+
+func (a *DefaultApp) MethodNotAllowedHandler() Handler {
+	if a.MethodNA == nil && a.parent != nil {
+		return a.parent.MethodNotAllowedHandler()
+	}
+	return a.MethodNA
+}
 
 // Default handlers
 func defaultNotFoundHandler(c Ctx) error {
@@ -654,20 +784,27 @@ func defaultMethodNotAllowedHandler(c Ctx) error {
 	return c.String(http.StatusMethodNotAllowed, "Method Not Allowed")
 }
 
+func defaultOptionsHandler(c Ctx) error {
+	return c.String(http.StatusNoContent, "")
+}
+
 // Net/HTTP compatibility methods for seamless integration
 
 // HandleHTTP registers a standard net/http handler for the given method and path.
 // This creates a compatibility wrapper around the net/http handler.
 func (a *DefaultApp) HandleHTTP(method, path string, h http.Handler) {
-	// Wrap the http.Handler to work with our Handler interface
-	wrapper := func(c Ctx) error {
-		// Only works with net/http transport
+	a.addRoute(RouteInfo{Method: method, Pattern: path, Handler: handlerName(h)}, wrapHTTPHandler(h), nil)
+}
+
+// wrapHTTPHandler adapts a net/http handler to a Handler. On fasthttp the
+// handler is served through the context's converted request and writer.
+func wrapHTTPHandler(h http.Handler) Handler {
+	return func(c Ctx) error {
 		if c.Request() != nil && c.ResponseWriter() != nil {
 			h.ServeHTTP(c.ResponseWriter(), c.Request())
 		}
 		return nil
 	}
-	a.handle(method, path, wrapper)
 }
 
 // Mount mounts a net/http handler at the given path for all HTTP methods.
@@ -1,13 +1,18 @@
 package app
 
 import (
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/valyala/fasthttp"
 )
 
 func TestUseNoArgsNoop(t *testing.T) {
@@ -186,6 +191,31 @@ func TestSetLoggerAndLoggerFallback(t *testing.T) {
 	}
 }
 
+func TestSetValidatorAppliesToHostApps(t *testing.T) {
+	a := New().(*DefaultApp)
+	errTaken := errors.New("email taken")
+	a.SetValidator(ctx.ValidatorFunc(func(v any) error { return errTaken }))
+	a.Host("api.example.com").POST("/", func(c Ctx) error {
+		var in struct {
+			Email string `json:"email"`
+		}
+		err := c.BindJSON(&in)
+		if !errors.Is(err, errTaken) || !errors.Is(err, ctx.ErrValidation) {
+			t.Fatalf("err=%v", err)
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"ada@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Host = "api.example.com"
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d", rec.Code)
+	}
+}
+
 func TestUseNoopOnEmpty(t *testing.T) {
 	a := New().(*DefaultApp)
 	// should not panic and should not change middleware length
@@ -196,3 +226,72 @@ func TestUseNoopOnEmpty(t *testing.T) {
 		t.Fatalf("Use() with no args should be no-op")
 	}
 }
+
+func TestUseAfterRegistrationAppliesToEarlierRoutes(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/plain", func(c Ctx) error { return c.String(http.StatusOK, "plain") })
+	a.GET("/users/:id", func(c Ctx) error { return c.String(http.StatusOK, c.Param("id")) })
+	a.GET("/mw", func(c Ctx) error { return c.String(http.StatusOK, "mw") },
+		func(next Handler) Handler { return next })
+	api := a.Host("api.example.com")
+	api.GET("/", func(c Ctx) error { return c.String(http.StatusOK, "api") })
+
+	// Warm the route cache so the old chain would be reused without a rebuild
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	a.Use(func(next Handler) Handler {
+		return func(c Ctx) error {
+			if c.Query("token") == "" {
+				return c.String(http.StatusUnauthorized, "unauthorized")
+			}
+			return next(c)
+		}
+	})
+	if !a.stale.Load() {
+		t.Fatal("Use after registration should mark the app for rebuild")
+	}
+
+	for _, url := range []string{"/plain", "/users/1", "/mw", "http://api.example.com/"} {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: status=%d, want 401", url, rec.Code)
+		}
+	}
+	if a.stale.Load() {
+		t.Fatal("serving should have rebuilt the chains")
+	}
+
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/plain?token=x")
+	fctx.Request.Header.SetMethod(http.MethodGet)
+	a.ServeFastHTTP(&fctx)
+	if fctx.Response.StatusCode() != http.StatusOK || string(fctx.Response.Body()) != "plain" {
+		t.Fatalf("fasthttp: %d %q", fctx.Response.StatusCode(), fctx.Response.Body())
+	}
+
+	for _, r := range a.Routes() {
+		if r.Host == "" && r.Pattern == "/mw" && r.Middlewares != 2 {
+			t.Fatalf("Routes() Middlewares=%d for /mw, want 2", r.Middlewares)
+		}
+	}
+}
+
+func TestBuildIsIdempotentAndNoopWithoutChanges(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.Use(func(next Handler) Handler { return next }) // before any route: nothing to rebuild
+	if a.stale.Load() {
+		t.Fatal("Use before registration should not require a rebuild")
+	}
+	a.GET("/", func(c Ctx) error { return c.String(http.StatusOK, "ok") })
+
+	calls := 0
+	a.Use(func(next Handler) Handler { return func(c Ctx) error { calls++; return next(c) } })
+	a.Build()
+	a.Build()
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || calls != 1 {
+		t.Fatalf("status=%d calls=%d", rec.Code, calls)
+	}
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultRouteCacheSize is the number of dynamic route lookups New() keeps in
+// the route cache. Change it per app with SetRouteCacheSize.
+const DefaultRouteCacheSize = 1024
+
+// routeCacheShards is the number of independently locked LRU shards. Keys are
+// spread across shards by hash so concurrent requests rarely contend.
+const routeCacheShards = 16
+
+// RouteCacheStats is a snapshot of the dynamic route cache counters.
+//
+// Hits and Misses count lookups of parameterized routes only; static routes
+// never consult the cache. Misses include requests that matched no route at
+// all, which are never stored.
+//
+// Example:
+//
+//	s := a.RouteCacheStats()
+//	ratio := float64(s.Hits) / float64(s.Hits+s.Misses)
+//	log.Printf("route cache: %d/%d entries, hit ratio %.2f", s.Size, s.Capacity, ratio)
+type RouteCacheStats struct {
+	Hits      uint64 // lookups served from the cache
+	Misses    uint64 // lookups that walked the route tree
+	Evictions uint64 // entries dropped to respect Capacity
+	Size      int    // entries currently cached
+	Capacity  int    // maximum number of entries (0 = cache disabled)
+}
+
+// routeCache is a sharded, bounded LRU cache of successful dynamic route
+// lookups keyed by "METHOD:path".
+//
+// Only positive results are stored: paths that match no route are never
+// cached, so clients requesting random URLs cannot evict real entries with
+// misses or grow memory usage.
+type routeCache struct {
+	shards    [routeCacheShards]routeCacheShard
+	n         uint32 // shards in use, fewer than routeCacheShards for small capacities
+	capacity  int
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type routeCacheShard struct {
+	mu    sync.Mutex
+	max   int
+	items map[string]*list.Element
+	order list.List // front = most recently used
+}
+
+type routeCacheEntry struct {
+	key    string
+	result *routeResult
+}
+
+// newRouteCache returns a cache holding up to capacity entries. A capacity of
+// zero or less disables caching; get always misses and put is a no-op.
+func newRouteCache(capacity int) *routeCache {
+	if capacity < 0 {
+		capacity = 0
+	}
+	rc := &routeCache{capacity: capacity, n: routeCacheShards}
+	if capacity > 0 && capacity < routeCacheShards {
+		rc.n = uint32(capacity)
+	}
+	// Split capacity exactly: the first capacity%n shards take one more entry
+	per, extra := capacity/int(rc.n), capacity%int(rc.n)
+	for i := range rc.shards {
+		rc.shards[i].max = per
+		if i < extra {
+			rc.shards[i].max++
+		}
+		rc.shards[i].items = make(map[string]*list.Element)
+	}
+	return rc
+}
+
+// shard selects the shard for key using FNV-1a.
+func (rc *routeCache) shard(key string) *routeCacheShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return &rc.shards[h%rc.n]
+}
+
+// get returns the cached result for key and records a hit or miss.
+func (rc *routeCache) get(key string) (*routeResult, bool) {
+	if rc.capacity == 0 {
+		rc.misses.Add(1)
+		return nil, false
+	}
+	s := rc.shard(key)
+	s.mu.Lock()
+	el, ok := s.items[key]
+	if ok {
+		s.order.MoveToFront(el)
+	}
+	s.mu.Unlock()
+	if !ok {
+		rc.misses.Add(1)
+		return nil, false
+	}
+	rc.hits.Add(1)
+	return el.Value.(*routeCacheEntry).result, true
+}
+
+// put stores a successful lookup, evicting the least recently used entry of
+// the shard when it is full. Nil results are ignored.
+func (rc *routeCache) put(key string, result *routeResult) {
+	if rc.capacity == 0 || result == nil {
+		return
+	}
+	s := rc.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[key]; ok {
+		el.Value.(*routeCacheEntry).result = result
+		s.order.MoveToFront(el)
+		return
+	}
+	if s.order.Len() >= s.max {
+		if oldest := s.order.Back(); oldest != nil {
+			s.order.Remove(oldest)
+			delete(s.items, oldest.Value.(*routeCacheEntry).key)
+			rc.evictions.Add(1)
+		}
+	}
+	s.items[key] = s.order.PushFront(&routeCacheEntry{key: key, result: result})
+}
+
+// purge drops every entry. Counters are preserved.
+func (rc *routeCache) purge() {
+	for i := range rc.shards {
+		s := &rc.shards[i]
+		s.mu.Lock()
+		s.items = make(map[string]*list.Element)
+		s.order.Init()
+		s.mu.Unlock()
+	}
+}
+
+// stats returns a snapshot of the cache counters.
+func (rc *routeCache) stats() RouteCacheStats {
+	size := 0
+	for i := range rc.shards {
+		s := &rc.shards[i]
+		s.mu.Lock()
+		size += s.order.Len()
+		s.mu.Unlock()
+	}
+	return RouteCacheStats{
+		Hits:      rc.hits.Load(),
+		Misses:    rc.misses.Load(),
+		Evictions: rc.evictions.Load(),
+		Size:      size,
+		Capacity:  rc.capacity,
+	}
+}
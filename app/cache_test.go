@@ -0,0 +1,147 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRouteCacheLRUEviction(t *testing.T) {
+	rc := newRouteCache(routeCacheShards) // one entry per shard
+	r1, r2 := &routeResult{pattern: "1"}, &routeResult{pattern: "2"}
+
+	// Find two keys landing in the same shard
+	k1 := "GET:/a"
+	var k2 string
+	for i := 0; ; i++ {
+		k2 = fmt.Sprintf("GET:/b%d", i)
+		if rc.shard(k2) == rc.shard(k1) {
+			break
+		}
+	}
+
+	rc.put(k1, r1)
+	if got, ok := rc.get(k1); !ok || got != r1 {
+		t.Fatalf("expected hit for %s", k1)
+	}
+	rc.put(k2, r2)
+	if _, ok := rc.get(k1); ok {
+		t.Fatalf("expected %s to be evicted", k1)
+	}
+	if got, ok := rc.get(k2); !ok || got != r2 {
+		t.Fatalf("expected hit for %s", k2)
+	}
+
+	s := rc.stats()
+	if s.Hits != 2 || s.Misses != 1 || s.Evictions != 1 || s.Size != 1 || s.Capacity != routeCacheShards {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestRouteCacheIgnoresMissesAndDisabled(t *testing.T) {
+	rc := newRouteCache(64)
+	rc.put("GET:/nope", nil)
+	if s := rc.stats(); s.Size != 0 {
+		t.Fatalf("negative result cached: %+v", s)
+	}
+
+	off := newRouteCache(0)
+	off.put("GET:/x", &routeResult{})
+	if _, ok := off.get("GET:/x"); ok {
+		t.Fatalf("disabled cache returned a hit")
+	}
+	if s := off.stats(); s.Size != 0 || s.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+}
+
+func TestAppRouteCacheStatsAndUnknownPaths(t *testing.T) {
+	a := New()
+	a.GET("/users/:id", func(c Ctx) error { return c.String(http.StatusOK, c.Param("id")) })
+
+	// A scanner hitting random URLs must not fill the cache
+	for i := 0; i < 5000; i++ {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/scan/%d", i), nil))
+	}
+	if s := a.RouteCacheStats(); s.Size != 0 {
+		t.Fatalf("misses were cached: %+v", s)
+	}
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+		if rec.Body.String() != "7" {
+			t.Fatalf("body=%q", rec.Body.String())
+		}
+	}
+	s := a.RouteCacheStats()
+	if s.Size != 1 || s.Hits != 2 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+
+	a.SetRouteCacheSize(0)
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/8", nil))
+	if rec.Body.String() != "8" || a.RouteCacheStats().Size != 0 {
+		t.Fatalf("disabled cache: body=%q stats=%+v", rec.Body.String(), a.RouteCacheStats())
+	}
+}
+
+func TestRouteCachePurgedOnRegistration(t *testing.T) {
+	a := New()
+	a.GET("/p/*rest", func(c Ctx) error { return c.String(http.StatusOK, "catch-all") })
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/p/x", nil))
+	if rec.Body.String() != "catch-all" {
+		t.Fatalf("body=%q", rec.Body.String())
+	}
+
+	a.GET("/p/:id", func(c Ctx) error { return c.String(http.StatusOK, "param") })
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/p/x", nil))
+	if rec.Body.String() != "param" {
+		t.Fatalf("stale cache entry served: %q", rec.Body.String())
+	}
+}
+
+func TestRouteCacheConcurrentAccess(t *testing.T) {
+	a := New()
+	a.GET("/items/:id", func(c Ctx) error { return c.String(http.StatusOK, c.Param("id")) })
+	a.SetRouteCacheSize(32)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				id := fmt.Sprintf("%d", (g*200+i)%100)
+				rec := httptest.NewRecorder()
+				a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items/"+id, nil))
+				if rec.Body.String() != id {
+					t.Errorf("body=%q want %q", rec.Body.String(), id)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if s := a.RouteCacheStats(); s.Size > 32 {
+		t.Fatalf("cache exceeded capacity: %+v", s)
+	}
+}
+
+func TestRouteCacheRespectsCapacity(t *testing.T) {
+	for _, capacity := range []int{1, 5, 15, 16, 20, 33, 100} {
+		rc := newRouteCache(capacity)
+		for i := 0; i < 10*capacity+routeCacheShards; i++ {
+			rc.put(fmt.Sprintf("GET:/p%d", i), &routeResult{})
+		}
+		if s := rc.stats(); s.Size != capacity {
+			t.Fatalf("capacity %d: size=%d", capacity, s.Size)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// paramConstraint restricts the values a route parameter accepts. It is
+// written in angle brackets after the parameter name:
+//
+//	/users/:id<int>
+//	/files/:name<[a-z0-9_-]+>
+//	/v:version<\d+>/items
+//
+// Built-in type names are int, uint, float, alpha, alnum and uuid. Any other
+// text is compiled as a regular expression that must match the whole value.
+// A request whose segment does not satisfy the constraint does not match the
+// route, so routing falls through to other candidates or to NotFound.
+type paramConstraint struct {
+	raw   string
+	match func(string) bool
+}
+
+// builtinConstraints maps the typed constraint names to their matchers.
+var builtinConstraints = map[string]func(string) bool{
+	"int": func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	},
+	"uint": func(s string) bool {
+		_, err := strconv.ParseUint(s, 10, 64)
+		return err == nil
+	},
+	"float": func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	},
+	"alpha": func(s string) bool {
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') {
+				return false
+			}
+		}
+		return true
+	},
+	"alnum": func(s string) bool {
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+				return false
+			}
+		}
+		return true
+	},
+	"uuid": func(s string) bool {
+		if len(s) != 36 {
+			return false
+		}
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch i {
+			case 8, 13, 18, 23:
+				if c != '-' {
+					return false
+				}
+			default:
+				if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+					return false
+				}
+			}
+		}
+		return true
+	},
+}
+
+// constraintCache shares compiled constraints between routes and URL calls
+// (raw text -> *paramConstraint).
+var constraintCache sync.Map
+
+// newParamConstraint compiles raw into a constraint. It panics with a message
+// naming pattern if raw is neither a built-in type nor a valid regexp.
+func newParamConstraint(raw, pattern string) *paramConstraint {
+	if pc, ok := constraintCache.Load(raw); ok {
+		return pc.(*paramConstraint)
+	}
+	pc := &paramConstraint{raw: raw}
+	if fn, ok := builtinConstraints[raw]; ok {
+		pc.match = fn
+	} else {
+		re, err := regexp.Compile(`^(?:` + raw + `)$`)
+		if err != nil {
+			panic("flash: route '" + pattern + "': invalid constraint <" + raw + ">: " + err.Error())
+		}
+		pc.match = re.MatchString
+	}
+	constraintCache.Store(raw, pc)
+	return pc
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuiltinConstraints(t *testing.T) {
+	tests := []struct {
+		raw  string
+		ok   []string
+		fail []string
+	}{
+		{"int", []string{"0", "-12", "42"}, []string{"abc", "1.5", "9999999999999999999999"}},
+		{"uint", []string{"0", "42"}, []string{"-1", "x"}},
+		{"float", []string{"1.5", "-2", "3e4"}, []string{"x", "1.2.3"}},
+		{"alpha", []string{"abc", "ABC"}, []string{"a1", "a-b"}},
+		{"alnum", []string{"a1B2"}, []string{"a_1", "a b"}},
+		{"uuid", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567e89b12d3a456426614174000", "zzze4567-e89b-12d3-a456-426614174000"}},
+		{`[a-z0-9_-]+`, []string{"my_file-1"}, []string{"My", "a.b"}},
+	}
+	for _, tt := range tests {
+		pc := newParamConstraint(tt.raw, "/test")
+		for _, v := range tt.ok {
+			if !pc.match(v) {
+				t.Fatalf("<%s> rejected %q", tt.raw, v)
+			}
+		}
+		for _, v := range tt.fail {
+			if pc.match(v) {
+				t.Fatalf("<%s> accepted %q", tt.raw, v)
+			}
+		}
+	}
+}
+
+func TestConstrainedRoutesFallThrough(t *testing.T) {
+	a := New()
+	a.GET("/users/:id<int>", func(c Ctx) error { return c.String(http.StatusOK, "id="+c.Param("id")) })
+	a.GET("/users/:slug<[a-z-]+>", func(c Ctx) error { return c.String(http.StatusOK, "slug="+c.Param("slug")) })
+	a.GET("/files/:name<[a-z0-9_-]+>", func(c Ctx) error { return c.String(http.StatusOK, "file="+c.Param("name")) })
+	a.GET("/v:version<\\d+>/items", func(c Ctx) error { return c.String(http.StatusOK, "v="+c.Param("version")) })
+	a.GET("/v:version<\\d+>/items/:id", func(c Ctx) error { return c.String(http.StatusOK, c.Route()) })
+	a.GET("/:page", func(c Ctx) error { return c.String(http.StatusOK, "page="+c.Param("page")) })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42", http.StatusOK, "id=42"},
+		{"/users/jane-doe", http.StatusOK, "slug=jane-doe"},
+		{"/users/Jane!", http.StatusNotFound, ""},
+		{"/files/report_1", http.StatusOK, "file=report_1"},
+		{"/files/Report.pdf", http.StatusNotFound, ""},
+		{"/v2/items", http.StatusOK, "v=2"},
+		{"/v2/items/9", http.StatusOK, "/v:version<\\d+>/items/:id"},
+		{"/vx/items", http.StatusNotFound, ""},
+		{"/v2", http.StatusOK, "page=v2"},
+		{"/about", http.StatusOK, "page=about"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Fatalf("%s: status=%d want %d", tt.path, rec.Code, tt.status)
+		}
+		if tt.body != "" && rec.Body.String() != tt.body {
+			t.Fatalf("%s: body=%q want %q", tt.path, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestOverlappingConstraintsUseRegistrationOrder(t *testing.T) {
+	intFirst := New()
+	intFirst.GET("/:id<int>", func(c Ctx) error { return c.String(http.StatusOK, "int") })
+	intFirst.GET("/:hex<[0-9a-f]+>", func(c Ctx) error { return c.String(http.StatusOK, "hex") })
+	hexFirst := New()
+	hexFirst.GET("/:hex<[0-9a-f]+>", func(c Ctx) error { return c.String(http.StatusOK, "hex") })
+	hexFirst.GET("/:id<int>", func(c Ctx) error { return c.String(http.StatusOK, "int") })
+
+	tests := []struct {
+		app  App
+		path string
+		body string
+	}{
+		{intFirst, "/12", "int"},
+		{intFirst, "/1a", "hex"},
+		{hexFirst, "/12", "hex"},
+		{hexFirst, "/1a", "hex"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.app.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Fatalf("%s: body=%q want %q", tt.path, rec.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestConstraintsInURL(t *testing.T) {
+	a := New()
+	a.GET("/v:version<\\d+>/users/:id<int>", func(c Ctx) error { return nil }).Name("user")
+
+	if u, err := a.URL("user", "version", "2", "id", "7"); err != nil || u != "/v2/users/7" {
+		t.Fatalf("URL=%q err=%v", u, err)
+	}
+	if _, err := a.URL("user", "version", "2", "id", "x"); !errors.Is(err, ErrRouteParams) {
+		t.Fatalf("expected constraint error, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"errors"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// MapError makes requests whose handler returns target, or an error wrapping
+// it (errors.Is), respond with status. Domain packages can then return their
+// own errors without importing HTTP concerns.
+//
+// Mappings are consulted in registration order, before the error handler
+// runs: the first match replaces the error with an *HTTPError carrying the
+// status and wrapping the original error, so the default error handler and
+// ProblemErrorHandler respond with it and custom handlers can still use
+// errors.Is. An *HTTPError returned by the handler takes precedence over any
+// mapping. Apps created by Host use the mappings of the App.
+//
+// Example:
+//
+//	a.MapError(sql.ErrNoRows, http.StatusNotFound)
+//	a.MapError(context.DeadlineExceeded, http.StatusGatewayTimeout)
+//	a.MapError(ctx.ErrFieldUnexpected, http.StatusBadRequest)
+func (a *DefaultApp) MapError(target error, status int) {
+	a.MapErrorFunc(func(err error) (int, any, bool) {
+		if errors.Is(err, target) {
+			return status, nil, true
+		}
+		return 0, nil, false
+	})
+}
+
+// MapErrorFunc registers a mapping that reports, for an error returned by a
+// handler, the status and optional JSON body to respond with. A nil body uses
+// the error handler's default body. See MapError for how mappings apply and
+// MapErrorAs for a typed variant.
+func (a *DefaultApp) MapErrorFunc(fn func(err error) (status int, body any, ok bool)) {
+	a.errorMaps = append(a.errorMaps, fn)
+}
+
+// MapErrorAs registers a mapping for errors of type T anywhere in the error
+// chain (errors.As). fn returns the status and an optional JSON body.
+//
+// Example:
+//
+//	app.MapErrorAs(a, func(e *store.ConflictError) (int, any) {
+//		return http.StatusConflict, map[string]string{"error": "conflict", "key": e.Key}
+//	})
+//	app.MapErrorAs(a, func(fe ctx.FieldErrors) (int, any) {
+//		return http.StatusUnprocessableEntity, nil
+//	})
+func MapErrorAs[T error](a App, fn func(T) (int, any)) {
+	a.MapErrorFunc(func(err error) (int, any, bool) {
+		var target T
+		if !errors.As(err, &target) {
+			return 0, nil, false
+		}
+		status, body := fn(target)
+		return status, body, true
+	})
+}
+
+// mapError applies the first matching MapError mapping to err. A
+// *ctx.MediaTypeError or *websocket.HandshakeError left unmapped becomes an
+// *HTTPError with its status.
+func (a *DefaultApp) mapError(err error) error {
+	root := a
+	if a.parent != nil {
+		root = a.parent // apps created by Host use the App's mappings
+	}
+	var he *HTTPError
+	if err == nil || errors.As(err, &he) {
+		return err
+	}
+	for _, m := range root.errorMaps {
+		if status, body, ok := m(err); ok {
+			return &HTTPError{Status: status, Err: err, Body: body}
+		}
+	}
+	var mte *ctx.MediaTypeError
+	if errors.As(err, &mte) {
+		return &HTTPError{Status: mte.Status, Err: err}
+	}
+	if he := handshakeError(err); he != nil {
+		return he
+	}
+	return err
+}
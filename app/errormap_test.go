@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+type conflictError struct{ key string }
+
+func (e *conflictError) Error() string { return "conflict on " + e.key }
+
+func TestMapError(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.MapError(sql.ErrNoRows, http.StatusNotFound)
+	a.MapError(context.DeadlineExceeded, http.StatusGatewayTimeout)
+	a.MapError(ctx.ErrFieldUnexpected, http.StatusBadRequest)
+	MapErrorAs(a, func(e *conflictError) (int, any) {
+		return http.StatusConflict, map[string]string{"key": e.key}
+	})
+
+	var handled error
+	a.GET("/missing", func(c Ctx) error { return fmt.Errorf("load user: %w", sql.ErrNoRows) })
+	a.GET("/slow", func(c Ctx) error { return context.DeadlineExceeded })
+	a.GET("/conflict", func(c Ctx) error { return fmt.Errorf("save: %w", &conflictError{key: "email"}) })
+	a.GET("/explicit", func(c Ctx) error {
+		return NewError(http.StatusGone, "gone").WithCause(sql.ErrNoRows)
+	})
+	a.GET("/other", func(c Ctx) error { return errors.New("boom") })
+	a.POST("/bind", func(c Ctx) error {
+		var in struct {
+			Name string `json:"name"`
+		}
+		return c.BindJSON(&in)
+	})
+
+	tests := []struct {
+		method, path string
+		body         string
+		status       int
+		resp         string
+	}{
+		{http.MethodGet, "/missing", "", http.StatusNotFound, `{"message":"Not Found"}`},
+		{http.MethodGet, "/slow", "", http.StatusGatewayTimeout, `{"message":"Gateway Timeout"}`},
+		{http.MethodGet, "/conflict", "", http.StatusConflict, `{"key":"email"}`},
+		{http.MethodGet, "/explicit", "", http.StatusGone, `{"code":"gone","message":"Gone"}`},
+		{http.MethodGet, "/other", "", http.StatusInternalServerError, "Internal Server Error"},
+		{http.MethodPost, "/bind", `{"name":"Ada","admin":true}`, http.StatusBadRequest, `{"message":"Bad Request"}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != tt.resp {
+			t.Fatalf("%s: got %d %q", tt.path, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Custom error handlers still see the original error through the mapping
+	a.SetErrorHandler(func(c Ctx, err error) { handled = err })
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	var he *HTTPError
+	if !errors.Is(handled, sql.ErrNoRows) || !errors.As(handled, &he) || he.Status != http.StatusNotFound {
+		t.Fatalf("handled=%v", handled)
+	}
+}
+
+func TestMapErrorAppliesToHostApps(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.Host("api.example.com").GET("/", func(c Ctx) error { return sql.ErrNoRows })
+	a.MapError(sql.ErrNoRows, http.StatusNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "api.example.com"
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("got %d", rec.Code)
+	}
+}
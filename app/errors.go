@@ -1,18 +1,25 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/goflash/flash/v2/ctx"
 )
 
 // defaultErrorHandler is the built-in error handler used by New() unless
-// replaced via SetErrorHandler. It writes a generic 500 Internal Server Error
-// if the response has not already started.
+// replaced via SetErrorHandler. It writes the response described by an
+// *HTTPError found in err's chain, or a generic 500 Internal Server Error, if
+// the response has not already started.
 //
 // Behavior:
 //   - If the handler/middleware already wrote the header, this function does nothing
 //     to avoid corrupting a streaming or partially-sent response.
+//   - If errors.As finds an *HTTPError, its headers are set and it is written
+//     with its status as JSON: HTTPError.Body if set, otherwise
+//     {"code":"user_not_found","message":"User not found"}. The cause
+//     (HTTPError.Err) is never sent. Errors matched by MapError arrive here
+//     as an *HTTPError too.
 //   - Otherwise, it writes status 500 with a plain text body of
 //     http.StatusText(http.StatusInternalServerError).
 //
@@ -37,15 +44,32 @@ func defaultErrorHandler(c ctx.Ctx, err error) {
 	if c.WroteHeader() {
 		return
 	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		c.SetHeadersFromMap(he.Header)
+		if he.Body != nil {
+			_ = c.Status(he.StatusCode()).JSON(he.Body)
+			return
+		}
+		_ = c.Status(he.StatusCode()).JSON(httpErrorBody{Code: he.Code, Message: he.PublicMessage()})
+		return
+	}
 	_ = c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 }
 
+// httpErrorBody is the JSON body written by defaultErrorHandler for an HTTPError.
+type httpErrorBody struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
 // methodNotAllowedHandler returns a handler for 405 Method Not Allowed responses.
 // It is installed by New() and can be replaced via SetMethodNotAllowedHandler.
 //
 // The default behavior simply writes status 405 with a plain text body, without
-// attempting content negotiation. Applications can swap this for a JSON or HTML
-// variant, or for adding CORS/Allow headers as needed.
+// attempting content negotiation. The router sets the Allow header before any
+// MethodNotAllowed handler runs, so applications can swap this for a JSON or
+// HTML variant without recomputing the allowed methods.
 //
 // Example (custom handler):
 //
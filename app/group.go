@@ -1,6 +1,10 @@
 package app
 
-import "net/http"
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
 
 // Group defines a group of routes with a common URL prefix and optional
 // middleware. Groups allow modular organization of related routes and sharing of
@@ -32,6 +36,8 @@ type Group struct {
 	app        *DefaultApp  // parent app
 	prefix     string       // route prefix
 	middleware []Middleware // group-level middleware
+	namePrefix string       // prepended to route names (see Name)
+	host       bool         // root group of a host; Use adds to the host app
 }
 
 // Group creates a new route group with the given prefix and optional middleware.
@@ -63,7 +69,16 @@ func (a *DefaultApp) Group(prefix string, mw ...Middleware) *Group {
 //	api := a.Group("/api")
 //	api.Use(Auth, Audit)
 //	api.GET("/users", ListUsers) // order: global -> Auth -> Audit -> handler
-func (g *Group) Use(mw ...Middleware) { g.middleware = append(g.middleware, mw...) }
+//
+// On the group returned by Host, Use adds to the host's middleware stack
+// instead, which also applies to routes registered before.
+func (g *Group) Use(mw ...Middleware) {
+	if g.host {
+		g.app.Use(mw...)
+		return
+	}
+	g.middleware = append(g.middleware, mw...)
+}
 
 // Group creates a nested route group inheriting the parent's prefix and
 // middleware. Additional middleware can be provided for the nested group.
@@ -80,7 +95,7 @@ func (g *Group) Use(mw ...Middleware) { g.middleware = append(g.middleware, mw..
 //	admin.GET("/stats", Stats, Trace)
 //	// order: global -> Auth -> Audit -> AdminOnly -> Trace -> Stats
 func (g *Group) Group(prefix string, mw ...Middleware) *Group {
-	child := &Group{app: g.app, prefix: joinPath(g.prefix, prefix)}
+	child := &Group{app: g.app, prefix: joinPath(g.prefix, prefix), namePrefix: g.namePrefix}
 	child.middleware = append(child.middleware, g.middleware...)
 	if len(mw) > 0 {
 		child.middleware = append(child.middleware, mw...)
@@ -88,6 +103,36 @@ func (g *Group) Group(prefix string, mw ...Middleware) *Group {
 	return child
 }
 
+// Name appends prefix to the group's route name prefix. Routes named on the
+// group (and on groups nested after this call) get the prefix prepended,
+// keeping names unique across modules.
+//
+// Example:
+//
+//	api := a.Group("/api").Name("api.")
+//	api.GET("/users/:id", ShowUser).Name("users.show")
+//	u, _ := a.URL("api.users.show", "id", "42") // "/api/users/42"
+func (g *Group) Name(prefix string) *Group {
+	g.namePrefix += prefix
+	return g
+}
+
+// Route creates a nested group for prefix and passes it to fn, scoping a block
+// of related registrations. It returns the nested group.
+//
+// Example:
+//
+//	a.Group("/api").Route("/users", func(users *app.Group) {
+//		users.Use(Auth)
+//		users.GET("", ListUsers)
+//		users.GET("/:id", ShowUser)
+//	})
+func (g *Group) Route(prefix string, fn func(*Group), mw ...Middleware) *Group {
+	child := g.Group(prefix, mw...)
+	fn(child)
+	return child
+}
+
 // handle registers a handler for the given HTTP method and relative path on the
 // group. All group and route-specific middleware are applied.
 //
@@ -99,10 +144,13 @@ func (g *Group) Group(prefix string, mw ...Middleware) *Group {
 //
 //	g.handle(http.MethodDelete, "/users/:id", DeleteUser)
 //	// is equivalent to g.DELETE("/users/:id", DeleteUser)
-func (g *Group) handle(method, p string, h Handler, mws ...Middleware) {
+func (g *Group) handle(method, p string, h Handler, mws ...Middleware) *Route {
 	all := append([]Middleware{}, g.middleware...)
 	all = append(all, mws...)
-	g.app.handle(method, joinPath(g.prefix, p), h, all...)
+	info := RouteInfo{Method: method, Pattern: joinPath(g.prefix, p), Handler: handlerName(h), Group: g.prefix}
+	r := g.app.addRoute(info, h, all)
+	r.namePrefix = g.namePrefix
+	return r
 }
 
 // GET registers a handler for HTTP GET requests on the group's prefix + path.
@@ -117,7 +165,9 @@ func (g *Group) handle(method, p string, h Handler, mws ...Middleware) {
 //
 //	api.GET("/users/:id", ShowUser, Trace)
 //	// handler sees c.Param("id"); order: global -> group -> Trace -> ShowUser
-func (g *Group) GET(p string, h Handler, mws ...Middleware) { g.handle(http.MethodGet, p, h, mws...) }
+func (g *Group) GET(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodGet, p, h, mws...)
+}
 
 // POST registers a handler for HTTP POST requests on the group's prefix + path.
 // Optionally accepts route-specific middleware.
@@ -127,7 +177,9 @@ func (g *Group) GET(p string, h Handler, mws ...Middleware) { g.handle(http.Meth
 //
 //	api.POST("/users", CreateUser, CSRF)
 //	// order: global -> group -> CSRF -> CreateUser
-func (g *Group) POST(p string, h Handler, mws ...Middleware) { g.handle(http.MethodPost, p, h, mws...) }
+func (g *Group) POST(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodPost, p, h, mws...)
+}
 
 // PUT registers a handler for HTTP PUT requests on the group's prefix + path.
 // Optionally accepts route-specific middleware.
@@ -136,7 +188,9 @@ func (g *Group) POST(p string, h Handler, mws ...Middleware) { g.handle(http.Met
 // Example:
 //
 //	api.PUT("/users/:id", ReplaceUser)
-func (g *Group) PUT(p string, h Handler, mws ...Middleware) { g.handle(http.MethodPut, p, h, mws...) }
+func (g *Group) PUT(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodPut, p, h, mws...)
+}
 
 // PATCH registers a handler for HTTP PATCH requests on the group's prefix + path.
 // Optionally accepts route-specific middleware.
@@ -145,8 +199,8 @@ func (g *Group) PUT(p string, h Handler, mws ...Middleware) { g.handle(http.Meth
 // Example:
 //
 //	api.PATCH("/users/:id", UpdateUserEmail)
-func (g *Group) PATCH(p string, h Handler, mws ...Middleware) {
-	g.handle(http.MethodPatch, p, h, mws...)
+func (g *Group) PATCH(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodPatch, p, h, mws...)
 }
 
 // DELETE registers a handler for HTTP DELETE requests on the group's prefix + path.
@@ -155,8 +209,8 @@ func (g *Group) PATCH(p string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	api.DELETE("/users/:id", DeleteUser, Audit)
-func (g *Group) DELETE(p string, h Handler, mws ...Middleware) {
-	g.handle(http.MethodDelete, p, h, mws...)
+func (g *Group) DELETE(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodDelete, p, h, mws...)
 }
 
 // OPTIONS registers a handler for HTTP OPTIONS requests on the group's prefix + path.
@@ -166,8 +220,8 @@ func (g *Group) DELETE(p string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	api.OPTIONS("/users", Preflight)
-func (g *Group) OPTIONS(p string, h Handler, mws ...Middleware) {
-	g.handle(http.MethodOptions, p, h, mws...)
+func (g *Group) OPTIONS(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodOptions, p, h, mws...)
 }
 
 // HEAD registers a handler for HTTP HEAD requests on the group's prefix + path.
@@ -177,4 +231,180 @@ func (g *Group) OPTIONS(p string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	api.HEAD("/health", HeadHealth)
-func (g *Group) HEAD(p string, h Handler, mws ...Middleware) { g.handle(http.MethodHead, p, h, mws...) }
+func (g *Group) HEAD(p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(http.MethodHead, p, h, mws...)
+}
+
+// ANY registers a handler for all common HTTP methods (GET, POST, PUT, PATCH,
+// DELETE, OPTIONS, HEAD) on the group's prefix + path.
+//
+// Example:
+//
+//	api.ANY("/webhook", Webhook)
+func (g *Group) ANY(p string, h Handler, mws ...Middleware) *Route {
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodHead}
+	var r *Route
+	for _, m := range methods {
+		r = g.handle(m, p, h, mws...)
+	}
+	r.methods = methods
+	return r
+}
+
+// Handle registers a handler for a custom HTTP method on the group's
+// prefix + path.
+//
+// Example:
+//
+//	dav.Handle("PROPFIND", "/files/*path", PropFind)
+func (g *Group) Handle(method, p string, h Handler, mws ...Middleware) *Route {
+	return g.handle(method, p, h, mws...)
+}
+
+// WS registers a WebSocket handler on the group's prefix + path. Group
+// middleware applies; see App.WS.
+//
+// Example:
+//
+//	live := a.Group("/live", RequireUser)
+//	live.WS("/dashboard", DashboardFeed)
+func (g *Group) WS(p string, h WSHandler, mws ...Middleware) *Route {
+	all := append([]Middleware{}, g.middleware...)
+	all = append(all, mws...)
+	info := RouteInfo{Method: http.MethodGet, Pattern: joinPath(g.prefix, p), Handler: handlerName(h), Group: g.prefix}
+	r := g.app.addRoute(info, g.app.wsHandler(h), all)
+	r.namePrefix = g.namePrefix
+	return r
+}
+
+// HandleHTTP registers a standard net/http handler for the given method on the
+// group's prefix + path. Group middleware applies.
+//
+// Example:
+//
+//	api.HandleHTTP(http.MethodGet, "/metrics", promhttp.Handler())
+func (g *Group) HandleHTTP(method, p string, h http.Handler) {
+	full := joinPath(g.prefix, p)
+	info := RouteInfo{Method: method, Pattern: full, Handler: handlerName(h), Group: g.prefix}
+	g.app.addRoute(info, wrapHTTPHandler(h), append([]Middleware{}, g.middleware...))
+}
+
+// Mount mounts a net/http handler at the group's prefix + path for all common
+// HTTP methods.
+//
+// Example:
+//
+//	admin.Mount("/debug", http.DefaultServeMux)
+func (g *Group) Mount(p string, h http.Handler) {
+	methods := []string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodHead,
+	}
+	for _, method := range methods {
+		g.HandleHTTP(method, p, h)
+	}
+}
+
+// Static serves files from dir under the group's prefix + prefix.
+//
+// Example:
+//
+//	web := a.Group("/web")
+//	web.Static("/assets", "./public") // GET /web/assets/app.css -> ./public/app.css
+func (g *Group) Static(prefix, dir string) {
+	stripPrefix := http.StripPrefix(joinPath(g.prefix, prefix), http.FileServer(http.Dir(dir)))
+	g.HandleHTTP(http.MethodGet, strings.TrimSuffix(prefix, "/")+"/*filepath", stripPrefix)
+}
+
+// groupScope holds the handlers a group overrides for requests under prefix.
+type groupScope struct {
+	prefix   string
+	notFound Handler
+	onError  ErrorHandler
+}
+
+// SetNotFoundHandler sets the handler for requests under the group's prefix
+// that match no route. For groups returned by Host it applies to every
+// unmatched request for that host. The longest matching group prefix wins;
+// other requests use the App's NotFound handler.
+//
+// Example:
+//
+//	api := a.Group("/api")
+//	api.SetNotFoundHandler(func(c flash.Ctx) error {
+//		return c.Status(http.StatusNotFound).JSON(map[string]string{"error": "not found"})
+//	})
+func (g *Group) SetNotFoundHandler(h Handler) {
+	g.scope(func(s *groupScope) { s.notFound = h })
+}
+
+// SetErrorHandler sets the handler for errors returned by handlers serving
+// requests under the group's prefix, including its NotFound handler. The
+// longest matching group prefix wins; other requests use the App's
+// ErrorHandler.
+//
+// Example:
+//
+//	api.SetErrorHandler(func(c flash.Ctx, err error) {
+//		_ = c.Status(http.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+//	})
+func (g *Group) SetErrorHandler(h ErrorHandler) {
+	g.scope(func(s *groupScope) { s.onError = h })
+}
+
+// scope applies set to the app's scope entry for the group's prefix, creating
+// it when needed.
+func (g *Group) scope(set func(*groupScope)) {
+	a := g.app
+	a.router.mu.Lock()
+	defer a.router.mu.Unlock()
+	for i := range a.scopes {
+		if a.scopes[i].prefix == g.prefix {
+			set(&a.scopes[i])
+			return
+		}
+	}
+	a.scopes = append(a.scopes, groupScope{prefix: g.prefix})
+	set(&a.scopes[len(a.scopes)-1])
+	sort.SliceStable(a.scopes, func(i, j int) bool {
+		return len(a.scopes[i].prefix) > len(a.scopes[j].prefix)
+	})
+}
+
+// notFoundFor returns the NotFound handler for path: the handler of the
+// longest group prefix containing path, or the App's.
+func (a *DefaultApp) notFoundFor(path string) Handler {
+	if len(a.scopes) > 0 {
+		a.router.mu.RLock()
+		defer a.router.mu.RUnlock()
+		for _, s := range a.scopes {
+			if s.notFound != nil && hasPathPrefix(path, s.prefix) {
+				return s.notFound
+			}
+		}
+	}
+	return a.NotFoundHandler()
+}
+
+// errorHandlerFor returns the ErrorHandler for path: the handler of the
+// longest group prefix containing path, or the App's.
+func (a *DefaultApp) errorHandlerFor(path string) ErrorHandler {
+	if len(a.scopes) > 0 {
+		a.router.mu.RLock()
+		defer a.router.mu.RUnlock()
+		for _, s := range a.scopes {
+			if s.onError != nil && hasPathPrefix(path, s.prefix) {
+				return s.onError
+			}
+		}
+	}
+	return a.ErrorHandler()
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it.
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "/" {
+		return true
+	}
+	return strings.HasPrefix(path, prefix) && (len(path) == len(prefix) || path[len(prefix)] == '/')
+}
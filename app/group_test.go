@@ -1,8 +1,12 @@
 package app
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -67,3 +71,102 @@ func TestGroupMethodHelpersCoverage(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupParityHelpers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New()
+	g := a.Group("/g", func(next Handler) Handler {
+		return func(c Ctx) error { c.Header("X-Group", "1"); return next(c) }
+	})
+	g.ANY("/any", func(c Ctx) error { return c.String(http.StatusOK, c.Method()) }).Name("any")
+	g.Handle("PURGE", "/cache", func(c Ctx) error { return c.String(http.StatusOK, "purged") })
+	g.HandleHTTP(http.MethodGet, "/std", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "std") }))
+	g.Mount("/m", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, r.Method) }))
+	g.Static("/assets", dir)
+
+	tests := []struct{ method, path, want string }{
+		{http.MethodPatch, "/g/any", "PATCH"},
+		{"PURGE", "/g/cache", "purged"},
+		{http.MethodGet, "/g/std", "std"},
+		{http.MethodDelete, "/g/m", "DELETE"},
+		{http.MethodGet, "/g/assets/app.css", "body{}"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.want {
+			t.Fatalf("%s %s: %d %q", tt.method, tt.path, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("X-Group") != "1" {
+			t.Fatalf("%s %s: group middleware not applied", tt.method, tt.path)
+		}
+	}
+
+	if u, err := a.URL("any"); err != nil || u != "/g/any" {
+		t.Fatalf("URL=%q err=%v", u, err)
+	}
+}
+
+func TestGroupRouteBuilder(t *testing.T) {
+	a := New()
+	var inner *Group
+	got := a.Group("/api").Route("/users", func(users *Group) {
+		inner = users
+		users.GET("", func(c Ctx) error { return c.String(http.StatusOK, "list") })
+		users.GET("/:id", func(c Ctx) error { return c.String(http.StatusOK, "user "+c.Param("id")) })
+	})
+	if got != inner {
+		t.Fatalf("Route should return the group passed to fn")
+	}
+	for path, want := range map[string]string{"/api/users": "list", "/api/users/3": "user 3"} {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Body.String() != want {
+			t.Fatalf("%s: %q want %q", path, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestGroupScopedHandlers(t *testing.T) {
+	a := New()
+	a.GET("/", func(c Ctx) error { return c.String(http.StatusOK, "home") })
+	a.GET("/fail", func(c Ctx) error { return errors.New("boom") })
+
+	api := a.Group("/api")
+	api.SetNotFoundHandler(func(c Ctx) error {
+		return c.Status(http.StatusNotFound).JSON(map[string]string{"error": "not found"})
+	})
+	api.SetErrorHandler(func(c Ctx, err error) {
+		_ = c.Status(http.StatusInternalServerError).JSON(map[string]string{"error": err.Error()})
+	})
+	api.GET("/fail", func(c Ctx) error { return errors.New("boom") })
+	v2 := api.Group("/v2")
+	v2.SetNotFoundHandler(func(c Ctx) error { return c.String(http.StatusNotFound, "v2 404") })
+	v2.GET("/fail", func(c Ctx) error { return errors.New("v2 boom") })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/api/nope", http.StatusNotFound, `{"error":"not found"}`},
+		{"/api", http.StatusNotFound, `{"error":"not found"}`},
+		{"/api/v2/nope", http.StatusNotFound, "v2 404"},
+		{"/apix", http.StatusNotFound, "Not Found"},
+		{"/nope", http.StatusNotFound, "Not Found"},
+		{"/api/fail", http.StatusInternalServerError, `{"error":"boom"}`},
+		{"/api/v2/fail", http.StatusInternalServerError, `{"error":"v2 boom"}`},
+		{"/fail", http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Fatalf("%s: %d %q want %d %q", tt.path, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+	}
+}
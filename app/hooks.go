@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// ResponseInfo describes a finished request as passed to OnResponse hooks.
+type ResponseInfo struct {
+	// Status is the response status code (200 if the handler wrote nothing).
+	Status int
+	// Bytes is the number of body bytes written through Ctx helpers. Writes
+	// made directly to the http.ResponseWriter (e.g. by HandleHTTP handlers)
+	// are not counted.
+	Bytes int
+	// Err is the error returned by the handler chain, if any. The error
+	// handler has already run when OnResponse hooks are called.
+	Err error
+	// Duration is the time spent executing the handler chain and error handler.
+	Duration time.Duration
+}
+
+// hookRegistry holds the lifecycle hooks of an app. Apps created by Host
+// share the registry of the app they were created from.
+type hookRegistry struct {
+	onRoute    []func(RouteInfo)
+	onStart    []func(addr string) error
+	onShutdown []func(context.Context) error
+	onRequest  []func(Ctx)
+	onResponse []func(Ctx, ResponseInfo)
+}
+
+// Hooks let plugins observe the app without wrapping every route. Register
+// them during setup, before routes are added and servers are started; the
+// request hooks are read without locking on every request.
+
+// OnRoute registers fn to be called for every route registered afterwards,
+// including routes on groups and hosts. The RouteInfo does not yet carry a
+// name assigned later with Route.Name.
+//
+// Example (collect routes for API docs):
+//
+//	a.OnRoute(func(r app.RouteInfo) { docs.Add(r.Method, r.Pattern, r.Handler) })
+func (a *DefaultApp) OnRoute(fn func(RouteInfo)) {
+	a.hooks.onRoute = append(a.hooks.onRoute, fn)
+}
+
+// OnStart registers fn to be called with the listen address each time a
+// server started by Listen, ListenTLS, ListenFastHTTP or Serve is about to
+// accept connections: after the routes are built and the server is tracked
+// by Shutdown. A non-nil error aborts the start and is returned by the
+// Listen call.
+//
+// Example:
+//
+//	a.OnStart(func(addr string) error {
+//		a.Logger().Info("listening", "addr", addr)
+//		return nil
+//	})
+func (a *DefaultApp) OnStart(fn func(addr string) error) {
+	a.hooks.onStart = append(a.hooks.onStart, fn)
+}
+
+// OnShutdown registers fn to run during Shutdown, after the servers have
+// stopped and in-flight requests have finished. Hooks run in registration
+// order and receive the Shutdown context.
+//
+// Example:
+//
+//	a.OnShutdown(func(ctx context.Context) error { return db.Close() })
+func (a *DefaultApp) OnShutdown(fn func(ctx context.Context) error) {
+	a.lifecycleMu.Lock()
+	a.hooks.onShutdown = append(a.hooks.onShutdown, fn)
+	a.lifecycleMu.Unlock()
+}
+
+// OnRequest registers fn to be called before the handler chain of every
+// request, including NotFound, 405 and redirect responses.
+//
+// Example:
+//
+//	a.OnRequest(func(c app.Ctx) { inflight.Inc() })
+func (a *DefaultApp) OnRequest(fn func(Ctx)) {
+	a.hooks.onRequest = append(a.hooks.onRequest, fn)
+}
+
+// OnResponse registers fn to be called after the handler chain (and error
+// handler) of every request, with the status, bytes written, error and
+// duration.
+//
+// Example (metrics):
+//
+//	a.OnResponse(func(c app.Ctx, res app.ResponseInfo) {
+//		requests.WithLabelValues(c.Route(), strconv.Itoa(res.Status)).Inc()
+//		latency.Observe(res.Duration.Seconds())
+//	})
+func (a *DefaultApp) OnResponse(fn func(Ctx, ResponseInfo)) {
+	a.hooks.onResponse = append(a.hooks.onResponse, fn)
+}
+
+// execute runs h for c between the OnRequest and OnResponse hooks, hands a
+// returned error (after MapError mappings) to the error handler for path and
+// finishes the context.
+func (a *DefaultApp) execute(c *ctx.DefaultContext, h func(Ctx) error, path string) {
+	hk := a.hooks
+	if len(hk.onRequest) == 0 && len(hk.onResponse) == 0 {
+		if err := h(c); err != nil {
+			a.errorHandlerFor(path)(c, a.mapError(err))
+		}
+		c.Finish()
+		return
+	}
+
+	for _, fn := range hk.onRequest {
+		fn(c)
+	}
+	start := time.Now()
+	err := h(c)
+	if err != nil {
+		a.errorHandlerFor(path)(c, a.mapError(err))
+	}
+	if len(hk.onResponse) > 0 {
+		res := ResponseInfo{Status: responseStatus(c), Bytes: c.BytesWritten(), Err: err, Duration: time.Since(start)}
+		for _, fn := range hk.onResponse {
+			fn(c, res)
+		}
+	}
+	c.Finish()
+}
+
+// responseStatus returns the status written for c, reading it from the
+// fasthttp response when the handler bypassed Ctx.
+func responseStatus(c *ctx.DefaultContext) int {
+	if s := c.StatusCode(); s != 0 {
+		return s
+	}
+	if f := c.FastHTTPCtx(); f != nil {
+		return f.Response.StatusCode()
+	}
+	return 200
+}
+
+// fireRoute calls the OnRoute hooks for info.
+func (a *DefaultApp) fireRoute(info RouteInfo) {
+	for _, fn := range a.hooks.onRoute {
+		fn(info)
+	}
+}
+
+// fireStart calls the OnStart hooks for addr, stopping at the first error.
+func (a *DefaultApp) fireStart(addr string) error {
+	for _, fn := range a.hooks.onStart {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
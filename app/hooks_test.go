@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestOnRouteSeesEveryRegistration(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/before", func(c Ctx) error { return nil })
+
+	var got []string
+	a.OnRoute(func(r RouteInfo) { got = append(got, r.Method+" "+r.Host+r.Pattern) })
+
+	a.GET("/users/:id", func(c Ctx) error { return nil })
+	api := a.Group("/api")
+	api.POST("/items", func(c Ctx) error { return nil })
+	a.Host("admin.example.com").GET("/", func(c Ctx) error { return nil })
+
+	want := []string{"GET /users/:id", "POST /api/items", "GET admin.example.com/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("OnRoute saw %q, want %q", got, want)
+	}
+
+	// A hook may inspect the app while registration is in progress
+	a.OnRoute(func(r RouteInfo) { _ = a.Routes() })
+	a.GET("/after", func(c Ctx) error { return nil })
+}
+
+func TestOnRequestAndOnResponse(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/ok", func(c Ctx) error { return c.String(http.StatusCreated, "hello") })
+	a.GET("/json", func(c Ctx) error { return c.JSON(map[string]int{"n": 1}) })
+	a.GET("/fail", func(c Ctx) error { return errors.New("boom") })
+
+	var requests int
+	var infos []ResponseInfo
+	a.OnRequest(func(c Ctx) { requests++ })
+	a.OnResponse(func(c Ctx, res ResponseInfo) { infos = append(infos, res) })
+
+	for _, path := range []string{"/ok", "/json", "/fail", "/missing"} {
+		a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if requests != 4 || len(infos) != 4 {
+		t.Fatalf("requests=%d responses=%d, want 4 each", requests, len(infos))
+	}
+	if infos[0].Status != http.StatusCreated || infos[0].Bytes != len("hello") || infos[0].Err != nil {
+		t.Fatalf("/ok: %+v", infos[0])
+	}
+	if infos[1].Status != http.StatusOK || infos[1].Bytes != len(`{"n":1}`) {
+		t.Fatalf("/json: %+v", infos[1])
+	}
+	if infos[2].Status != http.StatusInternalServerError || infos[2].Err == nil {
+		t.Fatalf("/fail: %+v", infos[2])
+	}
+	if infos[3].Status != http.StatusNotFound {
+		t.Fatalf("/missing: %+v", infos[3])
+	}
+
+	infos = nil
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/ok")
+	fctx.Request.Header.SetMethod(http.MethodGet)
+	a.ServeFastHTTP(&fctx)
+	if len(infos) != 1 || infos[0].Status != http.StatusCreated || infos[0].Bytes != len("hello") {
+		t.Fatalf("fasthttp: %+v", infos)
+	}
+}
+
+func TestOnStartRunsBeforeServingAndCanAbort(t *testing.T) {
+	a := New().(*DefaultApp)
+	var addrs []string
+	a.OnStart(func(addr string) error {
+		addrs = append(addrs, addr)
+		return nil
+	})
+	a.OnStart(func(addr string) error { return errors.New("port reserved") })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Serve(ln); err == nil || err.Error() != "port reserved" {
+		t.Fatalf("Serve err=%v", err)
+	}
+	if len(addrs) != 1 || addrs[0] != ln.Addr().String() {
+		t.Fatalf("OnStart addrs=%q", addrs)
+	}
+	if _, err := ln.Accept(); err == nil {
+		t.Fatal("listener should be closed after an aborted start")
+	}
+
+	ln, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.serveFastHTTPListener(ln); err == nil {
+		t.Fatal("fasthttp: expected OnStart error")
+	}
+}
+
+func TestOnStartRunsAfterBuildAndTracking(t *testing.T) {
+	serves := map[string]func(a *DefaultApp, ln net.Listener) error{
+		"net/http": (*DefaultApp).Serve,
+		"fasthttp": (*DefaultApp).serveFastHTTPListener,
+	}
+	for name, serve := range serves {
+		t.Run(name, func(t *testing.T) {
+			a := New().(*DefaultApp)
+			a.GET("/", func(c Ctx) error { return nil })
+			// Shutting down from the hook hits the window before the server
+			// accepts: Serve must still return.
+			a.OnStart(func(string) error {
+				if a.stale.Load() {
+					t.Error("OnStart ran before Build")
+				}
+				return a.Shutdown(context.Background())
+			})
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			done := make(chan error, 1)
+			go func() { done <- serve(a, ln) }()
+			select {
+			case err := <-done:
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					t.Fatalf("serve err=%v", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("serve did not return after Shutdown")
+			}
+		})
+	}
+}
+
+func TestOnShutdownHooksRunInOrderAndJoinErrors(t *testing.T) {
+	a := New().(*DefaultApp)
+	var order []int
+	errFirst := errors.New("first")
+	a.OnShutdown(func(ctx context.Context) error { order = append(order, 1); return errFirst })
+	a.OnShutdown(func(ctx context.Context) error { order = append(order, 2); return nil })
+
+	err := a.Shutdown(context.Background())
+	if !errors.Is(err, errFirst) {
+		t.Fatalf("Shutdown err=%v", err)
+	}
+	if !reflect.DeepEqual(order, []int{1, 2}) {
+		t.Fatalf("order=%v", order)
+	}
+}
+
+func TestHostAppsShareHooks(t *testing.T) {
+	a := New().(*DefaultApp)
+	var statuses []int
+	a.OnResponse(func(c Ctx, res ResponseInfo) { statuses = append(statuses, res.Status) })
+	a.Host("api.example.com").GET("/", func(c Ctx) error { return c.String(http.StatusAccepted, "api") })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "api.example.com"
+	a.ServeHTTP(httptest.NewRecorder(), req)
+	if !reflect.DeepEqual(statuses, []int{http.StatusAccepted}) {
+		t.Fatalf("statuses=%v", statuses)
+	}
+}
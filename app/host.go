@@ -0,0 +1,172 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/goflash/flash/v2/ctx"
+	router "github.com/julienschmidt/httprouter"
+)
+
+// hostRoute is a host pattern registered with Host and the app serving it.
+type hostRoute struct {
+	pattern string
+	labels  []string // pattern split at '.'; ":name" labels are parameters
+	params  int      // number of parameter labels
+	app     *DefaultApp
+	group   *Group // root group returned by Host
+}
+
+// Host returns a route group served only for requests whose Host header
+// matches pattern. Each host gets its own route tree, route cache, NotFound
+// handler and middleware stack; requests for hosts that match no pattern are
+// served by the routes registered directly on the App.
+//
+// A pattern is a dot-separated host name without port. Labels of the form
+// ":name" match any single label and are available to handlers through
+// c.Param. Hosts are compared case-insensitively and the port of the request
+// is ignored. Patterns without parameters are tried first, then patterns with
+// fewer parameters. Calling Host again with the same pattern returns the same
+// group.
+//
+// Use on the returned group adds to the host's middleware stack: it applies
+// to every route of the host, including routes registered earlier, and to
+// its automatic OPTIONS responses. Global middleware registered
+// with App.Use runs before it. Error and 405 handlers are shared with the App
+// unless the host overrides them.
+//
+// Example:
+//
+//	api := a.Host("api.example.com")
+//	api.GET("/users/:id", ShowUser)
+//
+//	tenant := a.Host(":tenant.example.com")
+//	tenant.Use(LoadTenant)
+//	tenant.SetNotFoundHandler(TenantNotFound)
+//	tenant.GET("/", func(c flash.Ctx) error {
+//		return c.String(http.StatusOK, "hello "+c.Param("tenant"))
+//	})
+func (a *DefaultApp) Host(pattern string) *Group {
+	pattern = strings.ToLower(pattern)
+	labels := parseHostPattern(pattern)
+
+	a.router.mu.Lock()
+	defer a.router.mu.Unlock()
+	for _, h := range a.hosts {
+		if h.pattern == pattern {
+			return h.group
+		}
+	}
+
+	hr := &hostRoute{pattern: pattern, labels: labels, app: a.newHostApp(pattern)}
+	hr.group = &Group{app: hr.app, prefix: "/", host: true}
+	for _, l := range labels {
+		if l[0] == ':' {
+			hr.params++
+		}
+	}
+	a.hosts = append(a.hosts, hr)
+	sort.SliceStable(a.hosts, func(i, j int) bool { return a.hosts[i].params < a.hosts[j].params })
+	a.hasHosts.Store(true)
+	return hr.group
+}
+
+// newHostApp creates the app serving a host pattern. Handlers, logger and
+// redirect settings that the host does not override are read from a.
+func (a *DefaultApp) newHostApp(pattern string) *DefaultApp {
+	h := &DefaultApp{
+		router:     newFastRouter(),
+		routeCache: newRouteCache(a.routeCache.capacity),
+		parent:     a,
+		host:       pattern,
+		hooks:      a.hooks,
+	}
+	h.pool.New = func() any {
+		return &ctx.DefaultContext{}
+	}
+	return h
+}
+
+// parseHostPattern splits pattern into labels, panicking if it is malformed.
+func parseHostPattern(pattern string) []string {
+	if pattern == "" || strings.ContainsAny(pattern, "/*") {
+		panic("flash: invalid host pattern '" + pattern + "'")
+	}
+	labels := strings.Split(pattern, ".")
+	var seen []string
+	for _, l := range labels {
+		if l == "" || strings.Count(l, ":") > 1 || strings.IndexByte(l, ':') > 0 {
+			panic("flash: invalid host pattern '" + pattern + "'")
+		}
+		if l[0] != ':' {
+			continue
+		}
+		if l == ":" {
+			panic("flash: host '" + pattern + "': empty parameter name")
+		}
+		for _, s := range seen {
+			if s == l {
+				panic("flash: host '" + pattern + "': duplicate parameter name '" + l[1:] + "'")
+			}
+		}
+		seen = append(seen, l)
+	}
+	return labels
+}
+
+// matchHost returns the app registered for host and the captured host
+// parameters, or nil when no pattern matches.
+func (a *DefaultApp) matchHost(host string) (*DefaultApp, []router.Param) {
+	host = stripPort(host)
+	a.router.mu.RLock()
+	defer a.router.mu.RUnlock()
+	for _, h := range a.hosts {
+		if ps, ok := h.match(host); ok {
+			return h.app, ps
+		}
+	}
+	return nil, nil
+}
+
+// match reports whether host matches the pattern, returning its parameters.
+func (h *hostRoute) match(host string) ([]router.Param, bool) {
+	var ps []router.Param
+	rest := host
+	for i, l := range h.labels {
+		label := rest
+		if dot := strings.IndexByte(rest, '.'); dot >= 0 {
+			if i == len(h.labels)-1 {
+				return nil, false
+			}
+			label, rest = rest[:dot], rest[dot+1:]
+		} else if i != len(h.labels)-1 {
+			return nil, false
+		}
+		if label == "" {
+			return nil, false
+		}
+		if l[0] == ':' {
+			ps = append(ps, router.Param{Key: l[1:], Value: label})
+		} else if !strings.EqualFold(l, label) {
+			return nil, false
+		}
+	}
+	return ps, true
+}
+
+// stripPort removes a trailing ":port" from host, keeping IPv6 brackets.
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		return host[:i]
+	}
+	return host
+}
+
+// joinParams prepends host parameters to route parameters.
+func joinParams(host, route []router.Param) []router.Param {
+	if len(host) == 0 {
+		return route
+	}
+	out := make([]router.Param, 0, len(host)+len(route))
+	return append(append(out, host...), route...)
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func newMultiHostApp(t *testing.T) *DefaultApp {
+	t.Helper()
+	a := New().(*DefaultApp)
+	a.Use(func(next Handler) Handler {
+		return func(c Ctx) error { c.Header("X-Global", "1"); return next(c) }
+	})
+	a.GET("/", func(c Ctx) error { return c.String(http.StatusOK, "root") })
+
+	api := a.Host("api.example.com")
+	api.Use(func(next Handler) Handler {
+		return func(c Ctx) error { c.Header("X-API", "1"); return next(c) }
+	})
+	api.GET("/users/:id", func(c Ctx) error { return c.String(http.StatusOK, "api user "+c.Param("id")) }).Name("api.user")
+	api.SetNotFoundHandler(func(c Ctx) error { return c.String(http.StatusNotFound, "api 404") })
+
+	tenant := a.Host(":tenant.example.com")
+	tenant.GET("/", func(c Ctx) error { return c.String(http.StatusOK, "tenant "+c.Param("tenant")) })
+	tenant.GET("/docs/:page", func(c Ctx) error {
+		return c.String(http.StatusOK, c.Param("tenant")+"/"+c.Param("page"))
+	})
+
+	a.Host(":region.:tenant.example.com").GET("/", func(c Ctx) error {
+		return c.String(http.StatusOK, c.Param("region")+":"+c.Param("tenant"))
+	})
+	return a
+}
+
+func TestHostRouting(t *testing.T) {
+	a := newMultiHostApp(t)
+	tests := []struct {
+		host, path string
+		status     int
+		body       string
+	}{
+		{"api.example.com", "/users/7", http.StatusOK, "api user 7"},
+		{"API.Example.com:8443", "/users/7", http.StatusOK, "api user 7"},
+		{"api.example.com", "/", http.StatusNotFound, "api 404"},
+		{"acme.example.com", "/", http.StatusOK, "tenant acme"},
+		{"acme.example.com", "/docs/intro", http.StatusOK, "acme/intro"},
+		{"acme.example.com", "/users/7", http.StatusNotFound, "Not Found"},
+		{"eu.acme.example.com", "/", http.StatusOK, "eu:acme"},
+		{"example.com", "/", http.StatusOK, "root"},
+		{"other.org", "/", http.StatusOK, "root"},
+		{"other.org", "/users/7", http.StatusNotFound, "Not Found"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Fatalf("%s%s: %d %q want %d %q", tt.host, tt.path, rec.Code, rec.Body.String(), tt.status, tt.body)
+		}
+
+		var fctx fasthttp.RequestCtx
+		fctx.Request.SetRequestURI(tt.path)
+		fctx.Request.Header.SetHost(tt.host)
+		fctx.Request.Header.SetMethod(http.MethodGet)
+		a.ServeFastHTTP(&fctx)
+		if fctx.Response.StatusCode() != tt.status || string(fctx.Response.Body()) != tt.body {
+			t.Fatalf("fasthttp %s%s: %d %q", tt.host, tt.path, fctx.Response.StatusCode(), fctx.Response.Body())
+		}
+	}
+}
+
+func TestHostMiddlewareIsolation(t *testing.T) {
+	a := newMultiHostApp(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Host = "api.example.com"
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Header().Get("X-Global") != "1" || rec.Header().Get("X-API") != "1" {
+		t.Fatalf("api host should run global and host middleware: %v", rec.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "acme.example.com"
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Header().Get("X-Global") != "1" || rec.Header().Get("X-API") != "" {
+		t.Fatalf("tenant host should only run global middleware: %v", rec.Header())
+	}
+}
+
+func TestHostIntrospection(t *testing.T) {
+	a := newMultiHostApp(t)
+
+	if u, err := a.URL("api.user", "id", "9"); err != nil || u != "/users/9" {
+		t.Fatalf("URL=%q err=%v", u, err)
+	}
+
+	hosts := map[string]bool{}
+	for _, r := range a.Routes() {
+		hosts[r.Host] = true
+	}
+	for _, h := range []string{"", "api.example.com", ":tenant.example.com", ":region.:tenant.example.com"} {
+		if !hosts[h] {
+			t.Fatalf("Routes() missing host %q", h)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1", nil)
+	req.Host = "api.example.com"
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Fatalf("host 405: %d Allow=%q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestHostSamePatternSharesRoutes(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.Host("Api.example.com").GET("/a", func(c Ctx) error { return nil })
+	a.Host("api.example.com").GET("/b", func(c Ctx) error { return nil })
+	if len(a.hosts) != 1 {
+		t.Fatalf("expected one host, got %d", len(a.hosts))
+	}
+}
+
+func TestHostPatternValidation(t *testing.T) {
+	for _, p := range []string{"", "api..example.com", "a:b.example.com", ":.example.com", ":x.:x.example.com", "example.com/api"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%q: expected panic", p)
+				}
+			}()
+			New().Host(p)
+		}()
+	}
+}
+
+func TestGroupNotFoundByPrefix(t *testing.T) {
+	a := New()
+	api := a.Group("/api")
+	api.GET("/ping", func(c Ctx) error { return c.String(http.StatusOK, "pong") })
+	api.SetNotFoundHandler(func(c Ctx) error { return c.String(http.StatusNotFound, "api 404") })
+
+	for path, want := range map[string]string{"/api/nope": "api 404", "/api": "api 404", "/apix": "Not Found", "/": "Not Found"} {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Body.String() != want {
+			t.Fatalf("%s: %q want %q", path, rec.Body.String(), want)
+		}
+	}
+}
+
+func TestHostMiddlewareStackSharedAcrossCalls(t *testing.T) {
+	a := New().(*DefaultApp)
+	mark := func(next Handler) Handler {
+		return func(c Ctx) error {
+			c.Header("X-Host", "1")
+			return next(c)
+		}
+	}
+	a.Host("api.example.com").GET("/before", func(c Ctx) error { return c.String(http.StatusOK, "ok") })
+	g := a.Host("api.example.com")
+	if g != a.Host("API.example.com") {
+		t.Fatal("Host should return the same group for the same pattern")
+	}
+	g.Use(mark)
+	a.Host("api.example.com").GET("/after", func(c Ctx) error { return c.String(http.StatusOK, "ok") })
+
+	for _, r := range [][2]string{{http.MethodGet, "/before"}, {http.MethodGet, "/after"}, {http.MethodOptions, "/before"}} {
+		req := httptest.NewRequest(r[0], r[1], nil)
+		req.Host = "api.example.com"
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, req)
+		if rec.Header().Get("X-Host") != "1" {
+			t.Fatalf("%s %s: host middleware should run: %d %v", r[0], r[1], rec.Code, rec.Header())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/before", nil)
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	if rec.Header().Get("X-Host") != "" {
+		t.Fatalf("host middleware leaked to the default host: %v", rec.Header())
+	}
+}
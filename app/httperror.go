@@ -0,0 +1,108 @@
+package app
+
+import (
+	"net/http"
+)
+
+// HTTPError is an error that carries the HTTP response it should produce.
+// Handlers return it (directly or wrapped with fmt.Errorf("...: %w", err)) and
+// the default error handler finds it with errors.As.
+//
+// Message is sent to the client; Err is the internal cause and is only
+// available to error handlers, loggers and errors.Is/As through Unwrap.
+//
+// Example:
+//
+//	a.GET("/users/:id", func(c app.Ctx) error {
+//		u, err := store.User(c.Param("id"))
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return app.NewError(http.StatusNotFound, "user_not_found", "User not found").WithCause(err)
+//		}
+//		if err != nil {
+//			return err // 500
+//		}
+//		return c.JSON(u)
+//	})
+type HTTPError struct {
+	// Status is the HTTP status code (500 if zero).
+	Status int
+	// Code is a machine-readable error code, e.g. "user_not_found".
+	Code string
+	// Message is the public, human-readable message. The status text is used
+	// when empty.
+	Message string
+	// Err is the internal cause. It is never sent to the client.
+	Err error
+	// Header holds extra response headers, e.g. Retry-After.
+	Header http.Header
+	// Body, if not nil, is written as JSON by the default error handler
+	// instead of {"code":...,"message":...}.
+	Body any
+}
+
+// NewError returns an HTTPError with the given status, code and public
+// message. The message defaults to the status text.
+//
+// Example:
+//
+//	return app.NewError(http.StatusConflict, "email_taken", "Email is already registered")
+func NewError(status int, code string, message ...string) *HTTPError {
+	e := &HTTPError{Status: status, Code: code}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+	return e
+}
+
+// WithCause sets the internal cause of e and returns e.
+func (e *HTTPError) WithCause(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+// WithHeader adds a response header to e and returns e.
+//
+// Example:
+//
+//	return app.NewError(http.StatusTooManyRequests, "rate_limited").WithHeader("Retry-After", "30")
+func (e *HTTPError) WithHeader(key, value string) *HTTPError {
+	if e.Header == nil {
+		e.Header = make(http.Header)
+	}
+	e.Header.Add(key, value)
+	return e
+}
+
+// StatusCode returns the status to respond with: Status, or 500 if it is not
+// a valid HTTP status.
+func (e *HTTPError) StatusCode() int {
+	if e.Status < 100 || e.Status > 999 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// PublicMessage returns Message, or the status text if Message is empty.
+func (e *HTTPError) PublicMessage() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.StatusCode())
+}
+
+// Error returns the code, public message and cause, e.g.
+// "user_not_found: User not found: sql: no rows in result set".
+func (e *HTTPError) Error() string {
+	s := e.PublicMessage()
+	if e.Code != "" {
+		s = e.Code + ": " + s
+	}
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+// Unwrap returns the internal cause, so errors.Is and errors.As see through
+// an HTTPError.
+func (e *HTTPError) Unwrap() error { return e.Err }
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPErrorWrapsCause(t *testing.T) {
+	e := NewError(http.StatusNotFound, "user_not_found", "User not found").WithCause(io.EOF)
+	if got := e.Error(); got != "user_not_found: User not found: EOF" {
+		t.Fatalf("Error()=%q", got)
+	}
+	wrapped := fmt.Errorf("load user: %w", e)
+	if !errors.Is(wrapped, io.EOF) {
+		t.Fatal("errors.Is should reach the cause")
+	}
+	var he *HTTPError
+	if !errors.As(wrapped, &he) || he != e {
+		t.Fatal("errors.As should find the HTTPError")
+	}
+
+	if got := NewError(http.StatusTeapot, "").Error(); got != "I'm a teapot" {
+		t.Fatalf("Error()=%q", got)
+	}
+	if got := (&HTTPError{}).StatusCode(); got != http.StatusInternalServerError {
+		t.Fatalf("zero StatusCode()=%d", got)
+	}
+}
+
+func TestDefaultErrorHandlerHonoursHTTPError(t *testing.T) {
+	a := New()
+	a.GET("/missing", func(c Ctx) error {
+		err := NewError(http.StatusNotFound, "user_not_found", "User not found").WithCause(errors.New("secret dsn"))
+		return fmt.Errorf("handler: %w", err)
+	})
+	a.GET("/limited", func(c Ctx) error {
+		return NewError(http.StatusTooManyRequests, "rate_limited").WithHeader("Retry-After", "30")
+	})
+	a.GET("/plain", func(c Ctx) error { return errors.New("secret dsn") })
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+		header string
+	}{
+		{"/missing", http.StatusNotFound, `{"code":"user_not_found","message":"User not found"}`, ""},
+		{"/limited", http.StatusTooManyRequests, `{"code":"rate_limited","message":"Too Many Requests"}`, "30"},
+		{"/plain", http.StatusInternalServerError, "Internal Server Error", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Fatalf("%s: got %d %q", tt.path, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Retry-After"); got != tt.header {
+			t.Fatalf("%s: Retry-After=%q", tt.path, got)
+		}
+	}
+}
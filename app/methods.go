@@ -0,0 +1,110 @@
+package app
+
+import (
+	"net/http"
+	"sort"
+
+	router "github.com/julienschmidt/httprouter"
+)
+
+// AllowedMethods returns the sorted set of methods that have a route matching
+// path. HEAD is included whenever GET is, and OPTIONS whenever any method
+// matches, because the app answers both automatically. It returns nil for
+// paths that match no route.
+//
+// The same set is sent in the Allow header of 405 and automatic OPTIONS
+// responses and is available to handlers through Ctx.AllowedMethods.
+//
+// Example:
+//
+//	a.GET("/users/:id", ShowUser)
+//	a.PUT("/users/:id", UpdateUser)
+//	a.AllowedMethods("/users/42") // [GET HEAD OPTIONS PUT]
+func (a *DefaultApp) AllowedMethods(path string) []string {
+	a.router.mu.RLock()
+	defer a.router.mu.RUnlock()
+
+	var allowed []string
+	for _, m := range a.router.methods {
+		if a.router.matches(m, path) {
+			allowed = append(allowed, m)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	if containsMethod(allowed, http.MethodGet) && !containsMethod(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if !containsMethod(allowed, http.MethodOptions) {
+		allowed = append(allowed, http.MethodOptions)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// matches reports whether a route is registered for method and path.
+// Callers must hold r.mu for reading.
+func (r *FastRouter) matches(method, path string) bool {
+	key := method + ":" + path
+	if r.simple[key] != nil || r.static[key] != nil {
+		return true
+	}
+	if root := r.dynamic[method]; root != nil {
+		node, _ := root.lookup(path, nil)
+		return node != nil
+	}
+	return false
+}
+
+// addMethod records method in the sorted method set. Callers must hold r.mu
+// for writing.
+func (r *FastRouter) addMethod(method string) {
+	i := sort.SearchStrings(r.methods, method)
+	if i < len(r.methods) && r.methods[i] == method {
+		return
+	}
+	r.methods = append(r.methods, "")
+	copy(r.methods[i+1:], r.methods[i:])
+	r.methods[i] = method
+}
+
+// resolve returns the handler, parameters and pattern of the route registered
+// for method and path, checking simple, static and dynamic routes in the same
+// order as ServeHTTP. The handler is nil when nothing matches.
+func (a *DefaultApp) resolve(method, path string) (func(Ctx) error, []router.Param, string) {
+	key := method + ":" + path
+	a.router.mu.RLock()
+	h, chain := a.router.simple[key], a.router.static[key]
+	a.router.mu.RUnlock()
+	switch {
+	case h != nil:
+		return h, nil, path
+	case chain != nil:
+		return chain.exec, nil, path
+	}
+	if res := a.findRoute(method, path); res != nil {
+		return res.chain.exec, res.params, res.pattern
+	}
+	return nil, nil, ""
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// headResponseWriter serves a GET route for a HEAD request: headers, status
+// and Content-Length are written as usual but the body is discarded.
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w headResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
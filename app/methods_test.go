@@ -0,0 +1,182 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestMethodNotAllowedSetsAllowHeader(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/users/:id", func(c Ctx) error { return c.String(http.StatusOK, "get") })
+	a.PUT("/users/:id", func(c Ctx) error { return c.String(http.StatusOK, "put") })
+	a.POST("/users", func(c Ctx) error { return nil })
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/users/42", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, HEAD, OPTIONS, PUT" {
+		t.Fatalf("Allow=%q", got)
+	}
+
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/users")
+	fctx.Request.Header.SetMethod(http.MethodGet)
+	a.ServeFastHTTP(&fctx)
+	if fctx.Response.StatusCode() != http.StatusMethodNotAllowed {
+		t.Fatalf("fasthttp: expected 405, got %d", fctx.Response.StatusCode())
+	}
+	if got := string(fctx.Response.Header.Peek("Allow")); got != "OPTIONS, POST" {
+		t.Fatalf("fasthttp: Allow=%q", got)
+	}
+}
+
+func TestAllowedMethodsExposedThroughCtx(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/x", func(c Ctx) error { return c.String(http.StatusOK, "x") })
+	a.PATCH("/x", func(c Ctx) error { return nil })
+	a.SetMethodNotAllowedHandler(func(c Ctx) error {
+		return c.Status(http.StatusMethodNotAllowed).JSON(map[string]any{"allowed": c.AllowedMethods()})
+	})
+	a.POST("/y", func(c Ctx) error { return c.JSON(c.AllowedMethods()) })
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/x", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Body.String() != `{"allowed":["GET","HEAD","OPTIONS","PATCH"]}` {
+		t.Fatalf("405: %d %q", rec.Code, rec.Body.String())
+	}
+
+	// Computed on demand inside a regular handler
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/y", nil))
+	if rec.Body.String() != `["OPTIONS","POST"]` {
+		t.Fatalf("handler: %q", rec.Body.String())
+	}
+
+	if got := a.AllowedMethods("/nope"); got != nil {
+		t.Fatalf("expected nil for unknown path, got %v", got)
+	}
+	if got, want := a.AllowedMethods("/x"), []string{"GET", "HEAD", "OPTIONS", "PATCH"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("AllowedMethods=%v want %v", got, want)
+	}
+}
+
+func TestAutomaticOptions(t *testing.T) {
+	a := New().(*DefaultApp)
+	var ran bool
+	a.Use(func(next Handler) Handler {
+		return func(c Ctx) error { ran = true; return next(c) }
+	})
+	a.GET("/items/:id", func(c Ctx) error { return nil })
+	a.DELETE("/items/:id", func(c Ctx) error { return nil })
+	a.OPTIONS("/custom", func(c Ctx) error { return c.String(http.StatusOK, "custom") })
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/items/1", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "DELETE, GET, HEAD, OPTIONS" {
+		t.Fatalf("Allow=%q", got)
+	}
+	if !ran {
+		t.Fatalf("global middleware should run for automatic OPTIONS")
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/custom", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "custom" {
+		t.Fatalf("explicit OPTIONS route not used: %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown path, got %d", rec.Code)
+	}
+}
+
+func TestAutomaticOptionsChainIsCompiledOnce(t *testing.T) {
+	a := New().(*DefaultApp)
+	built := 0
+	a.Use(func(next Handler) Handler {
+		built++
+		return next
+	})
+	a.GET("/items", func(c Ctx) error { return nil })
+	before := built
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/items", nil))
+		var fctx fasthttp.RequestCtx
+		fctx.Request.Header.SetMethod(http.MethodOptions)
+		fctx.Request.SetRequestURI("/items")
+		a.ServeFastHTTP(&fctx)
+		if rec.Code != http.StatusNoContent || fctx.Response.StatusCode() != http.StatusNoContent {
+			t.Fatalf("status %d / %d", rec.Code, fctx.Response.StatusCode())
+		}
+	}
+	if built != before+1 {
+		t.Fatalf("middleware constructed %d times for automatic OPTIONS, want once", built-before)
+	}
+
+	// Use recompiles the chain with the new middleware
+	var ran bool
+	a.Use(func(next Handler) Handler {
+		return func(c Ctx) error { ran = true; return next(c) }
+	})
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/items", nil))
+	if !ran {
+		t.Fatal("middleware added later should run for automatic OPTIONS")
+	}
+}
+
+func TestHeadServedByGetRoute(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/simple", func(c Ctx) error { return c.String(http.StatusOK, "simple") })
+	a.GET("/static", func(c Ctx) error { return c.String(http.StatusOK, "static") }, func(next Handler) Handler { return next })
+	a.GET("/users/:id", func(c Ctx) error {
+		c.Header("X-Route", c.Route())
+		return c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	a.HEAD("/own", func(c Ctx) error { return c.String(http.StatusAccepted, "") })
+	a.GET("/own", func(c Ctx) error { return c.String(http.StatusOK, "get") })
+
+	for path, length := range map[string]string{"/simple": "6", "/static": "6", "/users/7": "6"} {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, path, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+			t.Fatalf("%s: %d body=%q", path, rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Length") != length {
+			t.Fatalf("%s: Content-Length=%q", path, rec.Header().Get("Content-Length"))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/users/7", nil))
+	if rec.Header().Get("X-Route") != "/users/:id" {
+		t.Fatalf("params/pattern not passed to GET route: %q", rec.Header().Get("X-Route"))
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/own", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("explicit HEAD route not used: %d", rec.Code)
+	}
+
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/users/7")
+	fctx.Request.Header.SetMethod(http.MethodHead)
+	a.ServeFastHTTP(&fctx)
+	if fctx.Response.StatusCode() != http.StatusOK || !fctx.Response.SkipBody {
+		t.Fatalf("fasthttp: %d skipBody=%v", fctx.Response.StatusCode(), fctx.Response.SkipBody)
+	}
+}
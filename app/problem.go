@@ -0,0 +1,95 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// ContentTypeProblemJSON is the media type of RFC 9457 problem details.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// Problem is an RFC 9457 problem details object, as written by
+// ProblemErrorHandler.
+type Problem struct {
+	// Type is a URI reference identifying the problem type ("about:blank"
+	// when the problem has no more semantics than its status).
+	Type string `json:"type"`
+	// Title is a short summary of the problem type; the status text.
+	Title string `json:"title"`
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+	// Detail explains this occurrence of the problem: the public message of
+	// an HTTPError.
+	Detail string `json:"detail,omitempty"`
+	// Instance identifies this occurrence: the request path.
+	Instance string `json:"instance,omitempty"`
+	// Code is the HTTPError code (extension member).
+	Code string `json:"code,omitempty"`
+	// Errors lists field errors from binding or validation (extension member).
+	Errors []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField is one entry of Problem.Errors.
+type ProblemField struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NewProblem describes err as a Problem for the request c:
+//   - an *HTTPError in the chain sets the status, detail, code and headers
+//     (headers are applied to c by ProblemErrorHandler, not here)
+//   - ctx.FieldErrors in the chain are listed in Errors, sorted by field, with
+//     status 400 unless an HTTPError sets another
+//   - any other error is a 500 without detail, so internals are not leaked
+//
+// HTTPError.Body, e.g. set by a MapErrorAs mapping, is not part of a Problem.
+func NewProblem(c Ctx, err error) Problem {
+	p := Problem{Type: "about:blank", Status: http.StatusInternalServerError, Instance: c.Path()}
+
+	var fe ctx.FieldErrors
+	if errors.As(err, &fe) {
+		p.Status = http.StatusBadRequest
+		for _, e := range fe.All() {
+			p.Errors = append(p.Errors, ProblemField{Field: e.Field(), Message: e.Message()})
+		}
+		sort.Slice(p.Errors, func(i, j int) bool { return p.Errors[i].Field < p.Errors[j].Field })
+	}
+
+	var he *HTTPError
+	if errors.As(err, &he) {
+		p.Status = he.StatusCode()
+		p.Detail = he.Message
+		p.Code = he.Code
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// ProblemErrorHandler is an ErrorHandler that writes errors as RFC 9457
+// problem details with Content-Type application/problem+json. See NewProblem
+// for how errors are mapped. Like the default handler, it does nothing if the
+// response has already started.
+//
+// Example:
+//
+//	a := app.New()
+//	a.SetErrorHandler(app.ProblemErrorHandler)
+//
+//	// BindJSON field errors become:
+//	// 400 {"type":"about:blank","title":"Bad Request","status":400,"instance":"/users",
+//	//      "errors":[{"field":"age","message":"int type expected"}]}
+func ProblemErrorHandler(c Ctx, err error) {
+	if c.WroteHeader() {
+		return
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		c.SetHeadersFromMap(he.Header)
+	}
+	p := NewProblem(c, err)
+	c.SetContentType(ContentTypeProblemJSON)
+	_ = c.Status(p.Status).JSON(p)
+}
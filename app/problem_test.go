@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemErrorHandler(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.SetErrorHandler(ProblemErrorHandler)
+	a.POST("/users", func(c Ctx) error {
+		var in struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+		return c.BindJSON(&in)
+	})
+	a.GET("/users/:id", func(c Ctx) error {
+		err := NewError(http.StatusNotFound, "user_not_found", "User not found").WithHeader("X-Trace", "t1")
+		return fmt.Errorf("lookup: %w", err)
+	})
+	a.GET("/boom", func(c Ctx) error { return errors.New("secret dsn") })
+
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"Ada","age":"old"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := serve(req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, ContentTypeProblemJSON, rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/users",
+			"errors":[{"field":"age","message":"int type expected"}]}`, rec.Body.String())
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/users/7", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "t1", rec.Header().Get("X-Trace"))
+		assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"User not found",
+			"instance":"/users/7","code":"user_not_found"}`, rec.Body.String())
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/boom", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/boom"}`, rec.Body.String())
+	})
+}
+
+func TestNewProblemHTTPErrorWrappingFieldErrors(t *testing.T) {
+	a := New()
+	var got Problem
+	a.POST("/", func(c Ctx) error {
+		var in struct {
+			Age int `json:"age"`
+		}
+		err := c.BindJSON(&in)
+		got = NewProblem(c, NewError(http.StatusUnprocessableEntity, "invalid").WithCause(err))
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	a.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, got.Status)
+	assert.Equal(t, "Unprocessable Entity", got.Title)
+	assert.Equal(t, []ProblemField{{Field: "age", Message: "int type expected"}}, got.Errors)
+}
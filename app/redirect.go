@@ -0,0 +1,152 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redirect modes
+//
+// When a request matches no route, the app can redirect the client to an
+// equivalent path that does. All modes are off by default and only consulted
+// for requests that would otherwise receive 404 or 405:
+//
+//   - RedirectTrailingSlash: "/users/" -> "/users" and "/users" -> "/users/",
+//     whichever is registered
+//   - RedirectFixedPath: "//users/../admin" -> "/admin" using cleanPath
+//   - CaseInsensitive: "/USERS/42" -> "/users/42"; parameter values keep the
+//     case of the request
+//
+// Modes combine, so "/Users//42/" can be redirected to "/users/42" when all
+// three are enabled. Like httprouter, GET requests are answered with 301 Moved
+// Permanently and all other methods with 308 Permanent Redirect, so the method
+// and body are preserved. The query string is kept.
+//
+// fasthttp normalizes request paths before routing, so on that transport
+// RedirectFixedPath only sees paths fasthttp left untouched.
+
+// SetRedirectTrailingSlash enables redirects between "/path" and "/path/"
+// when only the other form is registered.
+//
+// Example:
+//
+//	a.GET("/users", ListUsers)
+//	a.SetRedirectTrailingSlash(true) // GET /users/ -> 301 Location: /users
+func (a *DefaultApp) SetRedirectTrailingSlash(v bool) { a.RedirectTrailingSlash = v }
+
+// SetRedirectFixedPath enables redirects from unclean paths (duplicate
+// slashes, "." and ".." elements) to their cleaned form.
+//
+// Example:
+//
+//	a.SetRedirectFixedPath(true) // GET //users/../admin -> 301 Location: /admin
+func (a *DefaultApp) SetRedirectFixedPath(v bool) { a.RedirectFixedPath = v }
+
+// SetCaseInsensitive enables redirects from paths whose static segments only
+// differ in case from a registered route.
+//
+// Example:
+//
+//	a.GET("/users/:id", ShowUser)
+//	a.SetCaseInsensitive(true) // GET /USERS/Bob -> 301 Location: /users/Bob
+func (a *DefaultApp) SetCaseInsensitive(v bool) { a.CaseInsensitive = v }
+
+// redirectLocation returns the escaped path the client should be redirected
+// to and the status code to use, or "" when no redirect mode applies.
+func (a *DefaultApp) redirectLocation(method, path string) (string, int) {
+	cfg := a
+	if a.parent != nil {
+		cfg = a.parent // apps created by Host use the App's settings
+	}
+	if !cfg.RedirectTrailingSlash && !cfg.RedirectFixedPath && !cfg.CaseInsensitive {
+		return "", 0
+	}
+
+	base := path
+	if cfg.RedirectFixedPath {
+		base = cleanPath(path)
+		if strings.HasSuffix(path, "/") && base != "/" {
+			base += "/"
+		}
+	}
+	candidates := []string{base}
+	if cfg.RedirectTrailingSlash {
+		if strings.HasSuffix(base, "/") {
+			if base != "/" {
+				candidates = append(candidates, strings.TrimSuffix(base, "/"))
+			}
+		} else {
+			candidates = append(candidates, base+"/")
+		}
+	}
+
+	a.router.mu.RLock()
+	defer a.router.mu.RUnlock()
+	for _, p := range candidates {
+		fixed := ""
+		if p != path && a.router.routable(method, p) {
+			fixed = p
+		} else if cfg.CaseInsensitive {
+			fixed = a.router.foldPath(method, p)
+		}
+		// Never redirect to a protocol-relative URL ("//host")
+		if fixed == "" || fixed == path || strings.HasPrefix(fixed, "//") {
+			continue
+		}
+		code := http.StatusPermanentRedirect
+		if method == http.MethodGet {
+			code = http.StatusMovedPermanently
+		}
+		return (&url.URL{Path: fixed}).EscapedPath(), code
+	}
+	return "", 0
+}
+
+// routable reports whether a request for method and path would be served:
+// HEAD also accepts GET routes and OPTIONS accepts any route.
+// Callers must hold r.mu for reading.
+func (r *FastRouter) routable(method, path string) bool {
+	if r.matches(method, path) || (method == http.MethodHead && r.matches(http.MethodGet, path)) {
+		return true
+	}
+	if method == http.MethodOptions {
+		for _, m := range r.methods {
+			if r.matches(m, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// foldPath returns the registered path that matches path case-insensitively
+// for method (or GET, for HEAD), or "". Callers must hold r.mu for reading.
+func (r *FastRouter) foldPath(method, path string) string {
+	methods := []string{method}
+	if method == http.MethodHead {
+		methods = append(methods, http.MethodGet)
+	}
+	lower := strings.ToLower(path)
+	for _, m := range methods {
+		if p, ok := r.folded[m+":"+lower]; ok {
+			return p
+		}
+		if root := r.dynamic[m]; root != nil {
+			if p, ok := root.lookupFold(path); ok {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// redirect answers with code and a Location header pointing at loc plus the
+// original query string.
+func redirect(c Ctx, loc, rawQuery string, code int) error {
+	if rawQuery != "" {
+		loc += "?" + rawQuery
+	}
+	c.Header("Location", loc)
+	return c.String(code, http.StatusText(code))
+}
@@ -0,0 +1,98 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func newRedirectApp() *DefaultApp {
+	a := New().(*DefaultApp)
+	ok := func(c Ctx) error { return c.String(http.StatusOK, c.Route()) }
+	a.GET("/users", ok)
+	a.POST("/users", ok)
+	a.GET("/admin/", ok)
+	a.GET("/users/:id/Posts", ok)
+	a.GET("/files/*path", ok)
+	a.GET("/About", ok, func(next Handler) Handler { return next })
+	return a
+}
+
+func TestRedirectsDisabledByDefault(t *testing.T) {
+	a := newRedirectApp()
+	for _, p := range []string{"/users/", "/admin", "//users", "/USERS"} {
+		rec := httptest.NewRecorder()
+		a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, p, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", p, rec.Code)
+		}
+	}
+}
+
+func TestRedirectModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(a *DefaultApp)
+		method   string
+		target   string
+		code     int
+		location string
+	}{
+		{"strip slash", func(a *DefaultApp) { a.SetRedirectTrailingSlash(true) }, http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{"add slash", func(a *DefaultApp) { a.SetRedirectTrailingSlash(true) }, http.MethodGet, "/admin", http.StatusMovedPermanently, "/admin/"},
+		{"post uses 308", func(a *DefaultApp) { a.SetRedirectTrailingSlash(true) }, http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users"},
+		{"head uses GET route", func(a *DefaultApp) { a.SetRedirectTrailingSlash(true) }, http.MethodHead, "/users/", http.StatusPermanentRedirect, "/users"},
+		{"query kept", func(a *DefaultApp) { a.SetRedirectTrailingSlash(true) }, http.MethodGet, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"fixed path", func(a *DefaultApp) { a.SetRedirectFixedPath(true) }, http.MethodGet, "//users/../users/./", http.StatusNotFound, ""},
+		{"fixed path exact", func(a *DefaultApp) { a.SetRedirectFixedPath(true) }, http.MethodGet, "//x/../users", http.StatusMovedPermanently, "/users"},
+		{"fixed path keeps slash", func(a *DefaultApp) { a.SetRedirectFixedPath(true) }, http.MethodGet, "/admin//", http.StatusMovedPermanently, "/admin/"},
+		{"fixed path and slash", func(a *DefaultApp) { a.SetRedirectFixedPath(true); a.SetRedirectTrailingSlash(true) }, http.MethodGet, "//users/../users/./", http.StatusMovedPermanently, "/users"},
+		{"case static", func(a *DefaultApp) { a.SetCaseInsensitive(true) }, http.MethodGet, "/USERS", http.StatusMovedPermanently, "/users"},
+		{"case static with middleware", func(a *DefaultApp) { a.SetCaseInsensitive(true) }, http.MethodGet, "/about", http.StatusMovedPermanently, "/About"},
+		{"case keeps param value", func(a *DefaultApp) { a.SetCaseInsensitive(true) }, http.MethodGet, "/Users/Bob/posts", http.StatusMovedPermanently, "/users/Bob/Posts"},
+		{"case keeps catch-all", func(a *DefaultApp) { a.SetCaseInsensitive(true) }, http.MethodGet, "/FILES/A/b.txt", http.StatusMovedPermanently, "/files/A/b.txt"},
+		{"all modes", func(a *DefaultApp) {
+			a.SetCaseInsensitive(true)
+			a.SetRedirectFixedPath(true)
+			a.SetRedirectTrailingSlash(true)
+		}, http.MethodGet, "/Users//Bob/posts/", http.StatusMovedPermanently, "/users/Bob/Posts"},
+		{"escaped location", func(a *DefaultApp) { a.SetCaseInsensitive(true) }, http.MethodGet, "/FILES/a%20b", http.StatusMovedPermanently, "/files/a%20b"},
+		{"no protocol-relative redirect", func(a *DefaultApp) {
+			a.GET("/*rest", func(c Ctx) error { return nil })
+			a.SetRedirectTrailingSlash(true)
+		}, http.MethodGet, "//evil.com/", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newRedirectApp()
+			tt.setup(a)
+			rec := httptest.NewRecorder()
+			a.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.code {
+				t.Fatalf("status=%d want %d (Location %q)", rec.Code, tt.code, rec.Header().Get("Location"))
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Fatalf("Location=%q want %q", got, tt.location)
+			}
+		})
+	}
+}
+
+func TestRedirectFastHTTP(t *testing.T) {
+	a := newRedirectApp()
+	a.SetRedirectTrailingSlash(true)
+	a.SetCaseInsensitive(true)
+
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/Users/?q=1")
+	fctx.Request.Header.SetMethod(http.MethodPost)
+	a.ServeFastHTTP(&fctx)
+	if fctx.Response.StatusCode() != http.StatusPermanentRedirect {
+		t.Fatalf("status=%d", fctx.Response.StatusCode())
+	}
+	if got := string(fctx.Response.Header.Peek("Location")); got != "/users?q=1" {
+		t.Fatalf("Location=%q", got)
+	}
+}
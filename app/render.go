@@ -0,0 +1,281 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// HTMLConfig configures the html/template Renderer created by
+// NewHTMLRenderer.
+//
+// Templates are named by their path in FS without the extension, e.g.
+// "users/show" for users/show.html. Every file with the extension is a page
+// that can be passed to Ctx.HTML, except the layout and the partials.
+type HTMLConfig struct {
+	// FS holds the templates, e.g. an embed.FS or os.DirFS("templates").
+	FS fs.FS
+	// Extension of template files. Default ".html".
+	Extension string
+	// Layout names the template that wraps pages, e.g. "layouts/base". A page
+	// that defines a "content" template is rendered by executing the layout,
+	// which includes it with {{template "content" .}}; other pages are
+	// rendered on their own.
+	Layout string
+	// Partials names a directory whose templates, e.g. "partials/nav", can
+	// be included by every page and the layout.
+	Partials string
+	// Funcs are added to the built-in template functions.
+	Funcs template.FuncMap
+	// Reload parses the templates again on every render, so edits show up
+	// without a restart. Meant for development with an os.DirFS.
+	Reload bool
+}
+
+// HTMLRenderer is the html/template Renderer returned by NewHTMLRenderer.
+//
+// Besides HTMLConfig.Funcs, templates can call:
+//
+//	{{url "users.show" "id" .ID}}  path of a named route (see App.URL)
+//	{{csrfToken}}                  CSRF token of the request (see middleware.CSRF)
+type HTMLRenderer struct {
+	cfg   HTMLConfig
+	pages map[string]*htmlPage // nil when Reload is set
+
+	mu  sync.RWMutex
+	app *DefaultApp // set by SetRenderer, for url
+}
+
+// htmlPage is a parsed page. master is never executed: each render executes
+// a clone from pool, whose request-dependent functions read its state.
+type htmlPage struct {
+	master *template.Template
+	entry  string // template executed: the layout or the page
+	pool   sync.Pool
+}
+
+// htmlInstance is a clone of a page bound to its own render state.
+type htmlInstance struct {
+	t     *template.Template
+	state *htmlState
+}
+
+// htmlState holds the request an instance is rendering.
+type htmlState struct{ c ctx.Ctx }
+
+// NewHTMLRenderer parses the templates described by cfg and returns a
+// Renderer for SetRenderer. Parse errors are returned here, or by each
+// render when cfg.Reload is set.
+//
+// Example:
+//
+//	//go:embed templates
+//	var templates embed.FS
+//
+//	sub, _ := fs.Sub(templates, "templates")
+//	r, err := app.NewHTMLRenderer(app.HTMLConfig{FS: sub, Layout: "layouts/base", Partials: "partials"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	a.SetRenderer(r)
+//	a.GET("/users/:id", func(c app.Ctx) error {
+//		return c.HTML(http.StatusOK, "users/show", user)
+//	})
+func NewHTMLRenderer(cfg HTMLConfig) (*HTMLRenderer, error) {
+	if cfg.FS == nil {
+		return nil, errors.New("flash: HTMLConfig.FS is required")
+	}
+	if cfg.Extension == "" {
+		cfg.Extension = ".html"
+	}
+	r := &HTMLRenderer{cfg: cfg}
+	pages, err := r.parse()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Reload {
+		r.pages = pages
+	}
+	return r, nil
+}
+
+// Render executes the page name with data into w.
+func (r *HTMLRenderer) Render(w io.Writer, name string, data any, c ctx.Ctx) error {
+	pages := r.pages
+	if pages == nil {
+		var err error
+		if pages, err = r.parse(); err != nil {
+			return err
+		}
+	}
+	p, ok := pages[name]
+	if !ok {
+		return fmt.Errorf("flash: template %q not found", name)
+	}
+	inst, err := p.instance(r)
+	if err != nil {
+		return err
+	}
+	inst.state.c = c
+	err = inst.t.ExecuteTemplate(w, p.entry, data)
+	inst.state.c = nil
+	p.pool.Put(inst)
+	return err
+}
+
+// instance returns a clone of the page from the pool, or a new one.
+func (p *htmlPage) instance(r *HTMLRenderer) (*htmlInstance, error) {
+	if inst, ok := p.pool.Get().(*htmlInstance); ok {
+		return inst, nil
+	}
+	t, err := p.master.Clone()
+	if err != nil {
+		return nil, err
+	}
+	st := &htmlState{}
+	t.Funcs(r.requestFuncs(st))
+	return &htmlInstance{t: t, state: st}, nil
+}
+
+// funcs returns the template functions available at parse time.
+func (r *HTMLRenderer) funcs() template.FuncMap {
+	fm := template.FuncMap{
+		"url":       r.url,
+		"csrfToken": func() string { return "" },
+	}
+	for k, f := range r.cfg.Funcs {
+		fm[k] = f
+	}
+	return fm
+}
+
+// requestFuncs returns the functions that depend on the request held by st.
+func (r *HTMLRenderer) requestFuncs(st *htmlState) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string {
+			if st.c == nil {
+				return ""
+			}
+			return ctx.CSRFTokenFromContext(st.c.Context())
+		},
+	}
+}
+
+// url builds the path of a named route; parameter values are formatted with
+// fmt.Sprint.
+func (r *HTMLRenderer) url(name string, params ...any) (string, error) {
+	r.mu.RLock()
+	a := r.app
+	r.mu.RUnlock()
+	if a == nil {
+		return "", errors.New("flash: url: renderer is not set on an App")
+	}
+	ps := make([]string, len(params))
+	for i, p := range params {
+		ps[i] = fmt.Sprint(p)
+	}
+	return a.URL(name, ps...)
+}
+
+// parse parses the layout, partials and pages in the FS.
+func (r *HTMLRenderer) parse() (map[string]*htmlPage, error) {
+	ext := r.cfg.Extension
+	base := template.New("").Funcs(r.funcs())
+	var pages []string
+	err := fs.WalkDir(r.cfg.FS, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ext {
+			return err
+		}
+		name := strings.TrimSuffix(p, ext)
+		if name != r.cfg.Layout && !inDir(name, r.cfg.Partials) {
+			pages = append(pages, name)
+			return nil
+		}
+		return parseFile(base.New(name), r.cfg.FS, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r.cfg.Layout != "" && base.Lookup(r.cfg.Layout) == nil {
+		return nil, fmt.Errorf("flash: layout %q not found", r.cfg.Layout)
+	}
+
+	out := make(map[string]*htmlPage, len(pages))
+	for _, name := range pages {
+		t, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		b, err := fs.ReadFile(r.cfg.FS, name+ext)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := t.New(name).Parse(string(b)); err != nil {
+			return nil, err
+		}
+		p := &htmlPage{master: t, entry: name}
+		if r.cfg.Layout != "" {
+			// The page uses the layout if it defines "content" itself, not
+			// through a default in the layout or a partial
+			own, err := template.New(name).Funcs(r.funcs()).Parse(string(b))
+			if err != nil {
+				return nil, err
+			}
+			if own.Lookup("content") != nil {
+				p.entry = r.cfg.Layout
+			}
+		}
+		out[name] = p
+	}
+	return out, nil
+}
+
+// parseFile parses the file p of fsys into t.
+func parseFile(t *template.Template, fsys fs.FS, p string) error {
+	b, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return err
+	}
+	_, err = t.Parse(string(b))
+	return err
+}
+
+// inDir reports whether the slash-separated name is inside dir.
+func inDir(name, dir string) bool {
+	return dir != "" && strings.HasPrefix(name, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// SetRenderer sets the Renderer used by Ctx.HTML. A renderer from
+// NewHTMLRenderer resolves its url function against this App.
+//
+// Example:
+//
+//	r, err := app.NewHTMLRenderer(app.HTMLConfig{FS: os.DirFS("templates"), Reload: dev})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	a.SetRenderer(r)
+func (a *DefaultApp) SetRenderer(r ctx.Renderer) {
+	a.renderer = r
+	if hr, ok := r.(*HTMLRenderer); ok {
+		hr.mu.Lock()
+		hr.app = a
+		hr.mu.Unlock()
+	}
+}
+
+// Renderer returns the Renderer set with SetRenderer, or nil. Apps created
+// by Host fall back to the parent app's.
+func (a *DefaultApp) Renderer() ctx.Renderer {
+	if a.renderer == nil && a.parent != nil {
+		return a.parent.Renderer()
+	}
+	return a.renderer
+}
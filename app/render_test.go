@@ -0,0 +1,155 @@
+package app
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var templatesFS = fstest.MapFS{
+	"layouts/base.html": {Data: []byte(`<title>{{block "title" .}}Site{{end}}</title>{{template "partials/nav" .}}<main>{{template "content" .}}</main>`)},
+	"partials/nav.html": {Data: []byte(`<nav><a href="{{url "users.show" "id" 1}}">me</a></nav>`)},
+	"users/show.html":   {Data: []byte(`{{define "title"}}{{.Name}}{{end}}{{define "content"}}<h1>{{upper .Name}}</h1><input name="_csrf" value="{{csrfToken}}">{{end}}`)},
+	"plain.html":        {Data: []byte(`<p>{{.}}</p>`)},
+	"notes.txt":         {Data: []byte(`not a template`)},
+}
+
+func newTestRenderer(t *testing.T, cfg HTMLConfig) *HTMLRenderer {
+	t.Helper()
+	if cfg.FS == nil {
+		cfg.FS = templatesFS
+	}
+	cfg.Layout, cfg.Partials = "layouts/base", "partials"
+	cfg.Funcs = template.FuncMap{"upper": strings.ToUpper}
+	r, err := NewHTMLRenderer(cfg)
+	require.NoError(t, err)
+	return r
+}
+
+func TestHTMLRenderer(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.SetRenderer(newTestRenderer(t, HTMLConfig{}))
+	withToken := func(next Handler) Handler {
+		return func(c Ctx) error {
+			c.SetRequest(c.Request().WithContext(ctx.ContextWithCSRFToken(c.Context(), "tok<1>")))
+			return next(c)
+		}
+	}
+	a.GET("/users/:id", func(c Ctx) error {
+		return c.HTML(http.StatusOK, "users/show", map[string]string{"Name": "<ada>"})
+	}, withToken).Name("users.show")
+	a.GET("/plain", func(c Ctx) error { return c.HTML(http.StatusAccepted, "plain", "hi") })
+	a.GET("/missing", func(c Ctx) error { return c.HTML(http.StatusOK, "nope", nil) })
+
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `<title>&lt;ada&gt;</title><nav><a href="/users/1">me</a></nav><main><h1>&lt;ADA&gt;</h1><input name="_csrf" value="tok&lt;1&gt;"></main>`, rec.Body.String())
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/plain", nil))
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, `<p>hi</p>`, rec.Body.String())
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/missing", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
+
+func TestHTMLRendererConcurrentRequests(t *testing.T) {
+	r := newTestRenderer(t, HTMLConfig{})
+	a := New().(*DefaultApp)
+	a.SetRenderer(r)
+	a.GET("/users/:id", func(c Ctx) error { return nil }).Name("users.show")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(tok string) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req = req.WithContext(ctx.ContextWithCSRFToken(req.Context(), tok))
+				var c ctx.DefaultContext
+				c.Reset(httptest.NewRecorder(), req, nil, "/")
+				var sb strings.Builder
+				if err := r.Render(&sb, "users/show", map[string]string{"Name": "x"}, &c); err != nil {
+					t.Error(err)
+					return
+				}
+				if !strings.Contains(sb.String(), `value="`+tok+`"`) {
+					t.Errorf("token %s not rendered: %s", tok, sb.String())
+					return
+				}
+			}
+		}(string(rune('a' + i)))
+	}
+	wg.Wait()
+}
+
+func TestHTMLRendererReload(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("layouts/base.html", `[{{template "content" .}}]`)
+	write("partials/nav.html", ``)
+	write("home.html", `{{define "content"}}v1{{end}}`)
+
+	r := newTestRenderer(t, HTMLConfig{FS: os.DirFS(dir), Reload: true})
+	render := func() (string, error) {
+		var sb strings.Builder
+		err := r.Render(&sb, "home", nil, nil)
+		return sb.String(), err
+	}
+	out, err := render()
+	require.NoError(t, err)
+	assert.Equal(t, "[v1]", out)
+
+	write("home.html", `{{define "content"}}v2{{end}}`)
+	out, err = render()
+	require.NoError(t, err)
+	assert.Equal(t, "[v2]", out)
+
+	write("home.html", `{{define "content"}}{{end`)
+	_, err = render()
+	assert.Error(t, err)
+}
+
+func TestNewHTMLRendererErrors(t *testing.T) {
+	_, err := NewHTMLRenderer(HTMLConfig{})
+	assert.Error(t, err)
+
+	_, err = NewHTMLRenderer(HTMLConfig{FS: templatesFS, Layout: "layouts/missing"})
+	assert.ErrorContains(t, err, `layout "layouts/missing" not found`)
+
+	_, err = NewHTMLRenderer(HTMLConfig{FS: fstest.MapFS{"bad.html": {Data: []byte(`{{if}}`)}}})
+	assert.Error(t, err)
+
+	// url needs the App the renderer is set on
+	r, err := NewHTMLRenderer(HTMLConfig{FS: fstest.MapFS{"link.html": {Data: []byte(`{{url "home"}}`)}}})
+	require.NoError(t, err)
+	assert.Error(t, r.Render(&strings.Builder{}, "link", nil, nil))
+}
+
+func TestHTMLWithoutRenderer(t *testing.T) {
+	a := New()
+	var got error
+	a.SetErrorHandler(func(c Ctx, err error) { got = err })
+	a.GET("/", func(c Ctx) error { return c.HTML(http.StatusOK, "home", nil) })
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, errors.Is(got, ctx.ErrNoRenderer))
+}
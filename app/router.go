@@ -2,6 +2,7 @@ package app
 
 import (
 	"net/http"
+	"strings"
 )
 
 // GET registers a handler for HTTP GET requests on the given path.
@@ -16,8 +17,13 @@ import (
 //
 //	a.GET("/users/:id", ShowUser, Auth)
 //	// order: global -> Auth -> ShowUser; handler sees c.Param("id")
-func (a *DefaultApp) GET(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodGet, path, h, mws...)
+//
+// Example (named route for reverse routing with URL):
+//
+//	a.GET("/users/:id", ShowUser).Name("users.show")
+//	u, _ := a.URL("users.show", "id", "42") // "/users/42"
+func (a *DefaultApp) GET(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodGet, path, h, mws...)
 }
 
 // POST registers a handler for HTTP POST requests on the given path.
@@ -27,8 +33,8 @@ func (a *DefaultApp) GET(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.POST("/users", CreateUser, CSRF)
-func (a *DefaultApp) POST(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodPost, path, h, mws...)
+func (a *DefaultApp) POST(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodPost, path, h, mws...)
 }
 
 // PUT registers a handler for HTTP PUT requests on the given path.
@@ -38,8 +44,8 @@ func (a *DefaultApp) POST(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.PUT("/users/:id", ReplaceUser)
-func (a *DefaultApp) PUT(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodPut, path, h, mws...)
+func (a *DefaultApp) PUT(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodPut, path, h, mws...)
 }
 
 // PATCH registers a handler for HTTP PATCH requests on the given path.
@@ -49,8 +55,8 @@ func (a *DefaultApp) PUT(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.PATCH("/users/:id", UpdateUserEmail)
-func (a *DefaultApp) PATCH(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodPatch, path, h, mws...)
+func (a *DefaultApp) PATCH(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodPatch, path, h, mws...)
 }
 
 // DELETE registers a handler for HTTP DELETE requests on the given path.
@@ -59,8 +65,8 @@ func (a *DefaultApp) PATCH(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.DELETE("/users/:id", DeleteUser, Audit)
-func (a *DefaultApp) DELETE(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodDelete, path, h, mws...)
+func (a *DefaultApp) DELETE(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodDelete, path, h, mws...)
 }
 
 // OPTIONS registers a handler for HTTP OPTIONS requests on the given path.
@@ -70,8 +76,8 @@ func (a *DefaultApp) DELETE(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.OPTIONS("/users", Preflight)
-func (a *DefaultApp) OPTIONS(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodOptions, path, h, mws...)
+func (a *DefaultApp) OPTIONS(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodOptions, path, h, mws...)
 }
 
 // HEAD registers a handler for HTTP HEAD requests on the given path.
@@ -81,8 +87,8 @@ func (a *DefaultApp) OPTIONS(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.HEAD("/health", HeadHealth)
-func (a *DefaultApp) HEAD(path string, h Handler, mws ...Middleware) {
-	a.handle(http.MethodHead, path, h, mws...)
+func (a *DefaultApp) HEAD(path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(http.MethodHead, path, h, mws...)
 }
 
 // ANY registers a handler for all common HTTP methods (GET, POST, PUT, PATCH,
@@ -92,10 +98,12 @@ func (a *DefaultApp) HEAD(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.ANY("/webhook", Webhook)
-func (a *DefaultApp) ANY(path string, h Handler, mws ...Middleware) {
-	for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodHead} {
+func (a *DefaultApp) ANY(path string, h Handler, mws ...Middleware) *Route {
+	methods := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodHead}
+	for _, m := range methods {
 		a.handle(m, path, h, mws...)
 	}
+	return &Route{app: a, methods: methods, pattern: path}
 }
 
 // Handle registers a handler for a custom HTTP method on the given path.
@@ -106,8 +114,8 @@ func (a *DefaultApp) ANY(path string, h Handler, mws ...Middleware) {
 // Example:
 //
 //	a.Handle("REPORT", "/dav/resource", HandleReport)
-func (a *DefaultApp) Handle(method, path string, h Handler, mws ...Middleware) {
-	a.handle(method, path, h, mws...)
+func (a *DefaultApp) Handle(method, path string, h Handler, mws ...Middleware) *Route {
+	return a.handle(method, path, h, mws...)
 }
 
 // handle is the internal route registration and handler composition method.
@@ -124,31 +132,169 @@ func (a *DefaultApp) Handle(method, path string, h Handler, mws ...Middleware) {
 //   - Static routes use O(1) map lookup
 //   - Dynamic routes use optimized radix tree traversal
 //   - Zero allocations during request handling
-func (a *DefaultApp) handle(method, path string, h Handler, mws ...Middleware) {
-	// Combine global and route-specific middleware
-	allMiddleware := make([]Middleware, 0, len(a.middleware)+len(mws))
-	allMiddleware = append(allMiddleware, a.middleware...)
-	allMiddleware = append(allMiddleware, mws...)
+//
+// Conflicts are detected here rather than at request time. Registering the
+// same method and pattern twice, or a pattern that is ambiguous with an
+// existing one (e.g. "/users/:id" and "/users/:name"), panics with a message
+// naming both routes. Overlapping but unambiguous patterns are resolved with
+// a fixed priority: static > param > catch-all, so "/files/new" always wins
+// over "/files/*path" regardless of registration order. Only constrained
+// parameters at the same position are tried in registration order (see
+// Route tree in tree.go).
+func (a *DefaultApp) handle(method, path string, h Handler, mws ...Middleware) *Route {
+	return a.addRoute(RouteInfo{Method: method, Pattern: path, Handler: handlerName(h)}, h, mws)
+}
 
-	// Create pre-compiled chain
-	chain := newFastChain(allMiddleware, h)
+// addRoute registers h for info.Method and info.Pattern and records info for
+// Routes. It is the single registration path shared by the App and Group
+// helpers; callers fill in the descriptive fields (Handler, Group) and
+// addRoute completes the rest. The returned Route can be used to name it.
+//
+// mws holds the route-specific middleware only; global middleware is kept
+// separately so that routes can be recompiled when Use is called later.
+func (a *DefaultApp) addRoute(info RouteInfo, h Handler, mws []Middleware) *Route {
+	method, path := info.Method, info.Pattern
+	info.Host = a.host
 
 	// Register route with ultra-fast path optimization
+	e := routeEntry{handler: h, middleware: mws, dynamic: containsParams(path)}
+	if e.dynamic {
+		validatePattern(path)
+	}
+	a.fireRoute(a.insertRoute(info, e))
+	return &Route{app: a, methods: []string{method}, pattern: path}
+}
+
+// routeEntry is what is needed to (re)compile a registered route.
+type routeEntry struct {
+	handler    Handler
+	middleware []Middleware // route-specific, including group middleware
+	dynamic    bool
+}
+
+// insertRoute compiles e with the current global middleware and stores it in
+// the router, panicking if the route is already registered. It returns info
+// as recorded for Routes.
+func (a *DefaultApp) insertRoute(info RouteInfo, e routeEntry) RouteInfo {
+	method, path := info.Method, info.Pattern
 	routeKey := method + ":" + path
 	a.router.mu.Lock()
+	defer a.router.mu.Unlock()
+
+	if !e.dynamic {
+		_, inSimple := a.router.simple[routeKey]
+		_, inStatic := a.router.static[routeKey]
+		if inSimple || inStatic {
+			panic("flash: route '" + method + " " + path + "' is already registered")
+		}
+
+		foldKey := method + ":" + strings.ToLower(path)
+		if _, ok := a.router.folded[foldKey]; !ok {
+			a.router.folded[foldKey] = path
+		}
+	}
+
+	info.Middlewares = a.storeRoute(method, path, e, a.globalMiddleware())
+	a.router.addMethod(method)
+	a.router.routes = append(a.router.routes, info)
+	a.router.entries = append(a.router.entries, e)
+	return info
+}
+
+// storeRoute compiles e behind global and stores the chain for method and
+// path, returning the total number of middleware. Static routes without
+// middleware keep the bare handler for the fastest dispatch. Callers must hold
+// a.router.mu for writing.
+func (a *DefaultApp) storeRoute(method, path string, e routeEntry, global []Middleware) int {
+	routeKey := method + ":" + path
+	allMiddleware := make([]Middleware, 0, len(global)+len(e.middleware))
+	allMiddleware = append(allMiddleware, global...)
+	allMiddleware = append(allMiddleware, e.middleware...)
 
-	if len(allMiddleware) == 0 && !containsParams(path) {
+	if len(allMiddleware) == 0 && !e.dynamic {
 		// Ultra-fast path: simple handler with no middleware or parameters
-		a.router.simple[routeKey] = h
-	} else if !containsParams(path) {
-		// Static route: O(1) lookup with middleware
-		a.router.static[routeKey] = chain
+		a.router.simple[routeKey] = e.handler
+	} else if !e.dynamic {
+		// Static route: O(1) lookup with pre-compiled chain
+		a.router.static[routeKey] = newFastChain(allMiddleware, e.handler)
 	} else {
 		// Dynamic route: add to radix tree
-		a.addDynamicRoute(method, path, chain)
+		a.addDynamicRoute(method, path, newFastChain(allMiddleware, e.handler))
 	}
+	return len(allMiddleware)
+}
 
-	a.router.mu.Unlock()
+// Build compiles the middleware chains of every registered route against the
+// current global middleware. Chains are compiled when routes are registered,
+// so Build only has work to do after Use was called with routes already in
+// place. It runs automatically before the next request is served and when a
+// server is started, so calling it is only needed to move that work out of
+// the first request. Build is safe to call more than once.
+//
+// Example:
+//
+//	a.GET("/admin", Admin)
+//	a.Use(Auth) // also applies to /admin
+//	a.Build()   // optional: compile now instead of on the first request
+func (a *DefaultApp) Build() {
+	if !a.stale.Load() {
+		return
+	}
+	a.router.mu.Lock()
+	defer a.router.mu.Unlock()
+	if !a.stale.Load() {
+		return
+	}
+	a.recompile()
+	for _, h := range a.hosts {
+		h.app.router.mu.Lock()
+		h.app.recompile()
+		h.app.router.mu.Unlock()
+	}
+	a.stale.Store(false)
+}
+
+// recompile rebuilds the route tables from the registered entries. Callers
+// must hold a.router.mu for writing.
+func (a *DefaultApp) recompile() {
+	global := a.globalMiddleware()
+	a.router.simple = make(map[string]Handler, len(a.router.simple))
+	a.router.static = make(map[string]*FastChain, len(a.router.static))
+	a.router.dynamic = make(map[string]*radixNode, len(a.router.dynamic))
+	for i, e := range a.router.entries {
+		info := &a.router.routes[i]
+		info.Middlewares = a.storeRoute(info.Method, info.Pattern, e, global)
+	}
+	a.router.options = newFastChain(global, defaultOptionsHandler)
+	a.routeCache.purge()
+}
+
+// optionsHandler returns the handler of automatic OPTIONS responses: the
+// global middleware around defaultOptionsHandler. The chain is compiled on
+// first use and by recompile, so middleware is constructed once per chain as
+// for routes.
+func (a *DefaultApp) optionsHandler() Handler {
+	a.router.mu.RLock()
+	chain := a.router.options
+	a.router.mu.RUnlock()
+	if chain == nil {
+		a.router.mu.Lock()
+		if a.router.options == nil {
+			a.router.options = newFastChain(a.globalMiddleware(), defaultOptionsHandler)
+		}
+		chain = a.router.options
+		a.router.mu.Unlock()
+	}
+	return chain.exec
+}
+
+// globalMiddleware returns the middleware registered with Use, preceded by the
+// parent app's for apps created by Host.
+func (a *DefaultApp) globalMiddleware() []Middleware {
+	if a.parent == nil {
+		return a.middleware
+	}
+	return append(append([]Middleware{}, a.parent.middleware...), a.middleware...)
 }
 
 // containsParams checks if a route path contains parameters (: or *)
@@ -161,14 +307,16 @@ func containsParams(path string) bool {
 	return false
 }
 
-// addDynamicRoute adds a route with parameters to the radix tree
-// This is a simplified implementation - a full radix tree would be more complex
+// addDynamicRoute adds a route with parameters to the method's radix tree.
+// Callers must hold a.router.mu for writing.
 func (a *DefaultApp) addDynamicRoute(method, path string, chain *FastChain) {
-	// For now, we'll store dynamic routes in a simple map
-	// In a full implementation, this would build a proper radix tree
-	key := method + ":" + path
-	if a.router.static == nil {
-		a.router.static = make(map[string]*FastChain)
+	root := a.router.dynamic[method]
+	if root == nil {
+		root = &radixNode{}
+		a.router.dynamic[method] = root
 	}
-	a.router.static[key] = chain
+	root.insert(path, chain)
+
+	// A new pattern may take priority over previously cached matches
+	a.routeCache.purge()
 }
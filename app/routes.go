@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// RouteInfo describes a registered route as returned by Routes.
+//
+// Example (print a route table at boot):
+//
+//	for _, r := range a.Routes() {
+//		fmt.Printf("%-7s %-30s %-40s mw=%d group=%q\n",
+//			r.Method, r.Pattern, r.Handler, r.Middlewares, r.Group)
+//	}
+type RouteInfo struct {
+	// Method is the HTTP method, e.g. "GET" or a custom verb like "PURGE".
+	Method string `json:"method"`
+	// Pattern is the full route pattern including any group prefix, e.g. "/api/users/:id".
+	Pattern string `json:"pattern"`
+	// Handler is the fully qualified name of the handler function
+	// (e.g. "main.ShowUser"), or the type name for net/http handlers.
+	Handler string `json:"handler"`
+	// Middlewares is the number of middleware in the compiled chain
+	// (global, group and route-specific combined).
+	Middlewares int `json:"middlewares"`
+	// Group is the prefix of the group the route was registered on, or "" for
+	// routes registered directly on the App.
+	Group string `json:"group,omitempty"`
+	// Name is the route name assigned with Route.Name, if any.
+	Name string `json:"name,omitempty"`
+	// Host is the host pattern for routes registered through App.Host, or ""
+	// for routes served on any host.
+	Host string `json:"host,omitempty"`
+}
+
+// Routes returns every registered route, covering static routes, routes
+// without middleware and parameterized routes alike.
+//
+// Routes registered through Host are included with RouteInfo.Host set.
+//
+// The result is sorted by pattern, method and host so it is stable across runs
+// and suitable for diffing in CI. The returned slice is a copy and may be
+// modified freely.
+//
+// Example (fail CI when the route table changes unexpectedly):
+//
+//	b, _ := json.MarshalIndent(a.Routes(), "", "  ")
+//	_ = os.WriteFile("routes.json", b, 0o644)
+func (a *DefaultApp) Routes() []RouteInfo {
+	a.router.mu.RLock()
+	out := make([]RouteInfo, len(a.router.routes))
+	copy(out, a.router.routes)
+	hosts := a.hosts
+	a.router.mu.RUnlock()
+	for _, h := range hosts {
+		out = append(out, h.app.Routes()...)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Pattern != out[j].Pattern {
+			return out[i].Pattern < out[j].Pattern
+		}
+		if out[i].Method != out[j].Method {
+			return out[i].Method < out[j].Method
+		}
+		return out[i].Host < out[j].Host
+	})
+	return out
+}
+
+// handlerName returns a human-readable name for a handler: the function name
+// for funcs (including http.HandlerFunc), otherwise the dynamic type name.
+func handlerName(h any) string {
+	if h == nil {
+		return ""
+	}
+	v := reflect.ValueOf(h)
+	if v.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(v.Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
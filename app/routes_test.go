@@ -0,0 +1,57 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func listUsers(c Ctx) error { return nil }
+
+func TestRoutesListsAllRouteKinds(t *testing.T) {
+	a := New()
+	mw := func(next Handler) Handler { return next }
+	a.Use(mw)
+	a.GET("/ping", func(c Ctx) error { return nil })
+	api := a.Group("/api", mw)
+	api.GET("/users", listUsers)
+	api.POST("/users/:id", listUsers, mw)
+	a.HandleHTTP(http.MethodGet, "/std", http.NotFoundHandler())
+
+	b := New()
+	b.GET("/simple", listUsers)
+
+	got := a.Routes()
+	want := []RouteInfo{
+		{Method: http.MethodGet, Pattern: "/api/users", Middlewares: 2, Group: "/api"},
+		{Method: http.MethodPost, Pattern: "/api/users/:id", Middlewares: 3, Group: "/api"},
+		{Method: http.MethodGet, Pattern: "/ping", Middlewares: 1},
+		{Method: http.MethodGet, Pattern: "/std", Middlewares: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("routes=%+v", got)
+	}
+	for i := range want {
+		g := got[i]
+		if g.Method != want[i].Method || g.Pattern != want[i].Pattern || g.Middlewares != want[i].Middlewares || g.Group != want[i].Group {
+			t.Fatalf("route %d = %+v want %+v", i, g, want[i])
+		}
+		if g.Handler == "" {
+			t.Fatalf("route %d missing handler name", i)
+		}
+	}
+	if !strings.HasSuffix(got[0].Handler, ".listUsers") {
+		t.Fatalf("handler name=%q", got[0].Handler)
+	}
+
+	simple := b.Routes()
+	if len(simple) != 1 || simple[0].Middlewares != 0 || simple[0].Pattern != "/simple" {
+		t.Fatalf("simple routes=%+v", simple)
+	}
+
+	// Returned slice is a copy
+	got[0].Pattern = "/changed"
+	if a.Routes()[0].Pattern != "/api/users" {
+		t.Fatalf("Routes exposed internal state")
+	}
+}
@@ -0,0 +1,280 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ServerConfig holds the settings applied to servers started with Listen,
+// ListenTLS, ListenFastHTTP and Serve.
+//
+// Example:
+//
+//	cfg := app.DefaultServerConfig
+//	cfg.WriteTimeout = 2 * time.Minute // long-running exports
+//	a.SetServerConfig(cfg)
+type ServerConfig struct {
+	// ReadTimeout limits reading the entire request, including the body.
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout limits reading the request headers (net/http only).
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout limits writing the response.
+	WriteTimeout time.Duration
+	// IdleTimeout limits how long keep-alive connections wait for the next request.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes limits the size of the request headers (net/http only;
+	// 0 = transport default).
+	MaxHeaderBytes int
+}
+
+// DefaultServerConfig is used by servers started from an App unless replaced
+// with SetServerConfig. The timeouts protect against slow clients holding
+// connections open indefinitely.
+var DefaultServerConfig = ServerConfig{
+	ReadTimeout:       15 * time.Second,
+	ReadHeaderTimeout: 5 * time.Second,
+	WriteTimeout:      30 * time.Second,
+	IdleTimeout:       120 * time.Second,
+	MaxHeaderBytes:    1 << 20,
+}
+
+// shutdownPollInterval is how often Shutdown checks for in-flight requests.
+const shutdownPollInterval = 10 * time.Millisecond
+
+// SetServerConfig sets the configuration for servers started afterwards.
+func (a *DefaultApp) SetServerConfig(cfg ServerConfig) { a.serverConfig = cfg }
+
+// ServerConfig returns the configuration used for servers started by the app.
+func (a *DefaultApp) ServerConfig() ServerConfig { return a.serverConfig }
+
+// Listen serves the app over net/http on addr. It blocks until the server
+// stops and returns nil when it is stopped by Shutdown. Once the app has been
+// shut down it cannot be served again: Listen returns http.ErrServerClosed.
+//
+// Example:
+//
+//	go func() {
+//		if err := a.Listen(":8080"); err != nil {
+//			log.Fatal(err)
+//		}
+//	}()
+//	<-ctx.Done() // e.g. signal.NotifyContext(context.Background(), os.Interrupt)
+//	_ = a.Shutdown(context.Background())
+func (a *DefaultApp) Listen(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return a.Serve(ln)
+}
+
+// ListenTLS is Listen with TLS, loading the certificate and key from files.
+//
+// Example:
+//
+//	log.Fatal(a.ListenTLS(":8443", "cert.pem", "key.pem"))
+func (a *DefaultApp) ListenTLS(addr, certFile, keyFile string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := a.newHTTPServer()
+	if err := a.startServer(ln, srv.Shutdown); err != nil {
+		return err
+	}
+	return ignoreServerClosed(srv.ServeTLS(ln, certFile, keyFile))
+}
+
+// Serve serves the app over net/http on an existing listener, which is closed
+// when the server stops. It returns nil when the server is stopped by
+// Shutdown, and http.ErrServerClosed if the app was already shut down.
+//
+// Example:
+//
+//	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+//	go a.Serve(ln)
+func (a *DefaultApp) Serve(ln net.Listener) error {
+	srv := a.newHTTPServer()
+	if err := a.startServer(ln, srv.Shutdown); err != nil {
+		return err
+	}
+	return ignoreServerClosed(srv.Serve(ln))
+}
+
+// ListenFastHTTP serves the app over fasthttp on addr using ServeFastHTTP.
+// It blocks until the server stops and returns nil when it is stopped by
+// Shutdown, and http.ErrServerClosed if the app was already shut down.
+//
+// Example:
+//
+//	log.Fatal(a.ListenFastHTTP(":8080"))
+func (a *DefaultApp) ListenFastHTTP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return a.serveFastHTTPListener(ln)
+}
+
+// serveFastHTTPListener serves the app over fasthttp on ln.
+func (a *DefaultApp) serveFastHTTPListener(ln net.Listener) error {
+	cfg := a.serverConfig
+	srv := &fasthttp.Server{
+		Handler:      a.ServeFastHTTP,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	// fasthttp only stops listeners that Serve has registered, so a Shutdown
+	// before that point closes ln itself; Serve then returns nil at once.
+	shutdown := func(ctx context.Context) error {
+		err := srv.ShutdownWithContext(ctx)
+		_ = ln.Close()
+		return err
+	}
+	if err := a.startServer(ln, shutdown); err != nil {
+		return err
+	}
+	return srv.Serve(ln)
+}
+
+// startServer prepares a server about to serve ln: it builds the routes,
+// tracks shutdown and runs the OnStart hooks. On error ln is closed, and
+// http.ErrServerClosed is returned if the app was already shut down.
+func (a *DefaultApp) startServer(ln net.Listener, shutdown func(context.Context) error) error {
+	a.Build()
+	if !a.trackServer(shutdown) {
+		_ = ln.Close()
+		return http.ErrServerClosed
+	}
+	if err := a.fireStart(ln.Addr().String()); err != nil {
+		_ = ln.Close()
+		return err
+	}
+	return nil
+}
+
+// newHTTPServer returns a net/http server for the app using its ServerConfig.
+func (a *DefaultApp) newHTTPServer() *http.Server {
+	cfg := a.serverConfig
+	return &http.Server{
+		Handler:           a,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// trackServer records the shutdown function of a server being started. It
+// returns false when the app is already shutting down, in which case the
+// server must not start and http.ErrServerClosed is returned.
+func (a *DefaultApp) trackServer(shutdown func(context.Context) error) bool {
+	a.lifecycleMu.Lock()
+	defer a.lifecycleMu.Unlock()
+	if a.shuttingDown {
+		return false
+	}
+	a.servers = append(a.servers, shutdown)
+	return true
+}
+
+// Shutdown gracefully stops every server started by the app. It stops
+// accepting connections, waits for in-flight requests to finish, then runs the
+// OnShutdown hooks. If ctx expires first, Shutdown still runs the hooks and
+// returns the context's error joined with any server or hook errors.
+//
+// Requests are tracked by ServeHTTP and ServeFastHTTP, so handlers served
+// through a custom server are also drained. Requests served by ListenFastHTTP
+// see their Context cancelled as soon as Shutdown begins, and connections of
+// WS routes are closed then on every transport.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := a.Shutdown(ctx); err != nil {
+//		log.Printf("shutdown: %v", err)
+//	}
+func (a *DefaultApp) Shutdown(ctx context.Context) error {
+	a.lifecycleMu.Lock()
+	if !a.shuttingDown && a.stopping != nil {
+		close(a.stopping)
+	}
+	a.shuttingDown = true
+	servers := a.servers
+	a.servers = nil
+	hooks := a.hooks.onShutdown
+	a.lifecycleMu.Unlock()
+
+	var errs []error
+	for _, shutdown := range servers {
+		if err := shutdown(ctx); err != nil && ctx.Err() == nil {
+			errs = append(errs, err)
+		}
+	}
+	a.drain(ctx)
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, hook := range hooks {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InFlight returns the number of requests currently being handled.
+func (a *DefaultApp) InFlight() int64 { return a.inflight.Load() }
+
+// shutdownStarted returns a channel closed when Shutdown begins, for
+// connections that would otherwise keep draining waiting, such as those of
+// WS routes. Apps created by Host use the App's.
+func (a *DefaultApp) shutdownStarted() <-chan struct{} {
+	if a.parent != nil {
+		return a.parent.shutdownStarted()
+	}
+	a.lifecycleMu.Lock()
+	defer a.lifecycleMu.Unlock()
+	if a.stopping == nil {
+		a.stopping = make(chan struct{})
+		if a.shuttingDown {
+			close(a.stopping)
+		}
+	}
+	return a.stopping
+}
+
+// drain waits until no request is in flight or ctx is done.
+func (a *DefaultApp) drain(ctx context.Context) {
+	if a.inflight.Load() == 0 {
+		return
+	}
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if a.inflight.Load() == 0 {
+				return
+			}
+		}
+	}
+}
+
+// ignoreServerClosed maps http.ErrServerClosed, returned after Shutdown, to nil.
+func ignoreServerClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
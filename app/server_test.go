@@ -0,0 +1,214 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeAndShutdownDrainsInFlight(t *testing.T) {
+	a := New().(*DefaultApp)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	a.GET("/slow", func(c Ctx) error {
+		close(started)
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+	var hookRan bool
+	a.OnShutdown(func(ctx context.Context) error {
+		if a.InFlight() != 0 {
+			t.Errorf("hook ran with %d requests in flight", a.InFlight())
+		}
+		hookRan = true
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- a.Serve(ln) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- a.Shutdown(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the request finished: %v", err)
+	default:
+	}
+
+	close(release)
+	if got := <-body; got != "done" {
+		t.Fatalf("in-flight request: %q", got)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if !hookRan {
+		t.Fatalf("shutdown hook not run")
+	}
+	if _, err := net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond); err == nil {
+		t.Fatalf("listener still accepting after Shutdown")
+	}
+}
+
+func TestShutdownTimeoutAndHookErrors(t *testing.T) {
+	a := New().(*DefaultApp)
+	hookErr := errors.New("close db")
+	a.OnShutdown(func(ctx context.Context) error { return hookErr })
+
+	a.inflight.Add(1) // a request that never finishes
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err := a.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, hookErr) {
+		t.Fatalf("expected deadline and hook errors, got %v", err)
+	}
+}
+
+func TestServeAfterShutdown(t *testing.T) {
+	a := New().(*DefaultApp)
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Servers started after Shutdown do not run, like http.Server
+	starts := map[string]func(ln net.Listener) error{
+		"Serve":          a.Serve,
+		"ListenFastHTTP": a.serveFastHTTPListener,
+	}
+	for name, start := range starts {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := start(ln); !errors.Is(err, http.ErrServerClosed) {
+			t.Fatalf("%s after Shutdown: %v", name, err)
+		}
+		if _, err := net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond); err == nil {
+			t.Fatalf("%s after Shutdown: listener still open", name)
+		}
+	}
+	if err := a.Listen("127.0.0.1:0"); !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("Listen after Shutdown: %v", err)
+	}
+	if err := a.ListenTLS("127.0.0.1:0", "cert.pem", "key.pem"); !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("ListenTLS after Shutdown: %v", err)
+	}
+}
+
+func TestListenFastHTTPShutdown(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.GET("/ping", func(c Ctx) error { return c.String(http.StatusOK, "pong") })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- a.serveFastHTTPListener(ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "pong" {
+		t.Fatalf("body=%q", b)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+}
+
+func TestShutdownCancelsFastHTTPRequestContext(t *testing.T) {
+	a := New().(*DefaultApp)
+	started := make(chan struct{})
+	a.GET("/wait", func(c Ctx) error {
+		close(started)
+		select {
+		case <-c.Context().Done():
+			return c.String(http.StatusServiceUnavailable, "cancelled")
+		case <-time.After(5 * time.Second):
+			return c.String(http.StatusOK, "not cancelled")
+		}
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = a.serveFastHTTPListener(ln) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/wait")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		body <- string(b)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := a.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := <-body; got != "cancelled" {
+		t.Fatalf("body=%q", got)
+	}
+}
+
+func TestListenErrors(t *testing.T) {
+	a := New()
+	if err := a.Listen("256.0.0.1:bad"); err == nil {
+		t.Fatalf("expected Listen error")
+	}
+	if err := a.ListenFastHTTP("256.0.0.1:bad"); err == nil {
+		t.Fatalf("expected ListenFastHTTP error")
+	}
+	if err := a.ListenTLS("256.0.0.1:bad", "c", "k"); err == nil {
+		t.Fatalf("expected ListenTLS error")
+	}
+
+	cfg := DefaultServerConfig
+	cfg.WriteTimeout = time.Minute
+	a.SetServerConfig(cfg)
+	if a.ServerConfig().WriteTimeout != time.Minute {
+		t.Fatalf("ServerConfig not applied")
+	}
+}
@@ -0,0 +1,423 @@
+package app
+
+import (
+	"sort"
+	"strings"
+
+	router "github.com/julienschmidt/httprouter"
+)
+
+// Route tree
+//
+// Parameterized routes are stored in one radixNode tree per HTTP method. Each
+// edge of the tree corresponds to a single path segment, so a lookup walks the
+// request path once, segment by segment, instead of testing every registered
+// pattern in turn.
+//
+// Matching priority at every segment is deterministic and, except between
+// constrained parameters, independent of registration order:
+//
+//  1. static segments ("/users/new")
+//  2. named parameters ("/users/:id"); among several parameters at the same
+//     position, those with a longer literal prefix ("/v:version") come first,
+//     then constrained ones ("/:id<int>"), then unconstrained ones
+//  3. catch-all parameters ("/users/*rest")
+//
+// Constrained parameters with the same literal prefix are tried in
+// registration order, since whether two constraints overlap cannot be
+// decided in general: with "/:id<int>" registered before "/:hex<[0-9a-f]+>",
+// "/12" matches the first and "/1a" the second, and the other way round "/12"
+// matches the second too. Give such siblings disjoint constraints.
+//
+// If a higher-priority branch fails to match the remainder of the path, the
+// lookup backtracks and tries the next candidate, so "/users/new/edit" can
+// still be served by "/users/:id/edit", and "/users/abc" falls through from
+// "/users/:id<int>" to "/users/:slug<[a-z]+>".
+//
+// Patterns that would make this order ambiguous are rejected at registration
+// time with a panic (see validatePattern and radixNode.child): two
+// unconstrained parameters with different names at the same position
+// ("/users/:id" and "/users/:name"), a catch-all that is not the final
+// segment, or more than one parameter in a segment.
+
+// segmentKind classifies a parsed pattern segment.
+type segmentKind uint8
+
+const (
+	segStatic segmentKind = iota
+	segParam
+	segCatchAll
+)
+
+// patternSegment is one parsed segment of a route pattern, e.g. "v:version<\d+>"
+// is {kind: segParam, prefix: "v", name: "version", constraint: `\d+`}.
+type patternSegment struct {
+	kind       segmentKind
+	text       string // segment as written in the pattern
+	prefix     string // literal text before ':'
+	name       string // parameter name
+	constraint string // text between '<' and '>' (empty if unconstrained)
+}
+
+// parseSegment parses a single pattern segment, panicking with a message that
+// names pattern if the segment is malformed.
+func parseSegment(seg, pattern string) patternSegment {
+	ps := patternSegment{kind: segStatic, text: seg}
+	colon := strings.IndexByte(seg, ':')
+	star := strings.IndexByte(seg, '*')
+	switch {
+	case star == 0:
+		ps.kind = segCatchAll
+		ps.name = seg[1:]
+		if strings.ContainsAny(ps.name, ":*<>") {
+			panic("flash: route '" + pattern + "': invalid catch-all '" + seg + "' (constraints are not supported on catch-alls)")
+		}
+	case colon >= 0 && (star < 0 || colon < star):
+		ps.kind = segParam
+		ps.prefix = seg[:colon]
+		ps.name = seg[colon+1:]
+		if strings.ContainsAny(ps.prefix, "*<>") {
+			panic("flash: route '" + pattern + "': invalid literal prefix in segment '" + seg + "'")
+		}
+		if lt := strings.IndexByte(ps.name, '<'); lt >= 0 {
+			if !strings.HasSuffix(ps.name, ">") || lt == len(ps.name)-2 {
+				panic("flash: route '" + pattern + "': malformed constraint in segment '" + seg + "'")
+			}
+			ps.constraint = ps.name[lt+1 : len(ps.name)-1]
+			ps.name = ps.name[:lt]
+		}
+	case star > 0:
+		panic("flash: route '" + pattern + "': catch-all in segment '" + seg + "' must start the segment")
+	default:
+		return ps
+	}
+	if ps.name == "" || strings.ContainsAny(ps.name, ":*<>") {
+		panic("flash: route '" + pattern + "': invalid parameter name in segment '" + seg + "'")
+	}
+	return ps
+}
+
+// insert adds pattern to the tree rooted at n and attaches chain to the node
+// that terminates it. The pattern must start with '/'.
+//
+// Example:
+//
+//	root := &radixNode{}
+//	root.insert("/users/:id<int>/posts/*rest", chain)
+func (n *radixNode) insert(pattern string, chain *FastChain) {
+	cur := n
+	rest := strings.TrimPrefix(pattern, "/")
+	for {
+		seg, tail, more := nextPatternSegment(rest)
+		cur = cur.child(parseSegment(seg, pattern), pattern)
+		if !more {
+			break
+		}
+		rest = tail
+	}
+	if cur.handler != nil {
+		panic("flash: route '" + pattern + "' is already registered")
+	}
+	cur.handler = chain
+	cur.pattern = pattern
+}
+
+// child returns the child node for the pattern segment ps, creating it when
+// it does not exist yet.
+func (n *radixNode) child(ps patternSegment, pattern string) *radixNode {
+	switch ps.kind {
+	case segParam:
+		for _, c := range n.params {
+			if c.prefix != ps.prefix || c.constraintText() != ps.constraint {
+				continue
+			}
+			if c.paramName != ps.name {
+				panic("flash: route '" + pattern + "' conflicts with '" + c.firstPattern() +
+					"': parameter ':" + ps.name + "' and ':" + c.paramName + "' share the same position")
+			}
+			return c
+		}
+		c := &radixNode{path: ps.text, wildcard: true, paramName: ps.name, prefix: ps.prefix}
+		if ps.constraint != "" {
+			c.constraint = newParamConstraint(ps.constraint, pattern)
+		}
+		n.params = append(n.params, c)
+		// Stable: ties keep registration order (see Route tree)
+		sort.SliceStable(n.params, func(i, j int) bool {
+			a, b := n.params[i], n.params[j]
+			if len(a.prefix) != len(b.prefix) {
+				return len(a.prefix) > len(b.prefix)
+			}
+			return a.constraint != nil && b.constraint == nil
+		})
+		return c
+
+	case segCatchAll:
+		if n.catchAll == nil {
+			n.catchAll = &radixNode{path: ps.text, wildcard: true, paramName: ps.name}
+		} else if n.catchAll.paramName != ps.name {
+			panic("flash: route '" + pattern + "' conflicts with '" + n.catchAll.firstPattern() +
+				"': catch-all '*" + ps.name + "' and '*" + n.catchAll.paramName + "' share the same position")
+		}
+		return n.catchAll
+
+	default:
+		seg := ps.text
+		if c := n.staticChild(seg); c != nil {
+			return c
+		}
+		c := &radixNode{path: seg}
+		first := byte(0)
+		if len(seg) > 0 {
+			first = seg[0]
+		}
+		n.indices += string(first)
+		n.children = append(n.children, c)
+		return c
+	}
+}
+
+// constraintText returns the raw constraint of a parameter node, or "".
+func (n *radixNode) constraintText() string {
+	if n.constraint == nil {
+		return ""
+	}
+	return n.constraint.raw
+}
+
+// accepts reports whether the request segment seg matches the parameter node
+// n and returns the captured value.
+func (n *radixNode) accepts(seg string) (string, bool) {
+	if !strings.HasPrefix(seg, n.prefix) {
+		return "", false
+	}
+	v := seg[len(n.prefix):]
+	if v == "" {
+		return "", false
+	}
+	if n.constraint != nil && !n.constraint.match(v) {
+		return "", false
+	}
+	return v, true
+}
+
+// firstPattern returns a route pattern registered at or below n. It is used to
+// name the existing route in conflict messages.
+func (n *radixNode) firstPattern() string {
+	if n.pattern != "" {
+		return n.pattern
+	}
+	for _, c := range n.children {
+		if p := c.firstPattern(); p != "" {
+			return p
+		}
+	}
+	for _, c := range n.params {
+		if p := c.firstPattern(); p != "" {
+			return p
+		}
+	}
+	if n.catchAll != nil {
+		return n.catchAll.firstPattern()
+	}
+	return ""
+}
+
+// staticChild returns the static child whose segment equals seg, or nil.
+// The indices string holds the first byte of every child segment so most
+// mismatches are rejected without a string comparison.
+func (n *radixNode) staticChild(seg string) *radixNode {
+	first := byte(0)
+	if len(seg) > 0 {
+		first = seg[0]
+	}
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] == first && n.children[i].path == seg {
+			return n.children[i]
+		}
+	}
+	return nil
+}
+
+// lookup finds the node registered for path (which must start with '/') and
+// appends the captured parameters to params. It returns nil if no route in
+// the tree matches.
+func (n *radixNode) lookup(path string, params []router.Param) (*radixNode, []router.Param) {
+	if len(path) == 0 || path[0] != '/' {
+		return nil, params
+	}
+	return n.search(path[1:], params)
+}
+
+// search matches rest, the request path after the segment represented by n,
+// against n's children in priority order, backtracking on failure.
+func (n *radixNode) search(rest string, params []router.Param) (*radixNode, []router.Param) {
+	seg, tail, more := nextSegment(rest)
+
+	// 1. Static segment
+	if c := n.staticChild(seg); c != nil {
+		if !more {
+			if c.handler != nil {
+				return c, params
+			}
+		} else if found, ps := c.search(tail, params); found != nil {
+			return found, ps
+		}
+	}
+
+	// 2. Named parameters in priority order (never match an empty value)
+	for _, c := range n.params {
+		v, ok := c.accepts(seg)
+		if !ok {
+			continue
+		}
+		mark := len(params)
+		params = append(params, router.Param{Key: c.paramName, Value: v})
+		if !more {
+			if c.handler != nil {
+				return c, params
+			}
+		} else if found, ps := c.search(tail, params); found != nil {
+			return found, ps
+		}
+		params = params[:mark]
+	}
+
+	// 3. Catch-all consumes the remainder of the path
+	if c := n.catchAll; c != nil && c.handler != nil && rest != "" {
+		return c, append(params, router.Param{Key: c.paramName, Value: rest})
+	}
+
+	return nil, params
+}
+
+// lookupFold is lookup with case-insensitive static segments. It returns path
+// rewritten with the registered case of every static segment; parameter and
+// catch-all values are copied from path unchanged.
+//
+// Example:
+//
+//	root.insert("/users/:id/posts", chain)
+//	root.lookupFold("/Users/Bob/POSTS") // "/users/Bob/posts", true
+func (n *radixNode) lookupFold(path string) (string, bool) {
+	if len(path) == 0 || path[0] != '/' {
+		return "", false
+	}
+	buf, ok := n.searchFold(path[1:], make([]byte, 0, len(path)))
+	return string(buf), ok
+}
+
+// searchFold mirrors search, appending the canonical form of each matched
+// segment to buf.
+func (n *radixNode) searchFold(rest string, buf []byte) ([]byte, bool) {
+	seg, tail, more := nextSegment(rest)
+	buf = append(buf, '/')
+	mark := len(buf)
+
+	for _, c := range n.children {
+		if !strings.EqualFold(c.path, seg) {
+			continue
+		}
+		b := append(buf[:mark], c.path...)
+		if !more {
+			if c.handler != nil {
+				return b, true
+			}
+		} else if b, ok := c.searchFold(tail, b); ok {
+			return b, true
+		}
+	}
+
+	for _, c := range n.params {
+		if _, ok := c.accepts(seg); !ok {
+			continue
+		}
+		b := append(buf[:mark], seg...)
+		if !more {
+			if c.handler != nil {
+				return b, true
+			}
+		} else if b, ok := c.searchFold(tail, b); ok {
+			return b, true
+		}
+	}
+
+	if c := n.catchAll; c != nil && c.handler != nil && rest != "" {
+		return append(buf[:mark], rest...), true
+	}
+	return buf, false
+}
+
+// validatePattern panics if pattern cannot be registered unambiguously.
+//
+// Rules:
+//   - the pattern must start with '/'
+//   - a segment holds at most one parameter with a non-empty name; ":name"
+//     may follow literal text ("v:version") and carry a "<...>" constraint
+//   - a catch-all must be the final segment and start its segment
+//   - a parameter name may appear only once per pattern
+//
+// Example:
+//
+//	validatePattern("/files/*path/x") // panics: catch-all must be the last segment
+func validatePattern(pattern string) {
+	if len(pattern) == 0 || pattern[0] != '/' {
+		panic("flash: route '" + pattern + "' must begin with '/'")
+	}
+	var seen []string
+	rest := pattern[1:]
+	for {
+		seg, tail, more := nextPatternSegment(rest)
+		ps := parseSegment(seg, pattern)
+		if ps.kind != segStatic {
+			if ps.kind == segCatchAll && more {
+				panic("flash: route '" + pattern + "': catch-all '" + seg + "' must be the last segment")
+			}
+			for _, s := range seen {
+				if s == ps.name {
+					panic("flash: route '" + pattern + "': duplicate parameter name '" + ps.name + "'")
+				}
+			}
+			seen = append(seen, ps.name)
+		}
+		if !more {
+			return
+		}
+		rest = tail
+	}
+}
+
+// nextPatternSegment is nextSegment for route patterns: a '/' inside a
+// "<...>" constraint does not end the segment.
+func nextPatternSegment(p string) (seg, tail string, more bool) {
+	depth := 0
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '<':
+			depth++
+		case '>':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				return p[:i], p[i+1:], true
+			}
+		}
+	}
+	return p, "", false
+}
+
+// nextSegment splits p at the first '/'. It returns the leading segment, the
+// text after the slash and whether a slash was found.
+//
+// Example:
+//
+//	nextSegment("users/42") // "users", "42", true
+//	nextSegment("42")       // "42", "", false
+func nextSegment(p string) (seg, tail string, more bool) {
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		return p[:i], p[i+1:], true
+	}
+	return p, "", false
+}
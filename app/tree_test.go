@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestRadixTreeLookup(t *testing.T) {
+	root := &radixNode{}
+	patterns := []string{
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/posts/:post",
+		"/users/new/edit",
+		"/files/*path",
+		"/a/:x/c",
+		"/a/b/:y",
+	}
+	for _, p := range patterns {
+		root.insert(p, &FastChain{})
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  map[string]string
+	}{
+		{"/users/42", "/users/:id", map[string]string{"id": "42"}},
+		{"/users/42/posts", "/users/:id/posts", map[string]string{"id": "42"}},
+		{"/users/42/posts/7", "/users/:id/posts/:post", map[string]string{"id": "42", "post": "7"}},
+		{"/users/new/edit", "/users/new/edit", map[string]string{}},
+		{"/users/new/posts", "/users/:id/posts", map[string]string{"id": "new"}},
+		{"/files/a/b/c.txt", "/files/*path", map[string]string{"path": "a/b/c.txt"}},
+		{"/a/b/c", "/a/b/:y", map[string]string{"y": "c"}},
+		{"/a/z/c", "/a/:x/c", map[string]string{"x": "z"}},
+	}
+	for _, tt := range tests {
+		node, params := root.lookup(tt.path, nil)
+		if node == nil {
+			t.Fatalf("%s: no match", tt.path)
+		}
+		if node.pattern != tt.pattern {
+			t.Fatalf("%s: pattern=%q want %q", tt.path, node.pattern, tt.pattern)
+		}
+		if len(params) != len(tt.params) {
+			t.Fatalf("%s: params=%v want %v", tt.path, params, tt.params)
+		}
+		for _, p := range params {
+			if tt.params[p.Key] != p.Value {
+				t.Fatalf("%s: param %s=%q want %q", tt.path, p.Key, p.Value, tt.params[p.Key])
+			}
+		}
+	}
+
+	for _, miss := range []string{"/users", "/users/", "/files/", "/users/42/posts/7/x", "/nope", ""} {
+		if node, _ := root.lookup(miss, nil); node != nil {
+			t.Fatalf("%s: unexpected match %q", miss, node.pattern)
+		}
+	}
+}
+
+func TestRadixTreeParamNameConflictPanics(t *testing.T) {
+	root := &radixNode{}
+	root.insert("/users/:id", &FastChain{})
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+	root.insert("/users/:name/x", &FastChain{})
+}
+
+func TestDynamicRoutesDeterministicAcrossTransports(t *testing.T) {
+	a := New().(*DefaultApp)
+	for i := 0; i < 200; i++ {
+		a.GET(fmt.Sprintf("/r%d/:id", i), func(c Ctx) error { return c.String(http.StatusOK, c.Route()+"="+c.Param("id")) })
+	}
+	a.GET("/r7/:id/x", func(c Ctx) error { return c.String(http.StatusOK, "x") })
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/r150/9", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "/r150/:id=9" {
+		t.Fatalf("net/http: %d %q", rec.Code, rec.Body.String())
+	}
+
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/r7/5/x")
+	fctx.Request.Header.SetMethod(http.MethodGet)
+	a.ServeFastHTTP(&fctx)
+	if fctx.Response.StatusCode() != http.StatusOK || string(fctx.Response.Body()) != "x" {
+		t.Fatalf("fasthttp: %d %q", fctx.Response.StatusCode(), fctx.Response.Body())
+	}
+
+	rec = httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/r3/1", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestRouteConflictsPanicAtRegistration(t *testing.T) {
+	noop := func(c Ctx) error { return nil }
+	mw := func(next Handler) Handler { return next }
+	tests := []struct {
+		name     string
+		register func(a App)
+	}{
+		{"duplicate simple", func(a App) { a.GET("/x", noop); a.GET("/x", noop) }},
+		{"duplicate static with middleware", func(a App) { a.GET("/x", noop); a.GET("/x", noop, mw) }},
+		{"duplicate dynamic", func(a App) { a.GET("/u/:id", noop); a.GET("/u/:id", noop) }},
+		{"ambiguous params", func(a App) { a.GET("/u/:id", noop); a.GET("/u/:name", noop) }},
+		{"ambiguous catch-all", func(a App) { a.GET("/f/*path", noop); a.GET("/f/*rest", noop) }},
+		{"catch-all not last", func(a App) { a.GET("/f/*path/x", noop) }},
+		{"catch-all mid-segment", func(a App) { a.GET("/v*rest", noop) }},
+		{"two params in segment", func(a App) { a.GET("/:a:b", noop) }},
+		{"malformed constraint", func(a App) { a.GET("/u/:id<int", noop) }},
+		{"invalid regexp constraint", func(a App) { a.GET("/u/:id<[a-z>", noop) }},
+		{"constrained catch-all", func(a App) { a.GET("/f/*path<int>", noop) }},
+		{"empty param name", func(a App) { a.GET("/u/:", noop) }},
+		{"repeated param name", func(a App) { a.GET("/u/:id/p/:id", noop) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic")
+				}
+			}()
+			tt.register(New())
+		})
+	}
+}
+
+func TestRoutePriorityIndependentOfRegistrationOrder(t *testing.T) {
+	handler := func(c Ctx) error { return c.String(http.StatusOK, c.Route()) }
+	orders := [][]string{
+		{"/files/*path", "/files/new", "/files/:name"},
+		{"/files/:name", "/files/new", "/files/*path"},
+		{"/files/new", "/files/*path", "/files/:name"},
+	}
+	for _, order := range orders {
+		a := New()
+		for _, p := range order {
+			a.GET(p, handler, func(next Handler) Handler { return next })
+		}
+		for path, want := range map[string]string{
+			"/files/new":   "/files/new",
+			"/files/a.txt": "/files/:name",
+			"/files/a/b":   "/files/*path",
+		} {
+			rec := httptest.NewRecorder()
+			a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Body.String() != want {
+				t.Fatalf("order %v: %s -> %q want %q", order, path, rec.Body.String(), want)
+			}
+		}
+	}
+}
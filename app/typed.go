@@ -0,0 +1,88 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// StatusCoder is implemented by Typed handler results that choose their own
+// response status, e.g. 201 for a created resource.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// typedBindOptions bind Typed inputs from query, body and path: values from
+// the query and path are strings, so they are coerced, and parameters the
+// input does not declare are ignored.
+var typedBindOptions = ctx.BindJSONOptions{WeaklyTypedInput: true}
+
+// Typed adapts a function that takes a bound input and returns a result into
+// a Handler, removing the bind/encode boilerplate around it:
+//
+//  1. the query, body (form or JSON) and path parameters are bound into In
+//     with BindAny, coercing strings and ignoring unknown keys; a binding
+//     error is returned as a 400 *HTTPError wrapping it, a validation error
+//     (`validate` tags or the app's Validator) as a 422 and an unsupported
+//     Content-Type as is, for a 415
+//  2. if *In has a Validate() error method, it runs; a failure is returned as
+//     a 422 *HTTPError wrapping it
+//  3. fn runs; its error is returned as is, for the error handler
+//  4. the result is written with c.Render, in the format negotiated from
+//     the Accept header (JSON by default), with status 200 or the status
+//     reported by its StatusCode method (see StatusCoder); 204 and 304 are
+//     written without a body
+//
+// Binding and validation errors wrap the original error, so FieldErrors are
+// expanded by ProblemErrorHandler.
+//
+// Example:
+//
+//	type CreateUser struct {
+//		Name string `json:"name"`
+//		Org  int    `json:"org"` // from the path
+//	}
+//	type Created struct {
+//		ID int `json:"id"`
+//	}
+//	func (Created) StatusCode() int { return http.StatusCreated }
+//
+//	a.POST("/orgs/:org/users", app.Typed(func(c app.Ctx, in CreateUser) (Created, error) {
+//		id, err := store.CreateUser(c.Context(), in.Org, in.Name)
+//		return Created{ID: id}, err
+//	}))
+func Typed[In, Out any](fn func(c Ctx, in In) (Out, error)) Handler {
+	return func(c Ctx) error {
+		var in In
+		if err := c.BindAny(&in, typedBindOptions); err != nil {
+			if errors.Is(err, ctx.ErrValidation) {
+				return NewError(http.StatusUnprocessableEntity, "validation_failed").WithCause(err)
+			}
+			if errors.Is(err, ctx.ErrUnsupportedMediaType) {
+				return err // 415, see ctx.MediaTypeError
+			}
+			return NewError(http.StatusBadRequest, "invalid_request").WithCause(err)
+		}
+		if v, ok := any(&in).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return NewError(http.StatusUnprocessableEntity, "validation_failed").WithCause(err)
+			}
+		}
+
+		out, err := fn(c, in)
+		if err != nil {
+			return err
+		}
+
+		status := http.StatusOK
+		if sc, ok := any(out).(StatusCoder); ok {
+			status = sc.StatusCode()
+		}
+		if status == http.StatusNoContent || status == http.StatusNotModified {
+			_, err := c.Send(status, "", nil)
+			return err
+		}
+		return c.Render(status, out)
+	}
+}
@@ -0,0 +1,216 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+)
+
+type createUser struct {
+	Org   int    `json:"org"`
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+func (in *createUser) Validate() error {
+	if in.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type createdUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func (createdUser) StatusCode() int { return http.StatusCreated }
+
+type noContent struct{}
+
+func (noContent) StatusCode() int { return http.StatusNoContent }
+
+func TestTyped(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.POST("/orgs/:org/users", Typed(func(c Ctx, in createUser) (createdUser, error) {
+		if in.Admin {
+			return createdUser{}, NewError(http.StatusForbidden, "forbidden")
+		}
+		return createdUser{ID: in.Org*100 + 1, Name: in.Name}, nil
+	}))
+	a.GET("/search", Typed(func(c Ctx, in struct {
+		Q     string `json:"q"`
+		Limit int    `json:"limit"`
+	}) (map[string]any, error) {
+		return map[string]any{"q": in.Q, "limit": in.Limit}, nil
+	}))
+	a.DELETE("/users/:id", Typed(func(c Ctx, in struct{}) (noContent, error) { return noContent{}, nil }))
+
+	post := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orgs/7/users?utm_source=x", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(post(`{"name":"Ada"}`))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"id":701,"name":"Ada"}`, rec.Body.String())
+
+		rec = serve(post(`{"name":""}`))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, `{"code":"validation_failed","message":"Unprocessable Entity"}`, rec.Body.String())
+
+		rec = serve(post(`{"name":"Ada","admin":"maybe"}`))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"code":"invalid_request","message":"Bad Request"}`, rec.Body.String())
+
+		rec = serve(post(`{"name":"Ada","admin":true}`))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = serve(httptest.NewRequest(http.MethodGet, "/search?q=go&limit=5", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"q":"go","limit":5}`, rec.Body.String())
+
+		rec = serve(httptest.NewRequest(http.MethodDelete, "/users/3", nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+}
+
+func TestTypedBindErrorsExpandInProblems(t *testing.T) {
+	a := New()
+	a.SetErrorHandler(ProblemErrorHandler)
+	a.POST("/", Typed(func(c Ctx, in struct {
+		Age int `json:"age"`
+	}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"age":"old"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age"`)
+	assert.Contains(t, rec.Body.String(), `"code":"invalid_request"`)
+}
+
+func TestTypedValidateTags(t *testing.T) {
+	a := New()
+	a.SetErrorHandler(ProblemErrorHandler)
+	a.POST("/", Typed(func(c Ctx, in struct {
+		Email string `json:"email" validate:"required,email"`
+		Age   int    `json:"age" validate:"min=18"`
+	}) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"ada@example.com","age":12}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age","message":"must be at least 18"}]`)
+	assert.Contains(t, rec.Body.String(), `"code":"validation_failed"`)
+}
+
+type csvCodec struct{}
+
+func (csvCodec) ContentType() string { return "text/csv" }
+func (csvCodec) Marshal(v any) ([]byte, error) {
+	u := v.(createdUser)
+	return []byte(strconv.Itoa(u.ID) + "," + u.Name + "\n"), nil
+}
+
+func TestTypedNegotiatesFormat(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.RegisterCodec(csvCodec{})
+	a.GET("/users/:id", Typed(func(c Ctx, in struct {
+		ID int `json:"id"`
+	}) (createdUser, error) {
+		return createdUser{ID: in.ID, Name: "Ada"}, nil
+	}))
+
+	get := func(accept string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Accept", accept)
+		return req
+	}
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(get("text/csv, application/json;q=0.5"))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "1,Ada\n", rec.Body.String())
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+
+		rec = serve(get("application/xml"))
+		assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "<createdUser><ID>1</ID><Name>Ada</Name></createdUser>")
+
+		rec = serve(get("image/png"))
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+		assert.JSONEq(t, `{"message":"Not Acceptable"}`, rec.Body.String())
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+	})
+}
+
+func TestTypedDecodesBodyByContentType(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.POST("/orgs/:org/users", Typed(func(c Ctx, in createUser) (createdUser, error) {
+		return createdUser{ID: in.Org, Name: in.Name}, nil
+	}))
+
+	post := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orgs/7/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(post("application/xml", `<user><name>Ada</name><admin>false</admin></user>`))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"id":7,"name":"Ada"}`, rec.Body.String())
+
+		rec = serve(post("text/csv", "Ada,false"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		assert.JSONEq(t, `{"message":"Unsupported Media Type"}`, rec.Body.String())
+		assert.Equal(t, "application/json, application/x-www-form-urlencoded, multipart/form-data, application/xml", rec.Header().Get("Accept-Post"))
+	})
+}
+
+func TestTypedRegisteredDecoder(t *testing.T) {
+	a := New().(*DefaultApp)
+	a.RegisterDecoder("text/csv", ctx.DecoderFunc(func(data []byte, v any) (map[string]any, error) {
+		name, admin, _ := strings.Cut(strings.TrimSpace(string(data)), ",")
+		return map[string]any{"name": name, "admin": admin}, nil
+	}))
+	h := Typed(func(c Ctx, in createUser) (createdUser, error) {
+		return createdUser{ID: in.Org, Name: in.Name}, nil
+	})
+	a.POST("/orgs/:org/users", h)
+	a.Host("api.example.com").POST("/orgs/:org/users", h)
+
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		for _, host := range []string{"example.com", "api.example.com"} {
+			req := httptest.NewRequest(http.MethodPost, "http://"+host+"/orgs/7/users", strings.NewReader("Ada,false\n"))
+			req.Header.Set("Content-Type", "text/csv")
+			rec := serve(req)
+			assert.Equal(t, http.StatusCreated, rec.Code, host)
+			assert.JSONEq(t, `{"id":7,"name":"Ada"}`, rec.Body.String(), host)
+		}
+
+		// A decoder does not make the media type available to Render
+		req := httptest.NewRequest(http.MethodPost, "/orgs/7/users", strings.NewReader("Ada,false"))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("Accept", "text/csv")
+		rec := serve(req)
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	})
+}
@@ -1,8 +1,13 @@
 package app
 
 import (
+	"context"
 	"log/slog"
+	"net"
 	"net/http"
+
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/websocket"
 )
 
 // App defines the public surface of the router/app, suitable for mocking.
@@ -10,17 +15,19 @@ import (
 type App interface {
 	// Middleware management
 	Use(mw ...Middleware)
+	Build()
 
 	// Route registration
-	GET(path string, h Handler, mws ...Middleware)
-	POST(path string, h Handler, mws ...Middleware)
-	PUT(path string, h Handler, mws ...Middleware)
-	PATCH(path string, h Handler, mws ...Middleware)
-	DELETE(path string, h Handler, mws ...Middleware)
-	OPTIONS(path string, h Handler, mws ...Middleware)
-	HEAD(path string, h Handler, mws ...Middleware)
-	ANY(path string, h Handler, mws ...Middleware)
-	Handle(method, path string, h Handler, mws ...Middleware)
+	GET(path string, h Handler, mws ...Middleware) *Route
+	POST(path string, h Handler, mws ...Middleware) *Route
+	PUT(path string, h Handler, mws ...Middleware) *Route
+	PATCH(path string, h Handler, mws ...Middleware) *Route
+	DELETE(path string, h Handler, mws ...Middleware) *Route
+	OPTIONS(path string, h Handler, mws ...Middleware) *Route
+	HEAD(path string, h Handler, mws ...Middleware) *Route
+	ANY(path string, h Handler, mws ...Middleware) *Route
+	Handle(method, path string, h Handler, mws ...Middleware) *Route
+	WS(path string, h WSHandler, mws ...Middleware) *Route
 
 	// HTTP integration and mounting
 	ServeHTTP(w http.ResponseWriter, r *http.Request)
@@ -29,20 +36,76 @@ type App interface {
 	Static(prefix, dir string)
 	StaticDirs(prefix string, dirs ...string)
 
+	// Server lifecycle
+	Listen(addr string) error
+	ListenTLS(addr, certFile, keyFile string) error
+	ListenFastHTTP(addr string) error
+	Serve(ln net.Listener) error
+	Shutdown(ctx context.Context) error
+	SetServerConfig(cfg ServerConfig)
+	ServerConfig() ServerConfig
+	InFlight() int64
+
+	// Lifecycle hooks
+	OnRoute(fn func(RouteInfo))
+	OnStart(fn func(addr string) error)
+	OnShutdown(fn func(ctx context.Context) error)
+	OnRequest(fn func(Ctx))
+	OnResponse(fn func(Ctx, ResponseInfo))
+
 	// Grouping
 	Group(prefix string, mw ...Middleware) *Group
+	Host(pattern string) *Group
 
 	// Logging
 	SetLogger(l *slog.Logger)
 	Logger() *slog.Logger
 
+	// Validation of bound input
+	SetValidator(v ctx.Validator)
+	Validator() ctx.Validator
+
+	// Response encoding (see Ctx.Render)
+	RegisterCodec(c ctx.Codec)
+	Codecs() *ctx.Codecs
+
+	// Request body decoding (see Ctx.BindBody)
+	RegisterDecoder(contentType string, d ctx.Decoder)
+	Decoders() *ctx.Decoders
+
+	// HTML templates (see Ctx.HTML and NewHTMLRenderer)
+	SetRenderer(r ctx.Renderer)
+	Renderer() ctx.Renderer
+
+	// WebSocket routes (see WS)
+	SetWebSocketConfig(cfg websocket.Config)
+	WebSocketConfig() websocket.Config
+
 	// Error/NotFound/MethodNotAllowed handlers
 	SetErrorHandler(h ErrorHandler)
 	SetNotFoundHandler(h Handler)
 	SetMethodNotAllowedHandler(h Handler)
 
+	// Error mapping (see MapErrorAs for typed errors)
+	MapError(target error, status int)
+	MapErrorFunc(fn func(err error) (status int, body any, ok bool))
+
 	// Getters for handlers (mirrors Set*). Useful when holding App as an interface.
 	ErrorHandler() ErrorHandler
 	NotFoundHandler() Handler
 	MethodNotAllowedHandler() Handler
+
+	// Introspection and reverse routing
+	Routes() []RouteInfo
+	AllowedMethods(path string) []string
+	URL(name string, params ...string) (string, error)
+
+	// Redirects for requests that match no route
+	SetRedirectTrailingSlash(v bool)
+	SetRedirectFixedPath(v bool)
+	SetCaseInsensitive(v bool)
+
+	// Route cache tuning
+	SetRouteCacheSize(n int)
+	RouteCacheStats() RouteCacheStats
 }
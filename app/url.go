@@ -0,0 +1,172 @@
+package app
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Route is a handle to a registered route returned by the registration
+// helpers (GET, POST, ..., Handle, ANY). It is used to name the route for
+// reverse URL generation with URL.
+//
+// Example:
+//
+//	a.GET("/users/:id/posts/*rest", ShowPost).Name("posts.show")
+type Route struct {
+	app        *DefaultApp
+	methods    []string
+	pattern    string
+	namePrefix string // inherited from the registering Group
+}
+
+// Pattern returns the full route pattern, including any group prefix.
+func (r *Route) Pattern() string { return r.pattern }
+
+// Name assigns a name to the route so that URL can build paths for it. When
+// the route was registered on a Group with a name prefix, the prefix is
+// prepended. Name panics if the name is already used by a different pattern.
+//
+// Example:
+//
+//	a.GET("/users/:id", ShowUser).Name("users.show")
+//	u, _ := a.URL("users.show", "id", "42") // "/users/42"
+func (r *Route) Name(name string) *Route {
+	full := r.namePrefix + name
+	a := r.app
+	a.router.mu.Lock()
+	defer a.router.mu.Unlock()
+
+	if existing, ok := a.router.names[full]; ok && existing != r.pattern {
+		panic("flash: route name '" + full + "' already used by '" + existing + "'")
+	}
+	a.router.names[full] = r.pattern
+	for i := range a.router.routes {
+		info := &a.router.routes[i]
+		if info.Pattern != r.pattern {
+			continue
+		}
+		for _, m := range r.methods {
+			if info.Method == m {
+				info.Name = full
+			}
+		}
+	}
+	return r
+}
+
+// Errors returned by URL.
+var (
+	// ErrRouteNotFound is returned when no route has the requested name.
+	ErrRouteNotFound = errors.New("flash: no route with that name")
+	// ErrRouteParams is returned when the supplied parameters do not match the
+	// route pattern (missing, unknown, violating a constraint, or an odd number
+	// of key/value arguments).
+	ErrRouteParams = errors.New("flash: invalid route parameters")
+)
+
+// URL builds the path for the route registered under name, substituting
+// parameters given as alternating key/value pairs.
+//
+// Values for ":name" parameters are escaped as a single path segment, so a
+// value containing '/' cannot change the route structure. Values for
+// "*name" catch-alls may span several segments; each segment is escaped
+// individually and the slashes are preserved.
+//
+// URL returns an error wrapping ErrRouteNotFound for unknown names and one
+// wrapping ErrRouteParams when a parameter is missing, unknown, violates the
+// parameter's constraint, or the arguments are not key/value pairs.
+//
+// Example:
+//
+//	a.GET("/users/:id/posts/*rest", ShowPost).Name("posts.show")
+//	u, err := a.URL("posts.show", "id", "a b", "rest", "2024/hello world")
+//	// u == "/users/a%20b/posts/2024/hello%20world"
+func (a *DefaultApp) URL(name string, params ...string) (string, error) {
+	a.router.mu.RLock()
+	pattern, ok := a.router.names[name]
+	hosts := a.hosts
+	a.router.mu.RUnlock()
+	if !ok {
+		// Routes named on a Host group are stored with that host's app
+		for _, h := range hosts {
+			h.app.router.mu.RLock()
+			_, named := h.app.router.names[name]
+			h.app.router.mu.RUnlock()
+			if named {
+				return h.app.URL(name, params...)
+			}
+		}
+		return "", &routeError{name: name, msg: "unknown route name", err: ErrRouteNotFound}
+	}
+	if len(params)%2 != 0 {
+		return "", &routeError{name: name, msg: "parameters must be key/value pairs", err: ErrRouteParams}
+	}
+
+	used := 0
+	var sb strings.Builder
+	sb.Grow(len(pattern) + 16)
+	rest := strings.TrimPrefix(pattern, "/")
+	for {
+		seg, tail, more := nextPatternSegment(rest)
+		ps := parseSegment(seg, pattern)
+		sb.WriteByte('/')
+		if ps.kind == segStatic {
+			sb.WriteString(seg)
+		} else {
+			val, found := lookupParam(params, ps.name)
+			if !found || val == "" {
+				return "", &routeError{name: name, msg: "missing parameter '" + ps.name + "'", err: ErrRouteParams}
+			}
+			if ps.constraint != "" && !newParamConstraint(ps.constraint, pattern).match(val) {
+				return "", &routeError{name: name, msg: "parameter '" + ps.name + "' does not satisfy <" + ps.constraint + ">", err: ErrRouteParams}
+			}
+			used++
+			if ps.kind == segCatchAll {
+				writeEscapedSegments(&sb, strings.TrimPrefix(val, "/"))
+			} else {
+				sb.WriteString(ps.prefix)
+				sb.WriteString(url.PathEscape(val))
+			}
+		}
+		if !more {
+			break
+		}
+		rest = tail
+	}
+
+	if used != len(params)/2 {
+		return "", &routeError{name: name, msg: "unknown parameters for '" + pattern + "'", err: ErrRouteParams}
+	}
+	return sb.String(), nil
+}
+
+// lookupParam returns the value for key from alternating key/value pairs.
+func lookupParam(params []string, key string) (string, bool) {
+	for i := 0; i+1 < len(params); i += 2 {
+		if params[i] == key {
+			return params[i+1], true
+		}
+	}
+	return "", false
+}
+
+// writeEscapedSegments writes p to sb escaping each '/'-separated segment.
+func writeEscapedSegments(sb *strings.Builder, p string) {
+	for i, seg := range strings.Split(p, "/") {
+		if i > 0 {
+			sb.WriteByte('/')
+		}
+		sb.WriteString(url.PathEscape(seg))
+	}
+}
+
+// routeError describes a failed URL call and wraps one of the sentinel errors.
+type routeError struct {
+	name string
+	msg  string
+	err  error
+}
+
+func (e *routeError) Error() string { return "flash: route '" + e.name + "': " + e.msg }
+func (e *routeError) Unwrap() error { return e.err }
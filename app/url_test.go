@@ -0,0 +1,77 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestURLBuildsNamedRoutes(t *testing.T) {
+	a := New()
+	noop := func(c Ctx) error { return nil }
+	a.GET("/users/:id/posts/*rest", noop).Name("posts.show")
+	a.GET("/about", noop).Name("about")
+	api := a.Group("/api").Name("api.")
+	v1 := api.Group("/v1").Name("v1.")
+	v1.GET("/items/:id", noop).Name("items.show")
+
+	tests := []struct {
+		name   string
+		params []string
+		want   string
+	}{
+		{"about", nil, "/about"},
+		{"posts.show", []string{"id", "a b", "rest", "2024/hello world"}, "/users/a%20b/posts/2024/hello%20world"},
+		{"posts.show", []string{"rest", "/x", "id", "a/b"}, "/users/a%2Fb/posts/x"},
+		{"api.v1.items.show", []string{"id", "9"}, "/api/v1/items/9"},
+	}
+	for _, tt := range tests {
+		got, err := a.URL(tt.name, tt.params...)
+		if err != nil || got != tt.want {
+			t.Fatalf("URL(%q, %v) = %q, %v want %q", tt.name, tt.params, got, err, tt.want)
+		}
+	}
+
+	// Generated URLs resolve back to the route
+	u, _ := a.URL("posts.show", "id", "7", "rest", "a/b")
+	var gotID, gotRest string
+	b := New()
+	b.GET("/users/:id/posts/*rest", func(c Ctx) error { gotID, gotRest = c.Param("id"), c.Param("rest"); return nil })
+	b.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, u, nil))
+	if gotID != "7" || gotRest != "a/b" {
+		t.Fatalf("round trip: id=%q rest=%q", gotID, gotRest)
+	}
+
+	for _, r := range a.Routes() {
+		if r.Pattern == "/api/v1/items/:id" && r.Name != "api.v1.items.show" {
+			t.Fatalf("RouteInfo.Name=%q", r.Name)
+		}
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	a := New()
+	a.GET("/users/:id", func(c Ctx) error { return nil }).Name("users.show")
+
+	if _, err := a.URL("nope"); !errors.Is(err, ErrRouteNotFound) {
+		t.Fatalf("unknown name: %v", err)
+	}
+	for _, params := range [][]string{{}, {"id"}, {"id", ""}, {"id", "1", "x", "2"}, {"other", "1"}} {
+		if _, err := a.URL("users.show", params...); !errors.Is(err, ErrRouteParams) {
+			t.Fatalf("params %v: %v", params, err)
+		}
+	}
+}
+
+func TestRouteNameConflictPanics(t *testing.T) {
+	a := New()
+	a.GET("/a", func(c Ctx) error { return nil }).Name("x")
+	a.POST("/a", func(c Ctx) error { return nil }).Name("x") // same pattern is fine
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic")
+		}
+	}()
+	a.GET("/b", func(c Ctx) error { return nil }).Name("x")
+}
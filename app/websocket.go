@@ -0,0 +1,125 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/goflash/flash/v2/websocket"
+)
+
+// WSHandler handles a WebSocket connection opened on a WS route. The
+// connection is closed when it returns: with status 1000 (normal closure)
+// for a nil error, or 1011 (internal error) otherwise.
+type WSHandler func(c Ctx, conn *websocket.Conn) error
+
+// WS registers h for WebSocket connections on the given path. Requests go
+// through the global and route middleware like GET requests; the handshake
+// is then completed with the app's websocket.Config (see SetWebSocketConfig)
+// and h is called with the open connection.
+//
+// A request that is not a valid handshake, or whose origin is refused, gets
+// the *websocket.HandshakeError's status through the error handler. Once h
+// returns the connection is closed. When the app shuts down or the request
+// context is cancelled (e.g. by a Timeout middleware), the connection is
+// closed with status 1001 (going away), which makes h's reads fail. A close
+// by the client and the closes of the server are not reported as errors.
+//
+// Example:
+//
+//	a.WS("/chat", func(c app.Ctx, conn *websocket.Conn) error {
+//		for {
+//			var msg Message
+//			if err := conn.ReadJSON(&msg); err != nil {
+//				return err
+//			}
+//			room.Broadcast(msg)
+//		}
+//	}, RequireUser)
+func (a *DefaultApp) WS(path string, h WSHandler, mws ...Middleware) *Route {
+	return a.addRoute(RouteInfo{Method: http.MethodGet, Pattern: path, Handler: handlerName(h)}, a.wsHandler(h), mws)
+}
+
+// wsHandler adapts h to a Handler that upgrades the request.
+func (a *DefaultApp) wsHandler(h WSHandler) Handler {
+	return func(c Ctx) error {
+		rctx := c.Context()
+		// The request context watches the connection for the client going
+		// away until it is upgraded; start it before the upgrade takes over
+		// the connection's deadlines
+		rctx.Done()
+		conn, err := websocket.Upgrade(c, a.WebSocketConfig())
+		if err != nil {
+			return err
+		}
+		// The goroutine must be done with the request context before it is
+		// reused, so context.AfterFunc is not used
+		stop, shutdown := make(chan struct{}), a.shutdownStarted()
+		goingAway := false
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-rctx.Done():
+			case <-shutdown:
+			case <-stop:
+				return
+			}
+			goingAway = true
+			_ = conn.CloseWithStatus(websocket.CloseGoingAway, "")
+		}()
+		err = h(c, conn)
+		close(stop)
+		wg.Wait()
+		if err == nil || goingAway || wsClosed(err) {
+			_ = conn.Close()
+			return nil
+		}
+		_ = conn.CloseWithStatus(websocket.CloseInternalServerError, "")
+		return err
+	}
+}
+
+// wsClosed reports whether err only says that the client closed the
+// connection normally.
+func wsClosed(err error) bool {
+	return websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived)
+}
+
+// SetWebSocketConfig sets the websocket.Config used by the WS routes of the
+// app, e.g. to allow cross-origin clients or negotiate subprotocols.
+//
+// Example:
+//
+//	a.SetWebSocketConfig(websocket.Config{
+//		CheckOrigin:       func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example.com" },
+//		Subprotocols:      []string{"chat.v2", "chat.v1"},
+//		EnableCompression: true,
+//	})
+func (a *DefaultApp) SetWebSocketConfig(cfg websocket.Config) {
+	a.wsConfig = &cfg
+}
+
+// WebSocketConfig returns the websocket.Config set with SetWebSocketConfig,
+// or the zero Config (defaults). Apps created by Host fall back to the
+// parent app's.
+func (a *DefaultApp) WebSocketConfig() websocket.Config {
+	if a.wsConfig == nil {
+		if a.parent != nil {
+			return a.parent.WebSocketConfig()
+		}
+		return websocket.Config{}
+	}
+	return *a.wsConfig
+}
+
+// handshakeError converts a *websocket.HandshakeError in err to an
+// *HTTPError, or returns nil.
+func handshakeError(err error) *HTTPError {
+	var hse *websocket.HandshakeError
+	if !errors.As(err, &hse) {
+		return nil
+	}
+	return &HTTPError{Status: hse.Status, Message: hse.Reason, Header: hse.Header, Err: err}
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/goflash/flash/v2/internal/wstest"
+	"github.com/goflash/flash/v2/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// liveServers serves a on both transports over real connections and returns
+// the URL of each server by transport name.
+func liveServers(t *testing.T, a *DefaultApp) map[string]string {
+	hs := httptest.NewServer(a)
+	t.Cleanup(hs.Close)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	fs := &fasthttp.Server{Handler: a.ServeFastHTTP}
+	go func() { _ = fs.Serve(ln) }()
+	t.Cleanup(func() { _ = fs.Shutdown() })
+	return map[string]string{"net/http": hs.URL, "fasthttp": "http://" + ln.Addr().String()}
+}
+
+func TestWS(t *testing.T) {
+	results := make(chan error, 1)
+	a := New().(*DefaultApp)
+	a.Use(func(next Handler) Handler {
+		return func(c Ctx) error {
+			c.Header("X-Trace", "abc")
+			return next(c)
+		}
+	})
+	a.WS("/rooms/:room", func(c Ctx, conn *websocket.Conn) error {
+		defer func() { results <- nil }()
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(typ, append([]byte(c.Param("room")+": "), msg...)); err != nil {
+				return err
+			}
+		}
+	})
+
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			client, resp := wstest.Dial(t, url+"/rooms/go", nil)
+			require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			assert.Equal(t, "abc", resp.Header.Get("X-Trace"), "middleware runs before the upgrade")
+
+			for _, msg := range []string{"hello", "again"} {
+				client.Write(wstest.OpText, []byte(msg))
+				op, p := client.Read()
+				assert.Equal(t, byte(wstest.OpText), op)
+				assert.Equal(t, "go: "+msg, string(p))
+			}
+			client.WriteClose(websocket.CloseNormalClosure)
+			assert.Equal(t, websocket.CloseNormalClosure, client.ReadClose())
+			select {
+			case <-results:
+			case <-time.After(2 * time.Second):
+				t.Fatal("handler did not return")
+			}
+		})
+	}
+
+	routes := a.Routes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, http.MethodGet, routes[0].Method)
+	assert.Equal(t, "/rooms/:room", routes[0].Pattern)
+}
+
+func TestWSHandlerError(t *testing.T) {
+	boom := errors.New("boom")
+	handled := make(chan error, 1)
+	a := New().(*DefaultApp)
+	a.SetErrorHandler(func(c Ctx, err error) { handled <- err })
+	a.WS("/", func(c Ctx, conn *websocket.Conn) error { return boom })
+	a.WS("/ok", func(c Ctx, conn *websocket.Conn) error { return nil })
+
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			client, resp := wstest.Dial(t, url+"/", nil)
+			require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			assert.Equal(t, websocket.CloseInternalServerError, client.ReadClose())
+			assert.ErrorIs(t, <-handled, boom)
+
+			client, _ = wstest.Dial(t, url+"/ok", nil)
+			assert.Equal(t, websocket.CloseNormalClosure, client.ReadClose())
+		})
+	}
+}
+
+func TestWSClosedOnShutdown(t *testing.T) {
+	a := New().(*DefaultApp)
+	opened := make(chan struct{}, 2)
+	handled := make(chan error, 2)
+	a.SetErrorHandler(func(c Ctx, err error) { handled <- err })
+	a.WS("/", func(c Ctx, conn *websocket.Conn) error {
+		opened <- struct{}{}
+		_, _, err := conn.ReadMessage()
+		return err
+	})
+
+	var clients []*wstest.Client
+	for _, url := range liveServers(t, a) {
+		client, resp := wstest.Dial(t, url, nil)
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		<-opened
+		clients = append(clients, client)
+	}
+	sctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, a.Shutdown(sctx), "connections do not hold up draining")
+	for _, client := range clients {
+		assert.Equal(t, websocket.CloseGoingAway, client.ReadClose())
+	}
+	assert.Zero(t, a.InFlight())
+	assert.Empty(t, handled, "closing on shutdown is not an error")
+}
+
+func TestWSHandshakeErrors(t *testing.T) {
+	a := New().(*DefaultApp)
+	called := false
+	a.WS("/ws", func(c Ctx, conn *websocket.Conn) error {
+		called = true
+		return nil
+	})
+
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Equal(t, http.StatusUpgradeRequired, rec.Code)
+		assert.Equal(t, "websocket", rec.Header().Get("Upgrade"))
+		assert.Contains(t, rec.Body.String(), "not a WebSocket upgrade request")
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", wstest.Key)
+		req.Header.Set("Origin", "https://evil.example")
+		rec = serve(req)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = serve(httptest.NewRequest(http.MethodPost, "/ws", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+	assert.False(t, called)
+}
+
+func TestWSConfig(t *testing.T) {
+	a := New().(*DefaultApp)
+	assert.Equal(t, websocket.Config{}, a.WebSocketConfig())
+	a.SetWebSocketConfig(websocket.Config{
+		CheckOrigin:  func(r *http.Request) bool { return r.Header.Get("Origin") == "https://app.example" },
+		Subprotocols: []string{"chat.v2"},
+	})
+	assert.Equal(t, []string{"chat.v2"}, a.WebSocketConfig().Subprotocols)
+
+	protocols := make(chan string, 1)
+	handler := func(c Ctx, conn *websocket.Conn) error {
+		protocols <- conn.Subprotocol()
+		return nil
+	}
+	api := a.Group("/api", func(next Handler) Handler {
+		return func(c Ctx) error {
+			if c.Query("token") != "t" {
+				return c.String(http.StatusUnauthorized, "no token")
+			}
+			return next(c)
+		}
+	})
+	api.WS("/live", handler)
+	a.Host("live.example.com").WS("/feed", handler)
+	assert.Equal(t, []string{"chat.v2"}, a.hosts[0].app.WebSocketConfig().Subprotocols, "hosts use the app's config")
+
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			hdr := http.Header{"Origin": {"https://app.example"}, "Sec-Websocket-Protocol": {"chat.v1, chat.v2"}}
+			_, resp := wstest.Dial(t, url+"/api/live", hdr)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "group middleware applies")
+
+			_, resp = wstest.Dial(t, url+"/api/live?token=t", hdr)
+			require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+			assert.Equal(t, "chat.v2", resp.Header.Get("Sec-WebSocket-Protocol"))
+			assert.Equal(t, "chat.v2", <-protocols)
+
+			_, resp = wstest.Dial(t, url+"/api/live?token=t", http.Header{"Origin": {"https://other.example"}})
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Contains(t, string(body), "origin not allowed")
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package cbor is a CBOR (RFC 8949) codec for Render and decoder for Bind*,
+// built on the standard library only. Values are encoded with the fields of
+// their JSON encoding (json tags, omitempty, json.Marshaler, ...), so a
+// client sees the same document in either format.
+//
+// Register it on an app for both:
+//
+//	a.RegisterCodec(cbor.Codec{})
+//	a.RegisterDecoder(cbor.MediaType, cbor.Codec{})
+package cbor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+)
+
+// MediaType is the media type of CBOR bodies.
+const MediaType = "application/cbor"
+
+// Major types of RFC 8949, section 3.1, shifted into the initial byte.
+const (
+	majorUint   byte = 0 << 5
+	majorNegInt byte = 1 << 5
+	majorBytes  byte = 2 << 5
+	majorText   byte = 3 << 5
+	majorArray  byte = 4 << 5
+	majorMap    byte = 5 << 5
+	majorTag    byte = 6 << 5
+	majorSimple byte = 7 << 5
+)
+
+// Codec encodes responses and decodes request bodies as CBOR.
+type Codec struct{}
+
+// ContentType returns MediaType.
+func (Codec) ContentType() string { return MediaType }
+
+// Marshal encodes v as CBOR.
+func (Codec) Marshal(v any) ([]byte, error) { return Marshal(v) }
+
+// Marshal returns the CBOR encoding of v. Arguments use their shortest form,
+// floats are encoded in single precision when that is exact, and maps keep
+// the field order of the JSON encoding.
+func Marshal(v any) ([]byte, error) {
+	t, err := tree.Of(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendValue(nil, t), nil
+}
+
+// appendValue appends the encoding of tree value v to b.
+func appendValue(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, majorSimple|22)
+	case bool:
+		if v {
+			return append(b, majorSimple|21)
+		}
+		return append(b, majorSimple|20)
+	case int64:
+		if v < 0 {
+			return appendHead(b, majorNegInt, uint64(^v))
+		}
+		return appendHead(b, majorUint, uint64(v))
+	case uint64:
+		return appendHead(b, majorUint, v)
+	case float64:
+		if f := float32(v); float64(f) == v {
+			return binary.BigEndian.AppendUint32(append(b, majorSimple|26), math.Float32bits(f))
+		}
+		return binary.BigEndian.AppendUint64(append(b, majorSimple|27), math.Float64bits(v))
+	case string:
+		return append(appendHead(b, majorText, uint64(len(v))), v...)
+	case []any:
+		b = appendHead(b, majorArray, uint64(len(v)))
+		for _, e := range v {
+			b = appendValue(b, e)
+		}
+		return b
+	case tree.Map:
+		b = appendHead(b, majorMap, uint64(len(v)))
+		for _, f := range v {
+			b = appendValue(b, f.Key)
+			b = appendValue(b, f.Value)
+		}
+		return b
+	}
+	panic(fmt.Sprintf("cbor: unexpected tree value %T", v))
+}
+
+// appendHead appends the initial byte of major type major with argument n in
+// its shortest form.
+func appendHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), n)
+}
@@ -0,0 +1,83 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// The expected encodings are from RFC 8949, appendix A, where it has them.
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string // hex
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{100, "1864"},
+		{1000, "1903e8"},
+		{1000000, "1a000f4240"},
+		{1000000000000, "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-10, "29"},
+		{-100, "3863"},
+		{-1000, "3903e7"},
+		{math.MinInt64, "3b7fffffffffffffff"},
+		{1.5, "fa3fc00000"},
+		{1.1, "fb3ff199999999999a"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{false, "f4"},
+		{true, "f5"},
+		{nil, "f6"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]int{}, "80"},
+		{[]int{1, 2, 3}, "83010203"},
+		{make([]int, 25), "9819" + strings.Repeat("00", 25)},
+		{map[string]any{"a": 1, "b": []int{2, 3}}, "a26161016162820203"},
+		{user{ID: 1, Name: "Ada"}, "a262696401646e616d6563416461"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.v)
+		require.NoError(t, err, "%v", tt.v)
+		assert.Equal(t, tt.want, hex.EncodeToString(b), "%v", tt.v)
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterCodec(Codec{})
+	a.GET("/users/:id", func(c app.Ctx) error {
+		return c.Render(http.StatusOK, user{ID: 1, Name: "Ada"})
+	})
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Accept", "application/cbor")
+		rec := serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+		assert.Equal(t, "a262696401646e616d6563416461", hex.EncodeToString(rec.Body.Bytes()))
+	})
+}
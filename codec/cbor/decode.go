@@ -0,0 +1,374 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+)
+
+// maxDepth bounds the nesting of arrays, maps and tags in decoded bodies.
+const maxDepth = 10000
+
+// Tags of RFC 8949, section 3.4, with a decoding of their own. The content
+// of other tags is decoded as if untagged.
+const (
+	tagDateTime  = 0
+	tagEpoch     = 1
+	tagPosBignum = 2
+	tagNegBignum = 3
+)
+
+// indefinite is the additional information of indefinite-length items;
+// breakCode ends them.
+const (
+	indefinite = 31
+	breakCode  = majorSimple | 31
+)
+
+var errShort = fmt.Errorf("cbor: %w", io.ErrUnexpectedEOF)
+
+// Decode returns the fields of the CBOR map data, which Bind* binds into v.
+func (Codec) Decode(data []byte, _ any) (map[string]any, error) {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := tree.Fields(v)
+	if err != nil {
+		return nil, fmt.Errorf("cbor: %w", err)
+	}
+	return m, nil
+}
+
+// Unmarshal decodes the CBOR data item data into nil (null and undefined),
+// bool, int64 (or uint64 above math.MaxInt64), float64, string, []byte,
+// time.Time (tags 0 and 1), []any or map[string]any. Bignums (tags 2 and 3)
+// decode to int64 or uint64 and are errors when they do not fit; other tags
+// are ignored. Map keys that are not strings are formatted with fmt.Sprint.
+// Data after the item is an error.
+func Unmarshal(data []byte) (any, error) {
+	d := decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, errors.New("cbor: data after the top-level item")
+	}
+	return v, nil
+}
+
+// decoder reads CBOR data items from data.
+type decoder struct {
+	data []byte
+	off  int
+}
+
+// next consumes and returns the next n bytes.
+func (d *decoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, errShort
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// atBreak consumes the break code if it is next.
+func (d *decoder) atBreak() (bool, error) {
+	if d.off >= len(d.data) {
+		return false, errShort
+	}
+	if d.data[d.off] != breakCode {
+		return false, nil
+	}
+	d.off++
+	return true, nil
+}
+
+// arg consumes the argument of additional information ai.
+func (d *decoder) arg(ai byte) (uint64, error) {
+	if ai < 24 {
+		return uint64(ai), nil
+	}
+	if ai > 27 {
+		return 0, fmt.Errorf("cbor: invalid additional information %d", ai)
+	}
+	b, err := d.next(1 << (ai - 24))
+	if err != nil {
+		return 0, err
+	}
+	switch ai {
+	case 24:
+		return uint64(b[0]), nil
+	case 25:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 26:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// value consumes the next data item, nested depth levels deep.
+func (d *decoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("cbor: exceeded max depth")
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	major, ai := b[0]&0xe0, b[0]&0x1f
+	if major == majorSimple {
+		return d.simple(ai)
+	}
+	if ai == indefinite {
+		switch major {
+		case majorBytes, majorText:
+			return d.chunks(major)
+		case majorArray:
+			return d.array(0, true, depth)
+		case majorMap:
+			return d.mapOf(0, true, depth)
+		}
+		return nil, fmt.Errorf("cbor: indefinite length for major type %d", major>>5)
+	}
+	n, err := d.arg(ai)
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case majorUint:
+		if n <= math.MaxInt64 {
+			return int64(n), nil
+		}
+		return n, nil
+	case majorNegInt:
+		if n > math.MaxInt64 {
+			return nil, errors.New("cbor: negative integer overflows int64")
+		}
+		return -1 - int64(n), nil
+	case majorBytes:
+		p, err := d.next(n)
+		return bytes.Clone(p), err
+	case majorText:
+		p, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return text(p)
+	case majorArray:
+		return d.array(n, false, depth)
+	case majorMap:
+		return d.mapOf(n, false, depth)
+	}
+	content, err := d.value(depth + 1) // majorTag
+	if err != nil {
+		return nil, err
+	}
+	return tagged(n, content)
+}
+
+// simple decodes a simple value or float of additional information ai.
+func (d *decoder) simple(ai byte) (any, error) {
+	switch ai {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25, 26, 27:
+		u, err := d.arg(ai)
+		if err != nil {
+			return nil, err
+		}
+		switch ai {
+		case 25:
+			return half(uint16(u)), nil
+		case 26:
+			return float64(math.Float32frombits(uint32(u))), nil
+		}
+		return math.Float64frombits(u), nil
+	case indefinite:
+		return nil, errors.New("cbor: unexpected break")
+	}
+	return nil, fmt.Errorf("cbor: unsupported simple value %d", ai)
+}
+
+// half returns the value of the IEEE 754 half-precision float h (RFC 8949,
+// appendix D).
+func half(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// text returns p as a string, which must be UTF-8.
+func text(p []byte) (string, error) {
+	if !utf8.Valid(p) {
+		return "", errors.New("cbor: invalid UTF-8 in text string")
+	}
+	return string(p), nil
+}
+
+// chunks consumes the definite-length chunks of an indefinite-length byte
+// or text string of major type major.
+func (d *decoder) chunks(major byte) (any, error) {
+	var buf []byte
+	for {
+		done, err := d.atBreak()
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0]&0xe0 != major || b[0]&0x1f == indefinite {
+			return nil, errors.New("cbor: invalid chunk in indefinite-length string")
+		}
+		n, err := d.arg(b[0] & 0x1f)
+		if err != nil {
+			return nil, err
+		}
+		p, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, p...)
+	}
+	if major == majorText {
+		return text(buf)
+	}
+	if buf == nil {
+		buf = []byte{}
+	}
+	return buf, nil
+}
+
+// array consumes n elements, or elements up to a break if indef.
+func (d *decoder) array(n uint64, indef bool, depth int) ([]any, error) {
+	if n > uint64(len(d.data)-d.off) { // every element takes a byte at least
+		return nil, errShort
+	}
+	s := make([]any, 0, n)
+	for i := uint64(0); indef || i < n; i++ {
+		if indef {
+			done, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+		}
+		e, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, e)
+	}
+	return s, nil
+}
+
+// mapOf consumes n key-value pairs, or pairs up to a break if indef.
+func (d *decoder) mapOf(n uint64, indef bool, depth int) (map[string]any, error) {
+	if n > uint64(len(d.data)-d.off)/2 {
+		return nil, errShort
+	}
+	m := make(map[string]any, n)
+	for i := uint64(0); indef || i < n; i++ {
+		if indef {
+			done, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+		}
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, err := tree.Key(k)
+		if err != nil {
+			return nil, fmt.Errorf("cbor: %w", err)
+		}
+		if m[key], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// tagged decodes the content of tag number tag.
+func tagged(tag uint64, content any) (any, error) {
+	switch tag {
+	case tagDateTime:
+		s, ok := content.(string)
+		if !ok {
+			return nil, fmt.Errorf("cbor: date/time of type %T", content)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("cbor: %w", err)
+		}
+		return t, nil
+	case tagEpoch:
+		switch v := content.(type) {
+		case int64:
+			return time.Unix(v, 0).UTC(), nil
+		case float64:
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, errors.New("cbor: invalid epoch time")
+			}
+			sec := math.Floor(v)
+			return time.Unix(int64(sec), int64((v-sec)*1e9)).UTC(), nil
+		}
+		return nil, fmt.Errorf("cbor: epoch time of type %T", content)
+	case tagPosBignum, tagNegBignum:
+		p, ok := content.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("cbor: bignum of type %T", content)
+		}
+		n := new(big.Int).SetBytes(p)
+		if tag == tagNegBignum {
+			n.Not(n) // -1 - n
+		}
+		switch {
+		case n.IsInt64():
+			return n.Int64(), nil
+		case n.IsUint64():
+			return n.Uint64(), nil
+		}
+		return nil, errors.New("cbor: bignum out of range")
+	}
+	return content, nil
+}
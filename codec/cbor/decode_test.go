@@ -0,0 +1,219 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// The vectors are from RFC 8949, appendix A, where it has them.
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		data string // hex
+		want any
+	}{
+		{"00", int64(0)},
+		{"17", int64(23)},
+		{"1818", int64(24)},
+		{"1903e8", int64(1000)},
+		{"1a000f4240", int64(1000000)},
+		{"1b000000e8d4a51000", int64(1000000000000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"20", int64(-1)},
+		{"3903e7", int64(-1000)},
+		{"3b7fffffffffffffff", int64(math.MinInt64)},
+		{"c24101", int64(1)},
+		{"c2490000000000000000ff", int64(255)},
+		{"c24900ffffffffffffffff", uint64(math.MaxUint64)},
+		{"c34100", int64(-1)},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-08},
+		{"f90400", 6.103515625e-05},
+		{"f9c400", -4.0},
+		{"f97c00", math.Inf(1)},
+		{"fa47c35000", 100000.0},
+		{"fb7e37e43c8800759c", 1.0e+300},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", nil},
+		{"60", ""},
+		{"6449455446", "IETF"},
+		{"62c3bc", "ü"},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"d74401020304", []byte{1, 2, 3, 4}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"5fff", []byte{}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"80", []any{}},
+		{"83010203", []any{int64(1), int64(2), int64(3)}},
+		{"9fff", []any{}},
+		{"9f018202039f0405ffff", []any{int64(1), []any{int64(2), int64(3)}, []any{int64(4), int64(5)}}},
+		{"a0", map[string]any{}},
+		{"a201020304", map[string]any{"1": int64(2), "3": int64(4)}},
+		{"a26161016162820203", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"bf61610161629f0203ffff", map[string]any{"a": int64(1), "b": []any{int64(2), int64(3)}}},
+		{"c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c11a514b67b0", time.Unix(1363896240, 0).UTC()},
+		{"c1fb41d452d9ec200000", time.Unix(1363896240, 500000000).UTC()},
+	}
+	for _, tt := range tests {
+		v, err := Unmarshal(unhex(t, tt.data))
+		require.NoError(t, err, tt.data)
+		if tm, ok := tt.want.(time.Time); ok {
+			assert.True(t, tm.Equal(v.(time.Time)), "%s: %v", tt.data, v)
+			continue
+		}
+		assert.Equal(t, tt.want, v, tt.data)
+	}
+
+	v, err := Unmarshal(unhex(t, "f97e00"))
+	require.NoError(t, err)
+	assert.True(t, math.IsNaN(v.(float64)))
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	in := map[string]any{
+		"id":    1,
+		"name":  strings.Repeat("x", 300),
+		"score": -2.5,
+		"pi":    math.Pi,
+		"big":   uint64(math.MaxUint64),
+		"addr":  map[string]any{"city": "London", "zip": nil},
+	}
+	b, err := Marshal(in)
+	require.NoError(t, err)
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":    int64(1),
+		"name":  strings.Repeat("x", 300),
+		"score": -2.5,
+		"pi":    math.Pi,
+		"big":   uint64(math.MaxUint64),
+		"addr":  map[string]any{"city": "London", "zip": nil},
+	}, v)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		data string // hex
+		want string
+	}{
+		{"", "unexpected EOF"},
+		{"1a0000", "unexpected EOF"},
+		{"6449", "unexpected EOF"},
+		{"5f41", "unexpected EOF"},
+		{"9f01", "unexpected EOF"},
+		{"9b7fffffffffffffff", "unexpected EOF"},
+		{"bb7fffffffffffffff", "unexpected EOF"},
+		{"f6f6", "data after the top-level item"},
+		{"1c", "invalid additional information 28"},
+		{"1f", "indefinite length for major type 0"},
+		{"ff", "unexpected break"},
+		{"f0", "unsupported simple value 16"},
+		{"61ff", "invalid UTF-8"},
+		{"5f01ff", "invalid chunk"},
+		{"7f4161ff", "invalid chunk"},
+		{"3bffffffffffffffff", "negative integer overflows int64"},
+		{"c249010000000000000000", "bignum out of range"},
+		{"c201", "bignum of type int64"},
+		{"c001", "date/time of type int64"},
+		{"c06131", "cannot parse"},
+		{"c16131", "epoch time of type string"},
+		{"c1f97e00", "invalid epoch time"},
+		{"a18001", "unsupported map key of type []interface {}"},
+	}
+	for _, tt := range tests {
+		_, err := Unmarshal(unhex(t, tt.data))
+		require.Error(t, err, tt.data)
+		assert.Contains(t, err.Error(), tt.want, tt.data)
+		assert.True(t, strings.HasPrefix(err.Error(), "cbor: "), err.Error())
+	}
+
+	_, err := Unmarshal([]byte{0x19})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	deep := strings.Repeat("\x81", maxDepth+1) + "\xf6"
+	_, err = Unmarshal([]byte(deep))
+	assert.EqualError(t, err, "cbor: exceeded max depth")
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Codec{}.Decode(unhex(t, "a1616101"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": int64(1)}, m)
+
+	m, err = Codec{}.Decode(unhex(t, "f6"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, m)
+
+	_, err = Codec{}.Decode(unhex(t, "83010203"), nil)
+	assert.EqualError(t, err, "cbor: body is not a map: got []interface {}")
+}
+
+type signup struct {
+	Name    string    `json:"name" validate:"required"`
+	Age     int       `json:"age" validate:"min=18"`
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+}
+
+func TestBind(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterDecoder(MediaType, Codec{})
+	a.SetErrorHandler(app.ProblemErrorHandler)
+	a.POST("/signup", app.Typed(func(c app.Ctx, in signup) (signup, error) {
+		return in, nil
+	}))
+	post := func(contentType string, body []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	marshal := func(v any) []byte {
+		b, err := Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(post(MediaType, marshal(map[string]any{"name": "Ada", "age": 36, "tags": []string{"a"}})))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"Ada","age":36,"tags":["a"],"created":"0001-01-01T00:00:00Z"}`, rec.Body.String())
+
+		rec = serve(post(MediaType, marshal(map[string]any{"name": "Ada", "age": 12})))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age","message":"must be at least 18"}]`)
+
+		rec = serve(post(MediaType, marshal(map[string]any{"name": "Ada", "age": "old"})))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age"`)
+
+		// {"name": "Ada", "age": 20, "created": 1(1)}, under a +cbor suffix
+		rec = serve(post("application/vnd.example+cbor", unhex(t, "a3646e616d656341646163616765146763726561746564c101")))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"Ada","age":20,"tags":null,"created":"1970-01-01T00:00:01Z"}`, rec.Body.String())
+
+		rec = serve(post(MediaType, []byte{0xff}))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
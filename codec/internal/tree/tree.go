@@ -0,0 +1,114 @@
+// Package tree converts Go values into the generic values that the codec
+// packages encode, so that every format names and omits fields exactly like
+// the JSON encoding does (json tags, omitempty, json.Marshaler, ...), and
+// decoded bodies into the fields that Bind* binds.
+package tree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Field is a member of a Map.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Map is a JSON object with its members in encoding order.
+type Map []Field
+
+// Of returns the tree of v: nil, bool, string, int64, uint64, float64, []any
+// or Map. Integral numbers become int64, or uint64 above math.MaxInt64; other
+// numbers become float64.
+func Of(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return value(dec)
+}
+
+// value reads the next value of dec.
+func value(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			s := []any{}
+			for dec.More() {
+				e, err := value(dec)
+				if err != nil {
+					return nil, err
+				}
+				s = append(s, e)
+			}
+			_, err := dec.Token()
+			return s, err
+		}
+		m := Map{}
+		for dec.More() {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			e, err := value(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, Field{Key: k.(string), Value: e})
+		}
+		_, err := dec.Token()
+		return m, err
+	case json.Number:
+		return number(t)
+	default: // nil, bool or string
+		return t, nil
+	}
+}
+
+// number returns n as an int64, a uint64 or a float64.
+func number(n json.Number) (any, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Key returns the map key of a decoded key: strings as they are, numbers and
+// other scalars formatted with fmt.Sprint.
+func Key(k any) (string, error) {
+	switch k := k.(type) {
+	case string:
+		return k, nil
+	case nil, bool, int, int64, uint64, float32, float64:
+		return fmt.Sprint(k), nil
+	}
+	return "", fmt.Errorf("unsupported map key of type %T", k)
+}
+
+// ErrNotMap is returned by Fields for bodies that are not a map.
+var ErrNotMap = errors.New("body is not a map")
+
+// Fields returns the fields of a decoded body: its map, an empty map for
+// null, or ErrNotMap.
+func Fields(v any) (map[string]any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		return v, nil
+	case nil:
+		return map[string]any{}, nil
+	}
+	return nil, fmt.Errorf("%w: got %T", ErrNotMap, v)
+}
@@ -0,0 +1,265 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+)
+
+// maxDepth bounds the nesting of arrays and maps in decoded bodies.
+const maxDepth = 10000
+
+// timestampExt is the extension type of MessagePack timestamps.
+const timestampExt = -1
+
+var errShort = fmt.Errorf("msgpack: %w", io.ErrUnexpectedEOF)
+
+// Decode returns the fields of the MessagePack map data, which Bind* binds
+// into v.
+func (Codec) Decode(data []byte, _ any) (map[string]any, error) {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := tree.Fields(v)
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return m, nil
+}
+
+// Unmarshal decodes the MessagePack value data into nil, bool, int64 (or
+// uint64 above math.MaxInt64), float64, string, []byte, time.Time (from the
+// timestamp extension), []any or map[string]any. Map keys that are not
+// strings are formatted with fmt.Sprint. Other extension types and data
+// after the value are errors.
+func Unmarshal(data []byte) (any, error) {
+	d := decoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, errors.New("msgpack: data after the top-level value")
+	}
+	return v, nil
+}
+
+// decoder reads MessagePack values from data.
+type decoder struct {
+	data []byte
+	off  int
+}
+
+// next consumes and returns the next n bytes.
+func (d *decoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, errShort
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// uint consumes a big-endian unsigned integer of size bytes.
+func (d *decoder) uint(size int) (uint64, error) {
+	b, err := d.next(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// value consumes the next value, nested depth levels deep.
+func (d *decoder) value(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, errors.New("msgpack: exceeded max depth")
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f: // positive fixint
+		return int64(c), nil
+	case c >= 0xe0: // negative fixint
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80: // fixmap
+		return d.mapOf(uint64(c&0x0f), depth)
+	case c&0xf0 == 0x90: // fixarray
+		return d.array(uint64(c&0x0f), depth)
+	case c&0xe0 == 0xa0: // fixstr
+		return d.str(uint64(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		p, err := d.next(n)
+		return bytes.Clone(p), err
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return u, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := 1 << (c - 0xd0)
+		u, err := d.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		switch size {
+		case 1:
+			return int64(int8(u)), nil
+		case 2:
+			return int64(int16(u)), nil
+		case 4:
+			return int64(int32(u)), nil
+		}
+		return int64(u), nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf: // map 16, 32
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("msgpack: invalid format 0x%02x", c)
+}
+
+// str consumes a UTF-8 string of n bytes.
+func (d *decoder) str(n uint64) (string, error) {
+	p, err := d.next(n)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(p) {
+		return "", errors.New("msgpack: invalid UTF-8 in string")
+	}
+	return string(p), nil
+}
+
+// array consumes n elements.
+func (d *decoder) array(n uint64, depth int) ([]any, error) {
+	if n > uint64(len(d.data)-d.off) { // every element takes a byte at least
+		return nil, errShort
+	}
+	s := make([]any, n)
+	for i := range s {
+		e, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		s[i] = e
+	}
+	return s, nil
+}
+
+// mapOf consumes n key-value pairs.
+func (d *decoder) mapOf(n uint64, depth int) (map[string]any, error) {
+	if n > uint64(len(d.data)-d.off)/2 {
+		return nil, errShort
+	}
+	m := make(map[string]any, n)
+	for range n {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, err := tree.Key(k)
+		if err != nil {
+			return nil, fmt.Errorf("msgpack: %w", err)
+		}
+		if m[key], err = d.value(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ext consumes the type and n bytes of data of an extension. Only
+// timestamps are supported.
+func (d *decoder) ext(n uint64) (any, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	typ := int8(b[0])
+	p, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if typ != timestampExt {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", typ)
+	}
+	var sec, nsec int64
+	switch len(p) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(p))
+	case 8:
+		u := binary.BigEndian.Uint64(p)
+		sec, nsec = int64(u&(1<<34-1)), int64(u>>34)
+	case 12:
+		sec, nsec = int64(binary.BigEndian.Uint64(p[4:])), int64(binary.BigEndian.Uint32(p))
+	default:
+		return nil, fmt.Errorf("msgpack: invalid timestamp of %d bytes", len(p))
+	}
+	if nsec > 999999999 {
+		return nil, errors.New("msgpack: invalid timestamp nanoseconds")
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
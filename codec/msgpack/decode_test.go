@@ -0,0 +1,191 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		data string // hex
+		want any
+	}{
+		{"c0", nil},
+		{"c2", false},
+		{"c3", true},
+		{"7f", int64(127)},
+		{"e0", int64(-32)},
+		{"cc80", int64(128)},
+		{"cd0100", int64(256)},
+		{"ce00010000", int64(1 << 16)},
+		{"cfffffffffffffffff", uint64(math.MaxUint64)},
+		{"d0df", int64(-33)},
+		{"d1ff7f", int64(-129)},
+		{"d2ffff7fff", int64(-32769)},
+		{"d38000000000000000", int64(math.MinInt64)},
+		{"ca3fc00000", 1.5},
+		{"cb3ff8000000000000", 1.5},
+		{"a3416461", "Ada"},
+		{"d903416461", "Ada"},
+		{"da0003416461", "Ada"},
+		{"db00000003416461", "Ada"},
+		{"c403010203", []byte{1, 2, 3}},
+		{"c50000", []byte{}},
+		{"920102", []any{int64(1), int64(2)}},
+		{"dc0001c0", []any{nil}},
+		{"dd00000000", []any{}},
+		{"81a16101", map[string]any{"a": int64(1)}},
+		{"de0001a161c3", map[string]any{"a": true}},
+		{"df00000001a161c2", map[string]any{"a": false}},
+		{"820102c3a178", map[string]any{"1": int64(2), "true": "x"}},
+		{"d6ff00000001", time.Unix(1, 0).UTC()},
+		{"d7ff0000000400000002", time.Unix(2, 1).UTC()},
+		{"c70cff00000003ffffffffffffffff", time.Unix(-1, 3).UTC()},
+	}
+	for _, tt := range tests {
+		v, err := Unmarshal(unhex(t, tt.data))
+		require.NoError(t, err, tt.data)
+		assert.Equal(t, tt.want, v, tt.data)
+	}
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	in := map[string]any{
+		"id":    1,
+		"name":  strings.Repeat("x", 300),
+		"tags":  make([]string, 20),
+		"score": -2.5,
+		"big":   uint64(math.MaxUint64),
+		"addr":  map[string]any{"city": "London"},
+	}
+	b, err := Marshal(in)
+	require.NoError(t, err)
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	tags := make([]any, 20)
+	for i := range tags {
+		tags[i] = ""
+	}
+	assert.Equal(t, map[string]any{
+		"id":    int64(1),
+		"name":  strings.Repeat("x", 300),
+		"tags":  tags,
+		"score": -2.5,
+		"big":   uint64(math.MaxUint64),
+		"addr":  map[string]any{"city": "London"},
+	}, v)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		data string // hex
+		want string
+	}{
+		{"", "unexpected EOF"},
+		{"a34164", "unexpected EOF"},
+		{"cd01", "unexpected EOF"},
+		{"92c0", "unexpected EOF"},
+		{"dd7fffffff", "unexpected EOF"},
+		{"df7fffffff", "unexpected EOF"},
+		{"db7fffffff", "unexpected EOF"},
+		{"c0c0", "data after the top-level value"},
+		{"c1", "invalid format 0xc1"},
+		{"a1ff", "invalid UTF-8"},
+		{"d40100", "unsupported extension type 1"},
+		{"d5ff0000", "invalid timestamp of 2 bytes"},
+		{"d7ffffffffff00000000", "invalid timestamp nanoseconds"},
+		{"8191c0c0", "unsupported map key of type []interface {}"},
+	}
+	for _, tt := range tests {
+		_, err := Unmarshal(unhex(t, tt.data))
+		require.Error(t, err, tt.data)
+		assert.Contains(t, err.Error(), tt.want, tt.data)
+		assert.True(t, strings.HasPrefix(err.Error(), "msgpack: "), err.Error())
+	}
+
+	_, err := Unmarshal([]byte{0xcd})
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	deep := strings.Repeat("\x91", maxDepth+1) + "\xc0"
+	_, err = Unmarshal([]byte(deep))
+	assert.EqualError(t, err, "msgpack: exceeded max depth")
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Codec{}.Decode(unhex(t, "81a16101"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": int64(1)}, m)
+
+	m, err = Codec{}.Decode(unhex(t, "c0"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, m)
+
+	_, err = Codec{}.Decode(unhex(t, "920102"), nil)
+	assert.EqualError(t, err, "msgpack: body is not a map: got []interface {}")
+}
+
+type signup struct {
+	Name    string    `json:"name" validate:"required"`
+	Age     int       `json:"age" validate:"min=18"`
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+}
+
+func TestBind(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterDecoder(MediaType, Codec{})
+	a.SetErrorHandler(app.ProblemErrorHandler)
+	a.POST("/signup", app.Typed(func(c app.Ctx, in signup) (signup, error) {
+		return in, nil
+	}))
+	post := func(body map[string]any) *http.Request {
+		b, err := Marshal(body)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(string(b)))
+		req.Header.Set("Content-Type", MediaType)
+		return req
+	}
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(post(map[string]any{"name": "Ada", "age": 36, "tags": []string{"a"}}))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"Ada","age":36,"tags":["a"],"created":"0001-01-01T00:00:00Z"}`, rec.Body.String())
+
+		rec = serve(post(map[string]any{"name": "Ada", "age": 12}))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age","message":"must be at least 18"}]`)
+
+		rec = serve(post(map[string]any{"name": "Ada", "age": "old"}))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age"`)
+
+		// timestamps bind into time.Time fields
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(string(unhex(t, "83a46e616d65a3416461a3616765cc80a763726561746564d6ff00000001"))))
+		req.Header.Set("Content-Type", MediaType)
+		rec = serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"Ada","age":128,"tags":null,"created":"1970-01-01T00:00:01Z"}`, rec.Body.String())
+
+		req = httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader("\xc1"))
+		req.Header.Set("Content-Type", MediaType)
+		rec = serve(req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,126 @@
+// Package msgpack is a MessagePack codec for Render and decoder for Bind*,
+// built on the standard library only. Values are encoded with the fields of
+// their JSON encoding (json tags, omitempty, json.Marshaler, ...), so a
+// client sees the same document in either format.
+//
+// Register it on an app for both:
+//
+//	a.RegisterCodec(msgpack.Codec{})
+//	a.RegisterDecoder(msgpack.MediaType, msgpack.Codec{})
+package msgpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+)
+
+// MediaType is the media type of MessagePack bodies.
+const MediaType = "application/msgpack"
+
+// Codec encodes responses and decodes request bodies as MessagePack.
+type Codec struct{}
+
+// ContentType returns MediaType.
+func (Codec) ContentType() string { return MediaType }
+
+// Marshal encodes v as MessagePack.
+func (Codec) Marshal(v any) ([]byte, error) { return Marshal(v) }
+
+// Marshal returns the MessagePack encoding of v. Integers use the smallest
+// format that holds them and maps keep the field order of the JSON encoding.
+func Marshal(v any) ([]byte, error) {
+	t, err := tree.Of(v)
+	if err != nil {
+		return nil, err
+	}
+	return appendValue(nil, t), nil
+}
+
+// appendValue appends the encoding of tree value v to b.
+func appendValue(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int64:
+		return appendInt(b, v)
+	case uint64:
+		return appendUint(b, v)
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v))
+	case string:
+		b = appendLen(b, len(v), 0xa0, 32, 0xd9)
+		return append(b, v...)
+	case []any:
+		b = appendLen(b, len(v), 0x90, 16, 0xdc)
+		for _, e := range v {
+			b = appendValue(b, e)
+		}
+		return b
+	case tree.Map:
+		b = appendLen(b, len(v), 0x80, 16, 0xde)
+		for _, f := range v {
+			b = appendValue(b, f.Key)
+			b = appendValue(b, f.Value)
+		}
+		return b
+	}
+	panic(fmt.Sprintf("msgpack: unexpected tree value %T", v))
+}
+
+// appendInt appends the shortest encoding of i.
+func appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return appendUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// appendUint appends the shortest encoding of u.
+func appendUint(b []byte, u uint64) []byte {
+	switch {
+	case u < 128:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+}
+
+// appendLen appends the header of a string, array or map of n elements:
+// the fix format fix|n below fixMax, otherwise the 8-bit (strings only),
+// 16-bit or 32-bit format starting at code.
+func appendLen(b []byte, n int, fix byte, fixMax int, code byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(b, fix|byte(n))
+	case code == 0xd9 && n <= math.MaxUint8:
+		return append(b, code, byte(n))
+	case code == 0xd9:
+		code++
+	}
+	if n <= math.MaxUint16 {
+		return binary.BigEndian.AppendUint16(append(b, code), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code+1), uint32(n))
+}
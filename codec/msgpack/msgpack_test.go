@@ -0,0 +1,104 @@
+package msgpack
+
+import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string // hex
+	}{
+		{nil, "c0"},
+		{false, "c2"},
+		{true, "c3"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{256, "cd0100"},
+		{1 << 16, "ce00010000"},
+		{1 << 32, "cf0000000100000000"},
+		{uint64(math.MaxUint64), "cfffffffffffffffff"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{-129, "d1ff7f"},
+		{-32769, "d2ffff7fff"},
+		{math.MinInt64, "d38000000000000000"},
+		{1.5, "cb3ff8000000000000"},
+		{"", "a0"},
+		{"Ada", "a3416461"},
+		{[]int{1, 2}, "920102"},
+		{map[string]int{"a": 1}, "81a16101"},
+		{user{ID: 1, Name: "Ada"}, "82a2696401a46e616d65a3416461"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.v)
+		require.NoError(t, err, "%v", tt.v)
+		assert.Equal(t, tt.want, hex.EncodeToString(b), "%v", tt.v)
+	}
+}
+
+func TestMarshalLengths(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string // hex of the header
+	}{
+		{strings.Repeat("x", 31), "bf"},
+		{strings.Repeat("x", 32), "d920"},
+		{strings.Repeat("x", 256), "da0100"},
+		{strings.Repeat("x", 1<<16), "db00010000"},
+		{make([]int, 15), "9f"},
+		{make([]int, 16), "dc0010"},
+		{make([]int, 1<<16), "dd00010000"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.v)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, hex.EncodeToString(b[:len(tt.want)/2]))
+	}
+	m := map[string]int{}
+	for i := range 16 {
+		m[strings.Repeat("k", i+1)] = i
+	}
+	b, err := Marshal(m)
+	require.NoError(t, err)
+	assert.Equal(t, "de0010", hex.EncodeToString(b[:3]))
+}
+
+func TestMarshalError(t *testing.T) {
+	_, err := Marshal(func() {})
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterCodec(Codec{})
+	a.GET("/users/:id", func(c app.Ctx) error {
+		return c.Render(http.StatusOK, user{ID: 1, Name: "Ada"})
+	})
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Accept", "application/msgpack")
+		rec := serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, MediaType, rec.Header().Get("Content-Type"))
+		assert.Equal(t, "82a2696401a46e616d65a3416461", hex.EncodeToString(rec.Body.Bytes()))
+	})
+}
@@ -0,0 +1,65 @@
+package yaml
+
+import (
+	"fmt"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Decode returns the fields of the YAML mapping data, which Bind* binds into
+// v. Only the first document is decoded.
+func (Codec) Decode(data []byte, _ any) (map[string]any, error) {
+	v, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := tree.Fields(v)
+	if err != nil {
+		return nil, fmt.Errorf("yaml: %w", err)
+	}
+	return m, nil
+}
+
+// Unmarshal decodes the first YAML document of data with yaml.v3, except
+// that mappings decode to map[string]any, with keys that are not strings
+// formatted with fmt.Sprint.
+func Unmarshal(data []byte) (any, error) {
+	var v any
+	if err := yamlv3.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return stringKeys(v)
+}
+
+// stringKeys replaces the map[any]any mappings of v with map[string]any.
+func stringKeys(v any) (any, error) {
+	var err error
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if v[k], err = stringKeys(e); err != nil {
+				return nil, err
+			}
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			key, err := tree.Key(k)
+			if err != nil {
+				return nil, fmt.Errorf("yaml: %w", err)
+			}
+			if m[key], err = stringKeys(e); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []any:
+		for i, e := range v {
+			if v[i], err = stringKeys(e); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
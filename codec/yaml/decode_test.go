@@ -0,0 +1,120 @@
+package yaml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshal(t *testing.T) {
+	v, err := Unmarshal([]byte(`
+name: Ada
+age: 36
+score: 9.5
+admin: true
+nick: ~
+tags: [a, b]
+1: one
+address:
+  city: London
+  codes: {true: yes, 2.5: half}
+orders:
+  - {id: 1}
+  - id: 2
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":    "Ada",
+		"age":     36,
+		"score":   9.5,
+		"admin":   true,
+		"nick":    nil,
+		"tags":    []any{"a", "b"},
+		"1":       "one",
+		"address": map[string]any{"city": "London", "codes": map[string]any{"true": "yes", "2.5": "half"}},
+		"orders":  []any{map[string]any{"id": 1}, map[string]any{"id": 2}},
+	}, v)
+}
+
+func TestUnmarshalRoundTrip(t *testing.T) {
+	b, err := Marshal(user{Name: "Ada", ID: 1, Score: 9.5, Note: "true", Tags: []string{"b: c"}, Address: address{City: "London"}})
+	require.NoError(t, err)
+	v, err := Unmarshal(b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name":    "Ada",
+		"id":      1,
+		"score":   9.5,
+		"admin":   false,
+		"nick":    nil,
+		"note":    "true",
+		"tags":    []any{"b: c"},
+		"address": map[string]any{"city": "London"},
+	}, v)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	_, err := Unmarshal([]byte("a: [1"))
+	assert.ErrorContains(t, err, "yaml: ")
+
+	_, err = Unmarshal([]byte("? [1, 2]\n: x\n"))
+	assert.ErrorContains(t, err, "yaml: ")
+}
+
+func TestDecode(t *testing.T) {
+	m, err := Codec{}.Decode([]byte("a: 1\n---\nb: 2\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": 1}, m)
+
+	m, err = Codec{}.Decode([]byte("---\n"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{}, m)
+
+	_, err = Codec{}.Decode([]byte("- 1\n- 2\n"), nil)
+	assert.EqualError(t, err, "yaml: body is not a map: got []interface {}")
+}
+
+type signup struct {
+	Name string   `json:"name" validate:"required"`
+	Age  int      `json:"age" validate:"min=18"`
+	Tags []string `json:"tags"`
+}
+
+func TestBind(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterDecoder(MediaType, Codec{})
+	a.SetErrorHandler(app.ProblemErrorHandler)
+	a.POST("/signup", app.Typed(func(c app.Ctx, in signup) (signup, error) {
+		return in, nil
+	}))
+	post := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		rec := serve(post(MediaType, "name: Ada\nage: 36\ntags: [a]\n"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"name":"Ada","age":36,"tags":["a"]}`, rec.Body.String())
+
+		rec = serve(post(MediaType+"; charset=utf-8", "name: Ada\nage: 12\n"))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age","message":"must be at least 18"}]`)
+
+		rec = serve(post(MediaType, "name: Ada\nage: old\n"))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"errors":[{"field":"age"`)
+
+		rec = serve(post("application/vnd.example+yaml", "name: Ada\nage: 20\n"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = serve(post(MediaType, "name: [Ada\n"))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,86 @@
+// Package yaml is a YAML codec for Render and decoder for Bind*, built on
+// gopkg.in/yaml.v3. Values are encoded with the fields of their JSON
+// encoding (json tags, omitempty, json.Marshaler, ...) rather than yaml
+// tags, so a client sees the same document in either format.
+//
+// Register it on an app for both:
+//
+//	a.RegisterCodec(yaml.Codec{})
+//	a.RegisterDecoder(yaml.MediaType, yaml.Codec{})
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/goflash/flash/v2/codec/internal/tree"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// MediaType is the media type of YAML bodies (RFC 9512).
+const MediaType = "application/yaml"
+
+// Codec encodes responses and decodes request bodies as YAML.
+type Codec struct{}
+
+// ContentType returns MediaType with a UTF-8 charset.
+func (Codec) ContentType() string { return MediaType + "; charset=utf-8" }
+
+// Marshal encodes v as YAML.
+func (Codec) Marshal(v any) ([]byte, error) { return Marshal(v) }
+
+// Marshal returns the YAML document of v, indented by two spaces. Mappings
+// keep the field order of the JSON encoding.
+func Marshal(v any) ([]byte, error) {
+	t, err := tree.Of(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node(t)); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// node returns the YAML node of tree value v.
+func node(v any) *yamlv3.Node {
+	switch v := v.(type) {
+	case nil:
+		return scalar("!!null", "null")
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(v))
+	case int64:
+		return scalar("!!int", strconv.FormatInt(v, 10))
+	case uint64:
+		return scalar("!!int", strconv.FormatUint(v, 10))
+	case float64:
+		return scalar("!!float", strconv.FormatFloat(v, 'g', -1, 64))
+	case string:
+		return scalar("!!str", v)
+	case []any:
+		n := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, e := range v {
+			n.Content = append(n.Content, node(e))
+		}
+		return n
+	case tree.Map:
+		n := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, f := range v {
+			n.Content = append(n.Content, scalar("!!str", f.Key), node(f.Value))
+		}
+		return n
+	}
+	panic(fmt.Sprintf("yaml: unexpected tree value %T", v))
+}
+
+// scalar returns a scalar node.
+func scalar(tag, value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value}
+}
@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goflash/flash/v2/app"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type user struct {
+	Name    string   `json:"name"`
+	ID      int      `json:"id"`
+	Score   float64  `json:"score"`
+	Admin   bool     `json:"admin"`
+	Nick    *string  `json:"nick"`
+	Note    string   `json:"note"`
+	Tags    []string `json:"tags"`
+	Address address  `json:"address"`
+	Skip    string   `json:"-"`
+	Empty   string   `json:"empty,omitempty"`
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(user{
+		Name:    "Ada",
+		ID:      1,
+		Score:   9.5,
+		Note:    "true",
+		Tags:    []string{"a", "b: c"},
+		Address: address{City: "London"},
+		Skip:    "x",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `name: Ada
+id: 1
+score: 9.5
+admin: false
+nick: null
+note: "true"
+tags:
+  - a
+  - 'b: c'
+address:
+  city: London
+`, string(b))
+}
+
+func TestMarshalScalars(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, "null\n"},
+		{"123", "\"123\"\n"},
+		{uint64(1 << 63), "9223372036854775808\n"},
+		{-2.5e-7, "-2.5e-07\n"},
+		{[]int{}, "[]\n"},
+		{map[string]int{}, "{}\n"},
+	}
+	for _, tt := range tests {
+		b, err := Marshal(tt.v)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, string(b), "%v", tt.v)
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestRender(t *testing.T) {
+	a := app.New().(*app.DefaultApp)
+	a.RegisterCodec(Codec{})
+	a.GET("/users/:id", func(c app.Ctx) error {
+		return c.Render(http.StatusOK, address{City: "London"})
+	})
+	transporttest.Run(t, a, func(t *testing.T, serve transporttest.ServeFunc) {
+		req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+		req.Header.Set("Accept", "application/yaml")
+		rec := serve(req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/yaml; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "city: London\n", rec.Body.String())
+	})
+}
@@ -1,9 +1,8 @@
 package ctx
 
 import (
-	"encoding/json"
 	"fmt"
-	"mime"
+	"io"
 	"net/url"
 	"reflect"
 	"strings"
@@ -64,6 +63,7 @@ var newMSDecoder = ms.NewDecoder
 // Defaults when options are omitted:
 //   - ErrorUnused = true  (unknown fields cause an error)
 //   - WeaklyTypedInput = false (no implicit type coercion)
+//   - SkipValidation = false (structs are validated after binding)
 //
 // If an options value is provided explicitly, its zero-values are honored as-is.
 //
@@ -89,9 +89,14 @@ type BindJSONOptions struct {
 	WeaklyTypedInput bool
 	// ErrorUnused when true returns an error for unexpected fields.
 	ErrorUnused bool
+	// SkipValidation disables validation after binding into a struct, e.g.
+	// when a struct is filled from several sources and is only complete
+	// after the last Bind call.
+	SkipValidation bool
 }
 
-// BindJSON decodes the request body JSON into v.
+// BindJSON decodes the request body JSON into v with the app's decoder for
+// application/json, JSONDecoder unless another is registered (see Decoder).
 //
 // When v is a pointer to a struct, you may pass BindJSONOptions to control strictness
 // and coercion; otherwise, non-struct targets use the standard library's strict
@@ -122,48 +127,32 @@ type BindJSONOptions struct {
 //	var m map[string]any
 //	_ = c.BindJSON(&m) // uses DisallowUnknownFields and returns raw json errors
 func (c *DefaultContext) BindJSON(v any, opts ...BindJSONOptions) error {
-	// Non-struct targets: use high-performance jsoniter with strict behavior
-	rv := reflect.ValueOf(v)
-	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
-		defer c.r.Body.Close()
-
-		if useStandardJSONForTests {
-			// Use standard library for test compatibility
-			dec := json.NewDecoder(c.r.Body)
-			dec.DisallowUnknownFields()
-			if err := dec.Decode(v); err != nil {
-				if fErr := mapJSONStrictError(err, reflect.TypeOf(nil)); fErr != nil {
-					return fErr
-				}
-				return err
-			}
-			return nil
-		}
-
-		// Use jsoniter for better performance while maintaining strict behavior
-		dec := jsoniterEscape.NewDecoder(c.r.Body)
-		dec.DisallowUnknownFields()
-		if err := dec.Decode(v); err != nil {
-			// Translate jsoniter errors to standard library format for compatibility
-			err = translateJSONError(err, v)
-			if fErr := mapJSONStrictError(err, reflect.TypeOf(nil)); fErr != nil { // no struct type context
-				return fErr
-			}
-			return err
-		}
-		return nil
+	r := c.Request()
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
 	}
-	// For struct targets, collect to map and delegate to BindMap for consistent behavior.
-	m, err := c.collectJSONMap()
+	// JSONDecoder returns the fields of struct targets, for BindMap, and
+	// decodes other targets strictly itself
+	m, err := c.jsonDecoder().Decode(b, v)
 	if err != nil {
 		return err
 	}
+	if m == nil {
+		return c.validateFilled(v, opts)
+	}
 	return c.BindMap(v, m, opts...)
 }
 
 // BindMap binds fields from the provided map into v using mapstructure, honoring options.
 // TagName is "json" for all binders to keep a single source-of-truth for names.
 //
+// After binding into a struct, v is validated with the app's Validator or
+// DefaultValidator (`validate` tags, see ValidateStruct) unless
+// BindJSONOptions.SkipValidation is set. All Bind* helpers that bind structs
+// go through BindMap, so they validate too.
+//
 // The map's keys must match the struct's `json` tag names (or field names if tag missing).
 // Type conversion behavior is governed by BindJSONOptions.WeaklyTypedInput.
 // Unknown key behavior is governed by BindJSONOptions.ErrorUnused.
@@ -185,12 +174,7 @@ func (c *DefaultContext) BindJSON(v any, opts ...BindJSONOptions) error {
 //		// err can be converted to FieldErrors indicating "extra" is unexpected
 //	}
 func (c *DefaultContext) BindMap(v any, m map[string]any, opts ...BindJSONOptions) error {
-	var o BindJSONOptions
-	if len(opts) > 0 {
-		o = opts[0]
-	} else {
-		o.ErrorUnused = true
-	}
+	o := bindOptions(opts)
 
 	// Target struct type for better error messages
 	var targetType reflect.Type
@@ -215,9 +199,20 @@ func (c *DefaultContext) BindMap(v any, m map[string]any, opts ...BindJSONOption
 		}
 		return err
 	}
+	if targetType != nil && !o.SkipValidation {
+		return c.validate(v)
+	}
 	return nil
 }
 
+// bindOptions returns the options passed to a Bind* helper, or the defaults.
+func bindOptions(opts []BindJSONOptions) BindJSONOptions {
+	if len(opts) > 0 {
+		return opts[0]
+	}
+	return BindJSONOptions{ErrorUnused: true}
+}
+
 // BindForm collects form body fields and binds them into v.
 // Supports application/x-www-form-urlencoded and multipart/form-data (textual fields only).
 //
@@ -267,8 +262,11 @@ func (c *DefaultContext) BindPath(v any, opts ...BindJSONOptions) error {
 	return c.BindMap(v, c.collectPathMap(), opts...)
 }
 
-// BindAny merges values from query, body (Form then JSON), and path, and binds them into v.
-// Precedence (highest wins): Path > Body > Query, and within Body: JSON > Form.
+// BindAny merges values from query, body, and path, and binds them into v.
+// Precedence (highest wins): Path > Body > Query. The body is decoded by its
+// Content-Type as in BindBody, including the 415 error for unsupported types.
+// A decoder that fills v itself (see Decoder) runs after the query was bound
+// into v, and the path is bound over its result.
 //
 // This is convenient for handlers that accept input from multiple sources while
 // maintaining a single struct definition.
@@ -285,41 +283,41 @@ func (c *DefaultContext) BindPath(v any, opts ...BindJSONOptions) error {
 //	}
 //	var in In
 //	_ = c.BindAny(&in) // => ID=10 (path), Name="Ada" (json), Active=true (query)
-//
-//	// Form vs JSON precedence: JSON overrides Form for keys present in both
-//	// Body: name="A" (form) and {"name":"B"} (json) => name becomes "B"
 func (c *DefaultContext) BindAny(v any, opts ...BindJSONOptions) error {
+	r := c.Request()
 	// Pre-size map to reduce growth rehashing
 	if !c.hasQueryCache() {
-		c.queryCache = c.r.URL.Query()
+		c.queryCache = r.URL.Query()
 		c.setHasQueryCache(true)
 	}
 	est := len(c.queryCache) + int(c.paramCount)
-	if c.r.PostForm != nil {
-		est += len(c.r.PostForm)
+	if r.PostForm != nil {
+		est += len(r.PostForm)
 	}
-	if c.r.MultipartForm != nil && c.r.MultipartForm.Value != nil {
-		est += len(c.r.MultipartForm.Value)
+	if r.MultipartForm != nil && r.MultipartForm.Value != nil {
+		est += len(r.MultipartForm.Value)
 	}
 	out := make(map[string]any, est)
 
 	// Lowest priority first: Query
 	c.collectQueryInto(out)
 
-	// Body: Form then JSON (JSON overrides Form)
-	ct := c.r.Header.Get("Content-Type")
-	mediaType, _, _ := mime.ParseMediaType(ct)
-	if mediaType == "application/x-www-form-urlencoded" || strings.HasPrefix(mediaType, "multipart/") {
-		if err := c.collectFormInto(out); err != nil {
-			return err
+	// Body, decoded by Content-Type (see BindBody). A decoder that fills v
+	// itself gets v with the query bound, and the path is bound over it.
+	bindQuery := func() error {
+		if len(out) == 0 {
+			return nil
 		}
+		o := bindOptions(opts)
+		o.SkipValidation = true
+		return c.BindMap(v, out, o)
 	}
-	if strings.Contains(mediaType, "+json") || mediaType == "application/json" {
-		jm, err := c.collectJSONMap()
-		if err != nil {
-			return err
-		}
-		mergeInto(out, jm, false)
+	filled, err := c.decodeBody(out, v, bindQuery)
+	if err != nil {
+		return err
+	}
+	if filled {
+		out = make(map[string]any, c.paramCount)
 	}
 
 	// Highest: Path
@@ -328,47 +326,24 @@ func (c *DefaultContext) BindAny(v any, opts ...BindJSONOptions) error {
 	return c.BindMap(v, out, opts...)
 }
 
-// collectJSONMap reads body and parses into map[string]any using high-performance jsoniter.
-// Honors default strictness at BindMap stage.
-func (c *DefaultContext) collectJSONMap() (map[string]any, error) {
-	defer c.r.Body.Close()
-	var m map[string]any
-
-	if useStandardJSONForTests {
-		// Use standard library for test compatibility
-		dec := json.NewDecoder(c.r.Body)
-		if err := dec.Decode(&m); err != nil {
-			return nil, err
-		}
-		return m, nil
-	}
-
-	// Use high-performance jsoniter decoder for better performance
-	dec := jsoniterEscape.NewDecoder(c.r.Body)
-	if err := dec.Decode(&m); err != nil {
-		// Translate jsoniter errors to standard library format for compatibility
-		return nil, translateJSONError(err, &m)
-	}
-	return m, nil
-}
-
 // collectFormMap parses the request form and returns a map[string]any using first value per key.
 func (c *DefaultContext) collectFormMap() (map[string]any, error) {
+	r := c.Request()
 	// ParseForm handles both x-www-form-urlencoded and multipart/form-data
-	if err := c.r.ParseForm(); err != nil {
+	if err := r.ParseForm(); err != nil {
 		return nil, err
 	}
 	// For multipart/form-data, ensure MultipartForm is populated
-	if ct := c.r.Header.Get("Content-Type"); strings.HasPrefix(ct, "multipart/") && c.r.MultipartForm == nil {
+	if ct := r.Header.Get("Content-Type"); strings.HasPrefix(ct, "multipart/") && r.MultipartForm == nil {
 		// Use a reasonable default memory limit similar to net/http server
-		if err := c.r.ParseMultipartForm(32 << 20); err != nil { // 32 MB
+		if err := r.ParseMultipartForm(32 << 20); err != nil { // 32 MB
 			return nil, err
 		}
 	}
 	// Prefer PostForm values; also include multipart textual values
-	out := valuesToMap(c.r.PostForm)
-	if c.r.MultipartForm != nil && c.r.MultipartForm.Value != nil {
-		for k, vals := range c.r.MultipartForm.Value {
+	out := valuesToMap(r.PostForm)
+	if r.MultipartForm != nil && r.MultipartForm.Value != nil {
+		for k, vals := range r.MultipartForm.Value {
 			if len(vals) > 0 {
 				// If key already present from PostForm, keep existing (PostForm first)
 				if _, ok := out[k]; !ok {
@@ -382,13 +357,14 @@ func (c *DefaultContext) collectFormMap() (map[string]any, error) {
 
 // collectQueryMap returns a map from URL query parameters (first value per key).
 func (c *DefaultContext) collectQueryMap() map[string]any {
+	r := c.Request()
 	// Fast path: if no query string, return empty map
-	if c.r.URL.RawQuery == "" {
+	if r.URL.RawQuery == "" {
 		return map[string]any{}
 	}
 	// Use lazy initialization with flag system
 	if !c.hasQueryCache() {
-		c.queryCache = c.r.URL.Query()
+		c.queryCache = r.URL.Query()
 		c.setHasQueryCache(true)
 	}
 	return valuesToMap(c.queryCache)
@@ -396,13 +372,14 @@ func (c *DefaultContext) collectQueryMap() map[string]any {
 
 // collectQueryInto writes first query values into dst (no intermediate map).
 func (c *DefaultContext) collectQueryInto(dst map[string]any) {
+	r := c.Request()
 	// Fast path: if no query string, return immediately
-	if c.r.URL.RawQuery == "" {
+	if r.URL.RawQuery == "" {
 		return
 	}
 	// Use lazy initialization with flag system
 	if !c.hasQueryCache() {
-		c.queryCache = c.r.URL.Query()
+		c.queryCache = r.URL.Query()
 		c.setHasQueryCache(true)
 	}
 	for k, vals := range c.queryCache {
@@ -450,21 +427,22 @@ func (c *DefaultContext) collectPathInto(dst map[string]any) {
 
 // collectFormInto parses the form and writes first values into dst (no intermediate map).
 func (c *DefaultContext) collectFormInto(dst map[string]any) error {
-	if err := c.r.ParseForm(); err != nil {
+	r := c.Request()
+	if err := r.ParseForm(); err != nil {
 		return err
 	}
-	if ct := c.r.Header.Get("Content-Type"); strings.HasPrefix(ct, "multipart/") && c.r.MultipartForm == nil {
-		if err := c.r.ParseMultipartForm(32 << 20); err != nil { // 32 MB
+	if ct := r.Header.Get("Content-Type"); strings.HasPrefix(ct, "multipart/") && r.MultipartForm == nil {
+		if err := r.ParseMultipartForm(32 << 20); err != nil { // 32 MB
 			return err
 		}
 	}
-	for k, vals := range c.r.PostForm {
+	for k, vals := range r.PostForm {
 		if len(vals) > 0 {
 			dst[k] = vals[0]
 		}
 	}
-	if c.r.MultipartForm != nil && c.r.MultipartForm.Value != nil {
-		for k, vals := range c.r.MultipartForm.Value {
+	if r.MultipartForm != nil && r.MultipartForm.Value != nil {
+		for k, vals := range r.MultipartForm.Value {
 			if len(vals) > 0 {
 				dst[k] = vals[0]
 			}
@@ -600,9 +578,45 @@ func mapMapStructureError(err error, o BindJSONOptions, targetType reflect.Type)
 			return fieldErrorsFromMap(map[string]string{field: ErrFieldInvalidType.Error()})
 		}
 	}
+	// Values that cannot be coerced when WeaklyTypedInput is true. map structure
+	// reports one line per field, e.g. "* cannot parse 'age' as int: ..."
+	if o.WeaklyTypedInput {
+		fe := map[string]string{}
+		for _, line := range strings.Split(s, "\n") {
+			field, ok := extractFieldFromMapStructureParseError(line)
+			if !ok {
+				continue
+			}
+			fe[field] = ErrFieldInvalidType.Error()
+			if targetType != nil {
+				if ft, ok2 := findExpectedFieldType(targetType, field); ok2 {
+					fe[field] = expectedTypeLabel(ft) + " " + ErrFieldTypeExpected.Error()
+				}
+			}
+		}
+		if len(fe) > 0 {
+			return fieldErrorsFromMap(fe)
+		}
+	}
 	return err
 }
 
+// extractFieldFromMapStructureParseError extracts the field name from a map
+// structure coercion error line: "* cannot parse 'age' as int: ...".
+func extractFieldFromMapStructureParseError(line string) (string, bool) {
+	const marker = "cannot parse '"
+	start := strings.Index(line, marker)
+	if start == -1 {
+		return "", false
+	}
+	start += len(marker)
+	end := strings.IndexByte(line[start:], '\'')
+	if end <= 0 {
+		return "", false
+	}
+	return line[start : start+end], true
+}
+
 // extractFieldFromMapStructureTypeError extracts the field name from a map structure type error string.
 func extractFieldFromMapStructureTypeError(s string) (string, bool) {
 	if strings.HasPrefix(s, " error(s) decoding:") {
@@ -0,0 +1,311 @@
+package ctx
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Codec encodes response bodies of one media type. Render picks the codec
+// whose media type best matches the request's Accept header. Request bodies
+// are decoded by Decoders, which are registered separately.
+//
+// JSONCodec and XMLCodec are built in. YAML, MessagePack and CBOR codecs are
+// in the opt-in packages under codec/, so the core has no dependency on
+// them; other formats are added by registering a Codec that wraps their
+// library:
+//
+//	type tomlCodec struct{}
+//
+//	func (tomlCodec) ContentType() string           { return "application/toml" }
+//	func (tomlCodec) Marshal(v any) ([]byte, error) { return toml.Marshal(v) }
+//
+//	a.RegisterCodec(tomlCodec{})
+type Codec interface {
+	// ContentType returns the Content-Type of encoded bodies, e.g.
+	// "application/xml; charset=utf-8". Its media type, without parameters,
+	// is matched against Accept.
+	ContentType() string
+	// Marshal encodes v.
+	Marshal(v any) ([]byte, error)
+}
+
+// JSONCodec encodes JSON like Ctx.JSON. Render writes it with Ctx.JSON, so
+// SetJSONEscapeHTML applies.
+type JSONCodec struct{}
+
+// ContentType returns "application/json; charset=utf-8".
+func (JSONCodec) ContentType() string { return headerContentTypeJSON }
+
+// Marshal encodes v as JSON, escaping HTML characters.
+func (JSONCodec) Marshal(v any) ([]byte, error) { return jsoniterEscape.Marshal(v) }
+
+// XMLCodec encodes XML with encoding/xml, prefixed by the XML header.
+type XMLCodec struct{}
+
+// ContentType returns "application/xml; charset=utf-8".
+func (XMLCodec) ContentType() string { return "application/xml; charset=utf-8" }
+
+// Marshal encodes v as an XML document.
+func (XMLCodec) Marshal(v any) ([]byte, error) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// Codecs is an ordered set of codecs keyed by media type. The first codec is
+// the default, used when the request has no Accept header or accepts any
+// type. Codecs are registered at setup; a Codecs is not safe for concurrent
+// registration while serving.
+type Codecs struct {
+	list  []Codec
+	types []string // media types of list, lower case
+}
+
+// NewCodecs returns a Codecs holding cs, in order of preference.
+func NewCodecs(cs ...Codec) *Codecs {
+	r := &Codecs{}
+	for _, c := range cs {
+		r.Register(c)
+	}
+	return r
+}
+
+// DefaultCodecs serves JSON, the default, and XML. It is used by contexts
+// whose app provides no codecs.
+var DefaultCodecs = NewCodecs(JSONCodec{}, XMLCodec{})
+
+// Register adds c, replacing a codec registered for the same media type in
+// place. New media types are appended, after the default.
+func (r *Codecs) Register(c Codec) {
+	mt := mediaType(c.ContentType())
+	for i, t := range r.types {
+		if t == mt {
+			r.list[i] = c
+			return
+		}
+	}
+	r.list = append(r.list, c)
+	r.types = append(r.types, mt)
+}
+
+// Lookup returns the codec registered for the media type of contentType,
+// ignoring parameters such as charset, or nil.
+func (r *Codecs) Lookup(contentType string) Codec {
+	mt := mediaType(contentType)
+	for i, t := range r.types {
+		if t == mt {
+			return r.list[i]
+		}
+	}
+	return nil
+}
+
+// MediaTypes returns the registered media types in order of preference.
+func (r *Codecs) MediaTypes() []string { return append([]string(nil), r.types...) }
+
+// Negotiate returns the codec preferred by accept, an Accept header value,
+// and false if none is acceptable. The codec with the highest q-value wins;
+// on a tie, the most specific matching range (type/subtype over type/* over
+// */*), then the range listed first, then registration order. An empty
+// accept selects the default codec.
+func (r *Codecs) Negotiate(accept string) (Codec, bool) {
+	if len(r.list) == 0 {
+		return nil, false
+	}
+	if strings.TrimSpace(accept) == "" {
+		return r.list[0], true
+	}
+	ranges := parseAccept(accept)
+	best, bestMatch := -1, acceptRange{}
+	for i, t := range r.types {
+		m, ok := matchAccept(ranges, t)
+		if !ok || m.q == 0 {
+			continue
+		}
+		if best < 0 || m.q > bestMatch.q ||
+			(m.q == bestMatch.q && (m.specificity > bestMatch.specificity ||
+				(m.specificity == bestMatch.specificity && m.index < bestMatch.index))) {
+			best, bestMatch = i, m
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	return r.list[best], true
+}
+
+// acceptRange is a media range of an Accept header.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+	specificity  int // 0 for */*, 1 for type/*, 2 for type/subtype
+	index        int // position in the header
+}
+
+// parseAccept parses an Accept header value. Malformed ranges are skipped.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for i, part := range strings.Split(accept, ",") {
+		mr, params, _ := strings.Cut(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mr)), "/")
+		if !ok || typ == "" || subtype == "" || (typ == "*" && subtype != "*") {
+			continue
+		}
+		ar := acceptRange{typ: typ, subtype: subtype, q: 1, index: i}
+		switch {
+		case typ == "*":
+		case subtype == "*":
+			ar.specificity = 1
+		default:
+			ar.specificity = 2
+		}
+		for _, p := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if !strings.EqualFold(k, "q") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+				ar.q = q
+			}
+		}
+		ranges = append(ranges, ar)
+	}
+	return ranges
+}
+
+// matchAccept returns the most specific range of ranges that matches media
+// type mt; its q-value applies to mt.
+func matchAccept(ranges []acceptRange, mt string) (acceptRange, bool) {
+	typ, subtype, _ := strings.Cut(mt, "/")
+	var best acceptRange
+	found := false
+	for _, ar := range ranges {
+		if (ar.typ != "*" && ar.typ != typ) || (ar.subtype != "*" && ar.subtype != subtype) {
+			continue
+		}
+		if !found || ar.specificity > best.specificity {
+			best, found = ar, true
+		}
+	}
+	return best, found
+}
+
+// mediaType returns the lower-case media type of a Content-Type value.
+func mediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// ErrNotAcceptable is matched by the error Render returns when no codec
+// satisfies the Accept header.
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// MediaTypeError reports a media type the app has no codec (Render) or
+// decoder (Bind*) for. Status is the response status it calls for, 406 Not
+// Acceptable from Render or 415 Unsupported Media Type from Bind*, and
+// Supported lists the media types that are. The app's error handling turns it into an *HTTPError with that
+// status.
+type MediaTypeError struct {
+	Status    int
+	MediaType string   // the request header value that could not be served
+	Supported []string // registered media types, in order of preference
+}
+
+// Error describes the unsupported media type and the supported ones.
+func (e *MediaTypeError) Error() string {
+	kind := "codec"
+	if e.Status == http.StatusUnsupportedMediaType {
+		kind = "decoder"
+	}
+	return fmt.Sprintf("ctx: no %s for %q (supported: %s)", kind, e.MediaType, strings.Join(e.Supported, ", "))
+}
+
+// Is reports whether target is the sentinel for e.Status.
+func (e *MediaTypeError) Is(target error) bool {
+	switch target {
+	case ErrNotAcceptable:
+		return e.Status == http.StatusNotAcceptable
+	case ErrUnsupportedMediaType:
+		return e.Status == http.StatusUnsupportedMediaType
+	}
+	return false
+}
+
+// codecs returns the app's codecs, or DefaultCodecs.
+func (c *DefaultContext) codecs() *Codecs {
+	if p, ok := c.appLogger.(interface{ Codecs() *Codecs }); ok {
+		if r := p.Codecs(); r != nil {
+			return r
+		}
+	}
+	return DefaultCodecs
+}
+
+// Render writes v with the given status, encoded by the codec negotiated
+// from the Accept header (see Codecs.Negotiate); JSON is the default. It
+// adds Accept to the Vary header. When no codec is acceptable it writes
+// nothing and returns a *MediaTypeError matching ErrNotAcceptable, which the
+// app's error handling turns into 406 Not Acceptable.
+//
+// Example:
+//
+//	// Accept: application/xml => <user><id>1</id></user>
+//	return c.Render(http.StatusOK, User{ID: 1})
+func (c *DefaultContext) Render(status int, v any) error {
+	c.addVary(headerAccept)
+	codecs := c.codecs()
+	accept := c.requestHeader(headerAccept)
+	codec, ok := codecs.Negotiate(accept)
+	if !ok {
+		return &MediaTypeError{Status: http.StatusNotAcceptable, MediaType: accept, Supported: codecs.MediaTypes()}
+	}
+	if _, ok := codec.(JSONCodec); ok {
+		return c.Status(status).JSON(v)
+	}
+	b, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = c.Send(status, codec.ContentType(), b)
+	return err
+}
+
+// requestHeader returns the first value of request header key, without
+// converting fasthttp requests.
+func (c *DefaultContext) requestHeader(key string) string {
+	if c.isFastHTTP() && c.r == nil {
+		return string(c.fctx.Request.Header.Peek(key))
+	}
+	return c.Request().Header.Get(key)
+}
+
+// addVary adds field to the Vary response header unless it is already
+// listed or Vary is "*".
+func (c *DefaultContext) addVary(field string) {
+	var values []string
+	if c.direct() {
+		for _, v := range c.fctx.Response.Header.PeekAll(headerVary) {
+			values = append(values, string(v))
+		}
+	} else {
+		values = c.w.Header()[headerVary]
+	}
+	for _, v := range values {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f == "*" || strings.EqualFold(f, field) {
+				return
+			}
+		}
+	}
+	c.AddHeader(headerVary, field)
+}
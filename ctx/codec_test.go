@@ -0,0 +1,167 @@
+package ctx
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+type textCodec struct{}
+
+func (textCodec) ContentType() string { return "text/plain; charset=utf-8" }
+func (textCodec) Marshal(v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, errors.New("text: not a string")
+	}
+	return []byte(s), nil
+}
+
+func TestCodecsNegotiate(t *testing.T) {
+	codecs := NewCodecs(JSONCodec{}, XMLCodec{}, textCodec{})
+	tests := []struct {
+		accept string
+		want   string // media type, "" for not acceptable
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml"},
+		{"APPLICATION/XML; charset=utf-8", "application/xml"},
+		{"text/html, application/xml;q=0.9, */*;q=0.1", "application/xml"},
+		{"application/json;q=0.5, application/xml", "application/xml"},
+		{"application/xml, application/json", "application/xml"},
+		{"application/json, application/xml", "application/json"},
+		{"*/*, application/xml", "application/xml"},
+		{"text/*", "text/plain"},
+		{"*/*;q=0.8, application/json;q=0", "application/xml"},
+		{"application/*;q=0.2, text/plain;q=0.3", "text/plain"},
+		{"text/html", ""},
+		{"application/json;q=0, application/xml;q=0, text/plain;q=0", ""},
+		{"garbage, */x", ""},
+	}
+	for _, tt := range tests {
+		c, ok := codecs.Negotiate(tt.accept)
+		if tt.want == "" {
+			assert.False(t, ok, tt.accept)
+			continue
+		}
+		require.True(t, ok, tt.accept)
+		assert.Equal(t, tt.want, mediaType(c.ContentType()), tt.accept)
+	}
+
+	_, ok := NewCodecs().Negotiate("")
+	assert.False(t, ok)
+}
+
+func TestCodecsRegister(t *testing.T) {
+	codecs := NewCodecs(JSONCodec{}, XMLCodec{})
+	codecs.Register(textCodec{})
+	assert.Equal(t, []string{"application/json", "application/xml", "text/plain"}, codecs.MediaTypes())
+	assert.Equal(t, textCodec{}, codecs.Lookup("Text/Plain; charset=utf-8"))
+	assert.Nil(t, codecs.Lookup("application/yaml"))
+
+	// Replacing keeps the position, so JSON stays the default
+	codecs.Register(replacedJSON{})
+	assert.Equal(t, []string{"application/json", "application/xml", "text/plain"}, codecs.MediaTypes())
+	c, _ := codecs.Negotiate("")
+	assert.Equal(t, replacedJSON{}, c)
+}
+
+type replacedJSON struct{ JSONCodec }
+
+func (replacedJSON) ContentType() string { return "application/json" }
+
+type renderUser struct {
+	ID   int    `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+}
+
+func TestRenderNetHTTP(t *testing.T) {
+	render := func(accept, vary string, v any) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		if vary != "" {
+			rec.Header().Set("Vary", vary)
+		}
+		var c DefaultContext
+		c.Reset(rec, req, nil, "/")
+		return rec, c.Render(http.StatusCreated, v)
+	}
+
+	rec, err := render("", "", renderUser{ID: 1, Name: "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id":1,"name":"Ada"}`, rec.Body.String())
+	assert.Equal(t, []string{"Accept"}, rec.Header().Values("Vary"))
+
+	rec, err = render("application/xml", "Origin", renderUser{ID: 1, Name: "Ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "application/xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<renderUser><id>1</id><name>Ada</name></renderUser>`, rec.Body.String())
+	assert.Equal(t, []string{"Origin", "Accept"}, rec.Header().Values("Vary"))
+
+	rec, err = render("application/xml", "origin, accept", renderUser{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"origin, accept"}, rec.Header().Values("Vary"))
+
+	rec, err = render("text/html", "", renderUser{})
+	var mte *MediaTypeError
+	require.ErrorAs(t, err, &mte)
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+	assert.Equal(t, http.StatusNotAcceptable, mte.Status)
+	assert.Equal(t, []string{"application/json", "application/xml"}, mte.Supported)
+	assert.False(t, rec.Flushed)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, []string{"Accept"}, rec.Header().Values("Vary"))
+
+	_, err = render("application/xml", "", map[string]any{"a": 1})
+	assert.Error(t, err)
+}
+
+func TestRenderFastHTTP(t *testing.T) {
+	app := codecsApp{codecs: NewCodecs(textCodec{}, XMLCodec{})}
+	render := func(accept string, v any) (*fasthttp.RequestCtx, error) {
+		fctx := &fasthttp.RequestCtx{}
+		if accept != "" {
+			fctx.Request.Header.Set("Accept", accept)
+		}
+		fctx.Response.Header.Set("Vary", "Origin")
+		var c DefaultContext
+		c.ResetFastHTTP(fctx, nil, "/", app)
+		err := c.Render(http.StatusOK, v)
+		c.Finish()
+		return fctx, err
+	}
+
+	fctx, err := render("application/xml", renderUser{ID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "application/xml; charset=utf-8", string(fctx.Response.Header.ContentType()))
+	assert.Contains(t, string(fctx.Response.Body()), "<renderUser><id>2</id>")
+	assert.Equal(t, [][]byte{[]byte("Origin"), []byte("Accept")}, fctx.Response.Header.PeekAll("Vary"))
+
+	// The app's codecs replace the defaults; the first is the default
+	fctx, err = render("", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", string(fctx.Response.Header.ContentType()))
+	assert.Equal(t, "hello", string(fctx.Response.Body()))
+
+	_, err = render("application/json", "hello")
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+}
+
+// codecsApp stands in for an app with its own codecs.
+type codecsApp struct {
+	validatorApp
+	codecs *Codecs
+}
+
+func (a codecsApp) Codecs() *Codecs { return a.codecs }
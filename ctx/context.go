@@ -0,0 +1,214 @@
+package ctx
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// requestContext holds the state of the context.Context of a request served
+// over fasthttp. It is taken from a pool the first time the request's context
+// is used, so requests that never use it pay nothing for it, and returned to
+// the pool when the request finishes. Values stored with Set are layered on
+// top of the context.
+//
+// The context is cancelled when:
+//   - the handler returns (Finish), as net/http does for its requests
+//   - the fasthttp server shuts down
+//   - the client closes a TCP connection while the handler runs
+//
+// The server and connection are only watched once Done is called.
+//
+// The context handed out is a fastRequestContext naming the generation of
+// the request. Finishing the request starts a new generation, so code that
+// keeps the context after the request finished sees it cancelled while the
+// requestContext serves other requests. The handle is all a request
+// allocates for its context, until Done is called.
+type requestContext struct {
+	mu   sync.Mutex
+	gen  uint64               // generation of the current request
+	fctx *fasthttp.RequestCtx // nil once finished
+	done chan struct{}
+	err  error
+
+	stop     chan struct{}  // closed by finish to stop the watchers
+	conn     net.Conn       // connection being peeked, if any
+	watchers sync.WaitGroup // goroutines started by Done
+}
+
+// aLongTimeAgo is a read deadline in the past, used to interrupt a read that
+// is blocked waiting for the peer.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// closedChan is the Done channel of contexts of finished requests.
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// requestContextPool holds finished requestContexts for reuse.
+var requestContextPool = sync.Pool{New: func() any { return new(requestContext) }}
+
+// acquireRequestContext returns a pooled requestContext serving the request
+// of fctx.
+func acquireRequestContext(fctx *fasthttp.RequestCtx) *requestContext {
+	rc := requestContextPool.Get().(*requestContext)
+	rc.start(fctx)
+	return rc
+}
+
+// start makes rc serve the request of fctx.
+func (rc *requestContext) start(fctx *fasthttp.RequestCtx) {
+	rc.mu.Lock()
+	rc.fctx = fctx
+	rc.mu.Unlock()
+}
+
+// context returns the context of the request rc currently serves.
+func (rc *requestContext) context() context.Context {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return &fastRequestContext{rc: rc, gen: rc.gen}
+}
+
+// fastRequestContext is the context.Context of the request served by rc in
+// generation gen. Once rc moved on to a later generation it reports the
+// request as cancelled.
+type fastRequestContext struct {
+	rc   *requestContext
+	gen  uint64
+	done <-chan struct{} // returned by Done, guarded by rc.mu
+}
+
+// Deadline reports no deadline; derive a context with context.WithTimeout to
+// set one.
+func (fc *fastRequestContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+// Value returns nil: values are stored in contexts derived from fc.
+func (fc *fastRequestContext) Value(key any) any { return nil }
+
+// Err returns context.Canceled once the context was cancelled.
+func (fc *fastRequestContext) Err() error {
+	rc := fc.rc
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.gen != fc.gen {
+		return context.Canceled
+	}
+	return rc.err
+}
+
+// Done returns a channel closed when the context is cancelled. The first call
+// starts watching the server and the connection.
+func (fc *fastRequestContext) Done() <-chan struct{} {
+	rc := fc.rc
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	switch {
+	case fc.done != nil:
+	case rc.gen != fc.gen:
+		fc.done = closedChan
+	case rc.done != nil:
+		fc.done = rc.done
+	case rc.err != nil:
+		rc.done = closedChan
+		fc.done = rc.done
+	default:
+		rc.done = make(chan struct{})
+		rc.watch()
+		fc.done = rc.done
+	}
+	return fc.done
+}
+
+// watch starts the goroutines that cancel rc on server shutdown or when the
+// peer closes the connection. rc.mu must be held.
+func (rc *requestContext) watch() {
+	conn := rc.fctx.Conn()
+	if conn == nil {
+		// A RequestCtx that was not set up by a server or Init
+		return
+	}
+	rc.stop = make(chan struct{})
+
+	if serverDone := rc.fctx.Done(); serverDone != nil {
+		rc.watchers.Add(1)
+		go func(stop <-chan struct{}) {
+			defer rc.watchers.Done()
+			select {
+			case <-serverDone:
+				rc.cancel(context.Canceled)
+			case <-stop:
+			}
+		}(rc.stop)
+	}
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		conn = tc.NetConn()
+	}
+	if !canWaitPeerClosed(conn) {
+		return
+	}
+	// The request has been read, so the read deadline set by the server is
+	// not needed until the next request; lift it to watch for as long as the
+	// handler runs. The server sets it again before reading.
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return
+	}
+	rc.conn = conn
+	rc.watchers.Add(1)
+	go func() {
+		defer rc.watchers.Done()
+		if waitPeerClosed(conn) {
+			rc.cancel(context.Canceled)
+		}
+	}()
+}
+
+// cancel records err and closes the done channel, unless rc was already
+// cancelled.
+func (rc *requestContext) cancel(err error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.err != nil {
+		return
+	}
+	rc.err = err
+	if rc.done != nil {
+		close(rc.done)
+	}
+}
+
+// finish cancels the request's context, stops the watchers and returns rc
+// to the pool for another request. It returns once the connection is no
+// longer read, so the server can read the next request from it.
+func (rc *requestContext) finish() {
+	rc.cancel(context.Canceled)
+
+	rc.mu.Lock()
+	stop, conn := rc.stop, rc.conn
+	rc.fctx, rc.stop, rc.conn = nil, nil, nil
+	rc.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		if conn != nil {
+			_ = conn.SetReadDeadline(aLongTimeAgo)
+		}
+		rc.watchers.Wait()
+		if conn != nil {
+			_ = conn.SetReadDeadline(time.Time{})
+		}
+	}
+
+	// Contexts handed out so far stay cancelled: they name this generation
+	rc.mu.Lock()
+	rc.gen++
+	rc.done, rc.err = nil, nil
+	rc.mu.Unlock()
+	requestContextPool.Put(rc)
+}
@@ -0,0 +1,253 @@
+package ctx
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// serveFast starts a fasthttp server that serves every request with a fresh
+// DefaultContext and returns its address.
+func serveFast(t *testing.T, h func(c *DefaultContext)) (*fasthttp.Server, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &fasthttp.Server{Handler: func(fctx *fasthttp.RequestCtx) {
+		var c DefaultContext
+		c.ResetFastHTTP(fctx, nil, "/")
+		h(&c)
+		c.Finish()
+	}}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return srv, ln.Addr().String()
+}
+
+func waitDone(t *testing.T, ctx context.Context) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("context was not cancelled")
+	}
+}
+
+func TestFastHTTPContextCarriesSetValues(t *testing.T) {
+	var fctx fasthttp.RequestCtx
+	fctx.Request.SetRequestURI("/")
+
+	var c DefaultContext
+	c.ResetFastHTTP(&fctx, nil, "/")
+	c.Set(ctxKey{}, "v1")
+	assert.Equal(t, "v1", c.Context().Value(ctxKey{}))
+	assert.Nil(t, c.r, "Set must not convert the request")
+
+	// Values survive the conversion to *http.Request and later Sets
+	assert.Equal(t, "v1", c.Request().Context().Value(ctxKey{}))
+	c.Set(otherKey{}, "v2")
+	assert.Equal(t, "v1", c.Get(ctxKey{}))
+	assert.Equal(t, "v2", c.Get(otherKey{}))
+
+	ctx := c.Context()
+	require.NoError(t, ctx.Err())
+	c.Finish()
+	waitDone(t, ctx)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// The pooled DefaultContext starts over with a new context for the next
+	// request; the finished one stays cancelled
+	c.ResetFastHTTP(&fctx, nil, "/")
+	assert.Nil(t, c.Get(ctxKey{}))
+	assert.NoError(t, c.Context().Err())
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	c.Finish()
+}
+
+func TestRequestContextReusedAcrossGenerations(t *testing.T) {
+	var first, second fasthttp.RequestCtx
+	rc := acquireRequestContext(&first)
+	old := rc.context()
+	oldDone := old.Done()
+	rc.finish()
+	waitDone(t, old)
+
+	// The same requestContext serves the next request, as the pool hands it
+	// out again
+	rc.start(&second)
+	cur := rc.context()
+	assert.NoError(t, cur.Err())
+	assert.ErrorIs(t, old.Err(), context.Canceled)
+	assert.Equal(t, oldDone, old.Done())
+	select {
+	case <-cur.Done():
+		t.Fatal("the context of the next request is cancelled")
+	default:
+	}
+
+	rc.finish()
+	waitDone(t, cur)
+	assert.ErrorIs(t, cur.Err(), context.Canceled)
+}
+
+func TestFastHTTPContextKeptAfterRequest(t *testing.T) {
+	// One DefaultContext serves every request, as with the app's pool
+	var c DefaultContext
+	contexts := make(chan context.Context, 2)
+	release := make(chan struct{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &fasthttp.Server{Handler: func(fctx *fasthttp.RequestCtx) {
+		c.ResetFastHTTP(fctx, nil, "/")
+		ctx := c.Context()
+		_ = ctx.Done() // watch the connection
+		contexts <- ctx
+		if string(fctx.Path()) == "/second" {
+			<-release
+		}
+		_ = c.String(http.StatusOK, "ok")
+		c.Finish()
+	}}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	get := func(path string) error {
+		if _, err := io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: test\r\n\r\n"); err != nil {
+			return err
+		}
+		res, err := http.ReadResponse(br, nil)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}
+
+	require.NoError(t, get("/first"))
+	kept := <-contexts
+
+	// A goroutine keeps using the first request's context while the next
+	// request is served
+	stop := make(chan struct{})
+	revived := make(chan bool, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				revived <- false
+				return
+			default:
+			}
+			select {
+			case <-kept.Done():
+			default:
+				revived <- true
+				return
+			}
+			if kept.Err() == nil {
+				revived <- true
+				return
+			}
+		}
+	}()
+	served := make(chan error, 1)
+	go func() { served <- get("/second") }()
+	current := <-contexts
+	assert.NoError(t, current.Err(), "the next request has a live context")
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-served)
+	close(stop)
+
+	assert.False(t, <-revived, "a finished context must stay cancelled")
+	assert.ErrorIs(t, kept.Err(), context.Canceled)
+	assert.ErrorIs(t, current.Err(), context.Canceled)
+}
+
+func TestFastHTTPContextCancelledWhenClientGoesAway(t *testing.T) {
+	cancelled := make(chan error, 1)
+	_, addr := serveFast(t, func(c *DefaultContext) {
+		select {
+		case <-c.Context().Done():
+			cancelled <- c.Context().Err()
+		case <-time.After(2 * time.Second):
+			cancelled <- nil
+		}
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond) // let the handler start waiting
+	require.NoError(t, conn.Close())
+
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+}
+
+func TestFastHTTPContextCancelledOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	srv, addr := serveFast(t, func(c *DefaultContext) {
+		done := c.Context().Done()
+		close(started)
+		select {
+		case <-done:
+			cancelled <- c.Context().Err()
+		case <-time.After(2 * time.Second):
+			cancelled <- nil
+		}
+	})
+
+	go func() { _, _ = http.Get("http://" + addr + "/") }()
+	<-started
+	go func() { _ = srv.Shutdown() }()
+
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+}
+
+func TestFastHTTPContextKeepsConnectionUsable(t *testing.T) {
+	// Waiting on the context watches the connection between requests
+	_, addr := serveFast(t, func(c *DefaultContext) {
+		select {
+		case <-c.Context().Done():
+			_ = c.String(http.StatusInternalServerError, "cancelled")
+		default:
+			_ = c.String(http.StatusOK, "ok")
+		}
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+	for i := 0; i < 3; i++ {
+		_, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\n")
+		require.NoError(t, err)
+		res, err := http.ReadResponse(br, nil)
+		require.NoError(t, err, "request %d", i)
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "ok", string(body), "request %d", i)
+	}
+
+	// The watcher must not consume pipelined requests either
+	_, err = io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\n\r\nGET / HTTP/1.1\r\nHost: test\r\n\r\n")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		res, err := http.ReadResponse(br, nil)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+}
@@ -0,0 +1,23 @@
+package ctx
+
+import "context"
+
+type csrfTokenContextKey struct{}
+
+// ContextWithCSRFToken returns a new context carrying the CSRF token of the
+// request. The CSRF middleware stores it so that handlers and templates (the
+// csrfToken function of app.NewHTMLRenderer) can embed it in forms.
+func ContextWithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenContextKey{}, token)
+}
+
+// CSRFTokenFromContext returns the CSRF token stored with
+// ContextWithCSRFToken, or "" if none is found.
+//
+// Example:
+//
+//	tok := ctx.CSRFTokenFromContext(c.Context())
+func CSRFTokenFromContext(ctx context.Context) string {
+	tok, _ := ctx.Value(csrfTokenContextKey{}).(string)
+	return tok
+}
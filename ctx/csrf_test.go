@@ -0,0 +1,16 @@
+package ctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCSRFTokenContext(t *testing.T) {
+	if tok := CSRFTokenFromContext(context.Background()); tok != "" {
+		t.Fatalf("got %q", tok)
+	}
+	c := ContextWithCSRFToken(context.Background(), "abc")
+	if tok := CSRFTokenFromContext(c); tok != "abc" {
+		t.Fatalf("got %q", tok)
+	}
+}
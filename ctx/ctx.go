@@ -1,11 +1,13 @@
 package ctx
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"html"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -59,6 +61,9 @@ type Ctx interface {
 	ResponseWriter() http.ResponseWriter
 	// SetResponseWriter replaces the underlying http.ResponseWriter.
 	SetResponseWriter(http.ResponseWriter)
+	// Hijack takes over the connection, e.g. for a WebSocket upgrade, on both
+	// transports. The response counts as written afterwards.
+	Hijack() (net.Conn, *bufio.ReadWriter, error)
 
 	// Basic request data
 	// Context returns the request-scoped context.Context.
@@ -69,6 +74,10 @@ type Ctx interface {
 	Path() string
 	// Route returns the route pattern (e.g., "/users/:id") when available.
 	Route() string
+	// AllowedMethods returns the methods that have a route for the request path
+	// (e.g., [GET HEAD OPTIONS PUT]), as sent in the Allow header of 405 and
+	// automatic OPTIONS responses.
+	AllowedMethods() []string
 	// Param returns a path parameter by name ("" if not present).
 	// Example: for route "/users/:id", Param("id") => "42".
 	Param(name string) string
@@ -135,6 +144,15 @@ type Ctx interface {
 	String(status int, body string) error
 	// Send writes raw bytes with a specific status and content type.
 	Send(status int, contentType string, b []byte) (int, error)
+	// Render writes v with the status, encoded by the codec negotiated from
+	// the Accept header (JSON by default), and adds Accept to Vary.
+	Render(status int, v any) error
+	// HTML renders the named template with data using the app's Renderer and
+	// writes it as text/html with the status.
+	HTML(status int, name string, data any) error
+	// SSE streams server-sent events written by fn until it returns or the
+	// request context is cancelled.
+	SSE(fn func(stream SSEStream) error, opts ...SSEOptions) error
 	// WroteHeader reports whether the header has already been written to the client.
 	WroteHeader() bool
 
@@ -154,7 +172,10 @@ type Ctx interface {
 	// BindPath collects path parameters and binds them into v.
 	BindPath(v any, opts ...BindJSONOptions) error
 
-	// BindAny collects from path, body (json/form), and query according to priority and binds them into v.
+	// BindBody decodes the body according to its Content-Type (JSON, XML, form or a registered Decoder) and binds it into v.
+	BindBody(v any, opts ...BindJSONOptions) error
+
+	// BindAny collects from path, body (see BindBody), and query according to priority and binds them into v.
 	BindAny(v any, opts ...BindJSONOptions) error
 
 	// Utilities
@@ -183,9 +204,12 @@ type Ctx interface {
 // - Optimized JSON serialization with pre-allocated buffers
 type DefaultContext struct {
 	// Hybrid transport support - only one will be active at a time
-	w    http.ResponseWriter  // net/http response writer (nil if using fasthttp)
-	r    *http.Request        // net/http request (nil if using fasthttp)
+	w    http.ResponseWriter  // response writer (&fw on fasthttp unless wrapped)
+	r    *http.Request        // request (converted on first use on fasthttp)
 	fctx *fasthttp.RequestCtx // fasthttp context (nil if using net/http)
+	fw   fastResponseWriter   // http.ResponseWriter over fctx (see fasthttp.go)
+	rc   *requestContext      // context of the fasthttp request, once used (see context.go)
+	ctx  context.Context      // fasthttp request context until the request is converted
 
 	// Ultra-optimized stack-allocated params (increased to 32)
 	// Covers 99.9%+ of real-world routing scenarios without heap allocation
@@ -193,9 +217,10 @@ type DefaultContext struct {
 	paramSlice router.Params    // heap fallback for >32 params (extremely rare)
 	paramCount uint8            // number of active parameters
 
-	status     uint16 // status code to write (uint16 saves memory)
-	wroteBytes int    // number of bytes written
-	route      string // route pattern (e.g., /users/:id)
+	status     uint16   // status code to write (uint16 saves memory)
+	wroteBytes int      // number of bytes written
+	route      string   // route pattern (e.g., /users/:id)
+	allowed    []string // methods routed for the path (lazy, see AllowedMethods)
 
 	// Pack boolean flags into single byte to reduce memory footprint
 	flags uint8 // bit-packed flags: wroteHeader|jsonEscape|hasQueryCache|isFastHTTP
@@ -275,6 +300,8 @@ func (c *DefaultContext) Reset(w http.ResponseWriter, r *http.Request, ps router
 	c.w = w
 	c.r = r
 	c.fctx = nil
+	c.fw.reset(nil)
+	c.ctx = nil
 	c.route = route
 	c.setFastHTTP(false)
 
@@ -285,8 +312,11 @@ func (c *DefaultContext) Reset(w http.ResponseWriter, r *http.Request, ps router
 func (c *DefaultContext) ResetFastHTTP(fctx *fasthttp.RequestCtx, ps router.Params, route string, appLogger ...interface{ Logger() *slog.Logger }) {
 	// Set fasthttp mode
 	c.fctx = fctx
-	c.w = nil
+	c.fw.reset(fctx)
+	c.w = &c.fw
 	c.r = nil
+	c.rc = nil
+	c.ctx = nil
 	c.route = route
 	c.setFastHTTP(true)
 
@@ -321,6 +351,7 @@ func (c *DefaultContext) resetCommon(ps router.Params, appLogger ...interface{ L
 	// Reset numeric fields efficiently
 	c.status = 0
 	c.wroteBytes = 0
+	c.allowed = nil
 
 	// Reset flags efficiently - set jsonEscape=true (default), keep fasthttp flag
 	if c.isFastHTTP() {
@@ -356,19 +387,37 @@ func (c *DefaultContext) resetCommon(ps router.Params, appLogger ...interface{ L
 	}
 }
 
-// Finish is a hook for context cleanup after request handling. No-op by default.
-// Frameworks may override or extend this method to release per-request resources.
+// Finish is a hook for context cleanup after request handling. Used internally
+// by the framework. On fasthttp it copies headers set through the
+// ResponseWriter's header map to the response if the header was never written,
+// ends a streamed response (see fastResponseWriter.Stream) and cancels the
+// request context.
 func (c *DefaultContext) Finish() {
-	// Reserved for future cleanup; reference receiver to create a coverable statement.
-	_ = c
+	if !c.isFastHTTP() {
+		return
+	}
+	if !c.fw.wrote {
+		c.fw.flushHeader()
+	}
+	c.fw.finishStream()
+	if c.rc != nil {
+		c.rc.finish()
+	}
+}
+
+// direct reports whether responses are written straight to the fasthttp
+// response: on fasthttp, as long as the ResponseWriter has not been replaced
+// and its header map has not been used.
+func (c *DefaultContext) direct() bool {
+	return c.isFastHTTP() && c.fw.header == nil && c.w == &c.fw
 }
 
 // Request returns the underlying *http.Request.
 // Use c.Context() to access the request-scoped context values.
-// Returns nil if using fasthttp transport.
+// On the fasthttp transport the request is converted on first use.
 func (c *DefaultContext) Request() *http.Request {
-	if c.isFastHTTP() {
-		return nil
+	if c.r == nil && c.isFastHTTP() {
+		c.r = newHTTPRequest(c.fctx).WithContext(c.fastContext())
 	}
 	return c.r
 }
@@ -378,31 +427,37 @@ func (c *DefaultContext) Request() *http.Request {
 //
 //	ctx := context.WithValue(c.Context(), key, value)
 //	c.SetRequest(c.Request().WithContext(ctx))
-//
-// No-op if using fasthttp transport.
 func (c *DefaultContext) SetRequest(r *http.Request) {
-	if !c.isFastHTTP() {
-		c.r = r
-	}
+	c.r = r
 }
 
 // ResponseWriter returns the underlying http.ResponseWriter.
-// Returns nil if using fasthttp transport.
+// On the fasthttp transport it writes into the fasthttp response.
 func (c *DefaultContext) ResponseWriter() http.ResponseWriter {
-	if c.isFastHTTP() {
-		return nil
-	}
 	return c.w
 }
 
 // SetResponseWriter replaces the underlying http.ResponseWriter.
 // This is rarely needed in application code, but useful for testing or when
 // wrapping the writer with middleware.
-// No-op if using fasthttp transport.
 func (c *DefaultContext) SetResponseWriter(w http.ResponseWriter) {
-	if !c.isFastHTTP() {
-		c.w = w
+	c.w = w
+}
+
+// Hijack lets the caller take over the connection, e.g. to upgrade it to the
+// WebSocket protocol (see app.WS). It works through writers that wrap the
+// ResponseWriter as long as they implement http.Hijacker or Unwrap, like
+// http.ResponseController.Hijack. Once hijacked, the response counts as
+// written, so error handlers leave it alone, and the caller is responsible
+// for the connection. On fasthttp the connection is closed when the request
+// finishes.
+func (c *DefaultContext) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(c.w).Hijack()
+	if err != nil {
+		return nil, nil, err
 	}
+	c.setWroteHeader(true)
+	return conn, brw, nil
 }
 
 // FastHTTPCtx returns the underlying *fasthttp.RequestCtx.
@@ -418,15 +473,32 @@ func (c *DefaultContext) FastHTTPCtx() *fasthttp.RequestCtx {
 // After the header is written, changing headers or status has no effect.
 func (c *DefaultContext) WroteHeader() bool { return c.wroteHeader() }
 
+// BytesWritten returns the number of response body bytes written through the
+// context helpers (JSON, String and Send).
+func (c *DefaultContext) BytesWritten() int { return c.wroteBytes }
+
 // Context returns the request context.Context.
-// It is the same as c.Request().Context() for net/http, or creates one for fasthttp.
+// It is the same as c.Request().Context(). On fasthttp the context is
+// cancelled when the handler returns, the client closes the connection or the
+// server shuts down, and it carries the values stored with Set.
 func (c *DefaultContext) Context() context.Context {
+	if c.r != nil {
+		return c.r.Context()
+	}
 	if c.isFastHTTP() {
-		// For fasthttp, we need to create a context
-		// In practice, we could cache this or use a more sophisticated approach
-		return context.Background()
+		return c.fastContext()
 	}
-	return c.r.Context()
+	return context.Background()
+}
+
+// fastContext returns the context of a fasthttp request that was not
+// converted, taking the request's requestContext from the pool on first use.
+func (c *DefaultContext) fastContext() context.Context {
+	if c.ctx == nil {
+		c.rc = acquireRequestContext(c.fctx)
+		c.ctx = c.rc.context()
+	}
+	return c.ctx
 }
 
 // Set stores a value in the request context using the provided key and value.
@@ -441,6 +513,11 @@ func (c *DefaultContext) Context() context.Context {
 //	c.Set(userKey{}, currentUser)
 func (c *DefaultContext) Set(key, value any) Ctx {
 	ctx := context.WithValue(c.Context(), key, value)
+	if c.r == nil && c.isFastHTTP() {
+		// Keep the value on the context; Request carries it over on conversion
+		c.ctx = ctx
+		return c
+	}
 	c.SetRequest(c.Request().WithContext(ctx))
 	return c
 }
@@ -486,6 +563,30 @@ func (c *DefaultContext) Path() string {
 // For example, "/users/:id".
 func (c *DefaultContext) Route() string { return c.route }
 
+// AllowedMethods returns the sorted methods that have a route matching the
+// request path. The router sets it before invoking the 405 and automatic
+// OPTIONS handlers; elsewhere it is computed on first use from the app.
+//
+// Example:
+//
+//	a.SetMethodNotAllowedHandler(func(c flash.Ctx) error {
+//		return c.Status(http.StatusMethodNotAllowed).JSON(map[string]any{
+//			"allowed": c.AllowedMethods(),
+//		})
+//	})
+func (c *DefaultContext) AllowedMethods() []string {
+	if c.allowed == nil {
+		if r, ok := c.appLogger.(interface{ AllowedMethods(path string) []string }); ok {
+			c.allowed = r.AllowedMethods(c.Path())
+		}
+	}
+	return c.allowed
+}
+
+// SetAllowedMethods sets the value returned by AllowedMethods. Used internally
+// by the framework when it has already computed the set.
+func (c *DefaultContext) SetAllowedMethods(methods []string) { c.allowed = methods }
+
 // Param returns a path parameter by name. Returns "" if not found.
 // Ultra-optimized with direct array access and minimal branching.
 //
@@ -752,7 +853,7 @@ func (c *DefaultContext) StatusCode() int {
 // Header sets a header on the response using optimized direct map manipulation.
 // Has no effect after the header is written. This method is automatically optimized for performance.
 func (c *DefaultContext) Header(key, value string) {
-	if c.isFastHTTP() {
+	if c.direct() {
 		c.fctx.Response.Header.Set(key, value)
 	} else {
 		c.w.Header()[http.CanonicalHeaderKey(key)] = []string{value}
@@ -762,7 +863,7 @@ func (c *DefaultContext) Header(key, value string) {
 // AddHeader adds a header value (appends if header already exists).
 // More efficient than multiple Header() calls for the same key.
 func (c *DefaultContext) AddHeader(key, value string) {
-	if c.isFastHTTP() {
+	if c.direct() {
 		c.fctx.Response.Header.Add(key, value)
 	} else {
 		c.w.Header().Add(key, value)
@@ -772,58 +873,69 @@ func (c *DefaultContext) AddHeader(key, value string) {
 // SetHeaders sets multiple headers efficiently in a single operation.
 // This is more efficient than multiple Header() calls.
 func (c *DefaultContext) SetHeaders(headers map[string]string) {
-	h := c.w.Header()
 	for k, v := range headers {
-		h[k] = []string{v}
+		c.setHeader(k, []string{v})
 	}
 }
 
 // SetHeadersFromMap sets multiple headers from an http.Header map.
 // This allows reusing pre-computed header combinations.
 func (c *DefaultContext) SetHeadersFromMap(headers http.Header) {
-	h := c.w.Header()
 	for k, v := range headers {
-		h[k] = v // Direct slice assignment (no allocation)
+		c.setHeader(k, v) // Direct slice assignment on net/http (no allocation)
+	}
+}
+
+// setHeader replaces the values of the response header key.
+func (c *DefaultContext) setHeader(key string, values []string) {
+	if c.direct() {
+		h := &c.fctx.Response.Header
+		h.Del(key)
+		for _, v := range values {
+			h.Add(key, v)
+		}
+		return
 	}
+	c.w.Header()[key] = values
 }
 
 // Common header setters for frequently used headers (optimized for performance)
 
 // SetContentType sets the Content-Type header with pre-allocated strings.
 func (c *DefaultContext) SetContentType(contentType string) {
-	c.w.Header()[headerContentType] = []string{contentType}
+	c.setHeader(headerContentType, []string{contentType})
 }
 
 // SetContentTypeJSON sets Content-Type to application/json using pre-allocated string.
 func (c *DefaultContext) SetContentTypeJSON() {
-	c.w.Header()[headerContentType] = []string{headerContentTypeJSON}
+	c.setHeader(headerContentType, []string{headerContentTypeJSON})
 }
 
 // SetContentTypeText sets Content-Type to text/plain using pre-allocated string.
 func (c *DefaultContext) SetContentTypeText() {
-	c.w.Header()[headerContentType] = []string{headerContentTypeText}
+	c.setHeader(headerContentType, []string{headerContentTypeText})
 }
 
 // SetCacheControl sets the Cache-Control header.
 func (c *DefaultContext) SetCacheControl(value string) {
-	c.w.Header()[headerCacheControl] = []string{value}
+	c.setHeader(headerCacheControl, []string{value})
 }
 
 // SetNoCache sets headers to disable caching.
 func (c *DefaultContext) SetNoCache() {
-	c.w.Header()[headerCacheControl] = []string{headerValueNoCache}
+	c.setHeader(headerCacheControl, []string{headerValueNoCache})
 }
 
 // SetMaxAge sets Cache-Control with max-age directive.
 func (c *DefaultContext) SetMaxAge(seconds int) {
 	if seconds == 3600 {
-		c.w.Header()[headerCacheControl] = []string{headerValueMaxAge1Hour}
+		c.setHeader(headerCacheControl, []string{headerValueMaxAge1Hour})
 	} else if seconds == 86400 {
-		c.w.Header()[headerCacheControl] = []string{headerValueMaxAge1Day}
+		c.setHeader(headerCacheControl, []string{headerValueMaxAge1Day})
 	} else if seconds == 604800 {
-		c.w.Header()[headerCacheControl] = []string{headerValueMaxAge1Week}
+		c.setHeader(headerCacheControl, []string{headerValueMaxAge1Week})
 	} else {
-		c.w.Header()[headerCacheControl] = []string{"max-age=" + strconv.Itoa(seconds)}
+		c.setHeader(headerCacheControl, []string{"max-age=" + strconv.Itoa(seconds)})
 	}
 }
 
@@ -857,6 +969,8 @@ var jsonBufPool = sync.Pool{
 var (
 	headerContentTypeText = "text/plain; charset=utf-8"
 	headerContentTypeJSON = "application/json; charset=utf-8"
+	headerContentTypeHTML = "text/html; charset=utf-8"
+	headerContentTypeSSE  = "text/event-stream"
 	headerContentLength   = "Content-Length"
 	headerContentType     = "Content-Type"
 	headerCacheControl    = "Cache-Control"
@@ -868,6 +982,7 @@ var (
 	headerAcceptEncoding  = "Accept-Encoding"
 	headerConnection      = "Connection"
 	headerUpgrade         = "Upgrade"
+	headerVary            = "Vary"
 )
 
 // Pre-computed common header combinations to avoid map allocations
@@ -957,7 +1072,7 @@ func (c *DefaultContext) JSON(v any) error {
 	}
 
 	if err != nil {
-		if c.isFastHTTP() {
+		if c.direct() {
 			c.fctx.SetStatusCode(fasthttp.StatusInternalServerError)
 		} else {
 			if !c.wroteHeader() {
@@ -968,16 +1083,20 @@ func (c *DefaultContext) JSON(v any) error {
 		return err
 	}
 
-	if c.isFastHTTP() {
+	if c.direct() {
 		// FastHTTP optimized path - zero allocations
-		if c.status == 0 {
-			c.status = http.StatusOK
+		if !c.wroteHeader() {
+			if c.status == 0 {
+				c.status = http.StatusOK
+			}
+			c.fctx.SetStatusCode(int(c.status))
+			if !hasContentType(&c.fctx.Response.Header) {
+				c.fctx.SetContentType(headerContentTypeJSON)
+			}
+			c.setWroteHeader(true)
 		}
-		c.fctx.SetStatusCode(int(c.status))
-		c.fctx.SetContentType(headerContentTypeJSON)
-		c.fctx.SetBody(b)
+		c.fctx.Response.AppendBody(b)
 		c.wroteBytes += len(b)
-		c.setWroteHeader(true)
 		return nil
 	}
 
@@ -1007,13 +1126,18 @@ func (c *DefaultContext) JSON(v any) error {
 //
 //	return c.String(http.StatusOK, "pong")
 func (c *DefaultContext) String(status int, body string) error {
-	if c.isFastHTTP() {
+	if c.direct() {
 		// FastHTTP optimized path - zero allocations with direct byte manipulation
-		c.fctx.SetStatusCode(status)
-		c.fctx.SetContentType(headerContentTypeText)
-		c.fctx.SetBodyString(body)
+		if !c.wroteHeader() {
+			c.status = uint16(status)
+			c.fctx.SetStatusCode(status)
+			if !hasContentType(&c.fctx.Response.Header) {
+				c.fctx.SetContentType(headerContentTypeText)
+			}
+			c.setWroteHeader(true)
+		}
+		c.fctx.Response.AppendBodyString(body)
 		c.wroteBytes += len(body)
-		c.setWroteHeader(true)
 		return nil
 	}
 
@@ -1025,6 +1149,7 @@ func (c *DefaultContext) String(status int, body string) error {
 			h[headerContentType] = []string{headerContentTypeText}
 		}
 		h[headerContentLength] = []string{fastContentLength(len(body))}
+		c.status = uint16(status)
 		c.w.WriteHeader(status)
 		c.setWroteHeader(true)
 	}
@@ -1042,12 +1167,27 @@ func (c *DefaultContext) String(status int, body string) error {
 //	data := []byte("<xml>ok</xml>")
 //	_, err := c.Send(http.StatusOK, "application/xml", data)
 func (c *DefaultContext) Send(status int, contentType string, b []byte) (int, error) {
+	if c.direct() {
+		if !c.wroteHeader() {
+			c.status = uint16(status)
+			c.fctx.SetStatusCode(status)
+			if contentType != "" && !hasContentType(&c.fctx.Response.Header) {
+				c.fctx.SetContentType(contentType)
+			}
+			c.setWroteHeader(true)
+		}
+		c.fctx.Response.AppendBody(b)
+		c.wroteBytes += len(b)
+		return len(b), nil
+	}
+
 	if !c.wroteHeader() {
 		h := c.w.Header()
 		if contentType != "" && h.Get(headerContentType) == "" {
 			h[headerContentType] = []string{contentType}
 		}
 		h[headerContentLength] = []string{fastContentLength(len(b))}
+		c.status = uint16(status)
 		c.w.WriteHeader(status)
 		c.setWroteHeader(true)
 	}
@@ -1058,8 +1198,21 @@ func (c *DefaultContext) Send(status int, contentType string, b []byte) (int, er
 
 // Clone returns a shallow copy of the context.
 // Safe for use across goroutines as long as the ResponseWriter is swapped to a
-// concurrency-safe writer if needed.
-func (c *DefaultContext) Clone() Ctx { cp := *c; return &cp }
+// concurrency-safe writer if needed. On fasthttp the copy reads the request
+// from its own *http.Request and params, so it stays valid after the request
+// finishes and the fasthttp request is reused; until it is given a writer
+// with SetResponseWriter, its writes fail rather than reach the response.
+func (c *DefaultContext) Clone() Ctx {
+	if c.isFastHTTP() {
+		// Share the request context, which Finish cancels
+		c.Context()
+	}
+	cp := *c
+	if c.isFastHTTP() {
+		cp.detach()
+	}
+	return &cp
+}
 
 // AppLogger returns the application logger from the context.
 // This avoids the need to inject logger into request context, reducing allocations.
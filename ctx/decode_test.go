@@ -0,0 +1,293 @@
+package ctx
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeLines decodes "key: value" lines, standing in for a user decoder.
+var decodeLines = DecoderFunc(func(data []byte, v any) (map[string]any, error) {
+	m := map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		k, val, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, errors.New("lines: missing colon")
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+	}
+	return m, nil
+})
+
+// decodersApp stands in for an app with its own decoders.
+type decodersApp struct {
+	validatorApp
+	decoders *Decoders
+}
+
+func (a decodersApp) Decoders() *Decoders { return a.decoders }
+
+type xmlOrder struct {
+	ID    int    `json:"id"`
+	Email string `json:"email" validate:"email"`
+	Items []struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	} `json:"item"`
+}
+
+func bodyContext(tr *transport, method, contentType, body string, app ...interface{ Logger() *slog.Logger }) (*DefaultContext, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	var c DefaultContext
+	if len(app) > 0 {
+		tr.reset(&c, rec, req, nil, "/orders", app[0])
+	} else {
+		tr.reset(&c, rec, req, nil, "/orders")
+	}
+	return &c, rec
+}
+
+func TestBindBodyXML(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		weak := BindJSONOptions{WeaklyTypedInput: true, ErrorUnused: true}
+		body := `<?xml version="1.0"?>
+<order id="7">
+  <email>ada@example.com</email>
+  <item><sku>a</sku><qty>1</qty></item>
+  <item><sku>b</sku><qty>2</qty></item>
+</order>`
+		for _, ct := range []string{"application/xml", "application/xml; charset=utf-8", "application/atom+xml"} {
+			c, _ := bodyContext(tr, http.MethodPost, ct, body)
+			var out xmlOrder
+			require.NoError(t, c.BindBody(&out, weak), ct)
+			assert.Equal(t, 7, out.ID)
+			assert.Equal(t, "ada@example.com", out.Email)
+			require.Len(t, out.Items, 2)
+			assert.Equal(t, "b", out.Items[1].SKU)
+			assert.Equal(t, 2, out.Items[1].Qty)
+		}
+
+		// Unknown elements and validation report FieldErrors like JSON
+		c, _ := bodyContext(tr, http.MethodPost, "application/xml", `<order><email>nope</email><admin>1</admin></order>`)
+		var out xmlOrder
+		err := c.BindBody(&out, weak)
+		assert.ErrorIs(t, err, ErrFieldUnexpected)
+		c, _ = bodyContext(tr, http.MethodPost, "application/xml", `<order><email>nope</email></order>`)
+		err = c.BindBody(&out, weak)
+		assert.Equal(t, map[string]string{"email": "must be a valid email address"}, fieldMap(t, err))
+
+		c, _ = bodyContext(tr, http.MethodPost, "application/xml", `<order><email>`)
+		assert.ErrorContains(t, c.BindBody(&out, weak), "decode application/xml body")
+	})
+}
+
+func TestBindBodyRegisteredDecoder(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		decoders := NewDecoders()
+		decoders.Register("text/x-lines", decodeLines)
+		app := decodersApp{decoders: decoders}
+		c, _ := bodyContext(tr, http.MethodPost, "text/x-lines; charset=utf-8", "name: Ada\nage: 36\n", app)
+		var out struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}
+		require.NoError(t, c.BindBody(&out, BindJSONOptions{WeaklyTypedInput: true}))
+		assert.Equal(t, "Ada", out.Name)
+		assert.Equal(t, 36, out.Age)
+
+		c, _ = bodyContext(tr, http.MethodPost, "text/x-lines", "name Ada", app)
+		assert.ErrorContains(t, c.BindBody(&out), "decode text/x-lines body: lines: missing colon")
+
+		// JSON, XML and forms keep their built-in decoding
+		c, _ = bodyContext(tr, http.MethodPost, "application/json", `{"name":"Bob","age":7}`, app)
+		require.NoError(t, c.BindBody(&out))
+		assert.Equal(t, "Bob", out.Name)
+		c, _ = bodyContext(tr, http.MethodPost, "application/xml", "<u><age>9</age></u>", app)
+		require.NoError(t, c.BindBody(&out, BindJSONOptions{WeaklyTypedInput: true}))
+		assert.Equal(t, 9, out.Age)
+		c, _ = bodyContext(tr, http.MethodPost, "application/x-www-form-urlencoded", "name=Cy&age=8", app)
+		require.NoError(t, c.BindBody(&out, BindJSONOptions{WeaklyTypedInput: true}))
+		assert.Equal(t, 8, out.Age)
+
+		c, rec := bodyContext(tr, http.MethodPost, "text/csv", "a,b", app)
+		assert.ErrorIs(t, c.BindBody(&out), ErrUnsupportedMediaType)
+		tr.flush(c)
+		assert.Equal(t, "application/json, application/x-www-form-urlencoded, multipart/form-data, application/xml, text/x-lines", rec.Header().Get("Accept-Post"))
+
+		// Codecs only encode responses: registering one does not decode bodies
+		codecs := codecsApp{codecs: NewCodecs(JSONCodec{}, textCodec{})}
+		c, _ = bodyContext(tr, http.MethodPost, "text/plain", "Ada", codecs)
+		assert.ErrorIs(t, c.BindBody(&out), ErrUnsupportedMediaType)
+	})
+}
+
+// protoUser stands in for a generated message that decodes itself.
+type protoUser struct {
+	Name  string `validate:"required"`
+	Email string `validate:"email"`
+}
+
+func (u *protoUser) unmarshal(data []byte) error {
+	name, email, _ := strings.Cut(string(data), "|")
+	*u = protoUser{Name: name, Email: email}
+	return nil
+}
+
+func TestBindDecoderFillsTarget(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		decoders := NewDecoders()
+		decoders.Register("application/x-protobuf", DecoderFunc(func(data []byte, v any) (map[string]any, error) {
+			u, ok := v.(*protoUser)
+			if !ok {
+				return nil, errors.New("not a message")
+			}
+			return nil, u.unmarshal(data)
+		}))
+		app := decodersApp{decoders: decoders}
+
+		c, _ := bodyContext(tr, http.MethodPost, "application/x-protobuf", "Ada|ada@example.com", app)
+		var u protoUser
+		require.NoError(t, c.BindBody(&u))
+		assert.Equal(t, protoUser{Name: "Ada", Email: "ada@example.com"}, u)
+
+		// The filled target is validated like a bound one
+		c, _ = bodyContext(tr, http.MethodPost, "application/x-protobuf", "|nope", app)
+		err := c.BindBody(&u)
+		assert.Equal(t, map[string]string{"Name": "required", "Email": "must be a valid email address"}, fieldMap(t, err))
+		c, _ = bodyContext(tr, http.MethodPost, "application/x-protobuf", "|nope", app)
+		assert.NoError(t, c.BindBody(&u, BindJSONOptions{SkipValidation: true}))
+
+		c, _ = bodyContext(tr, http.MethodPost, "application/x-protobuf", "Ada", app)
+		var other struct{ Name string }
+		assert.ErrorContains(t, c.BindBody(&other), "decode application/x-protobuf body: not a message")
+	})
+}
+
+func TestBindAnyDecoderFillsTarget(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		type in struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			Page int    `json:"page"`
+		}
+		decoders := NewDecoders()
+		decoders.Register("text/x-name", DecoderFunc(func(data []byte, v any) (map[string]any, error) {
+			v.(*in).Name = string(data)
+			return nil, nil
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/users/7?page=2&name=query", strings.NewReader("Ada"))
+		req.Header.Set("Content-Type", "text/x-name")
+		var c DefaultContext
+		tr.reset(&c, httptest.NewRecorder(), req, httprouter.Params{{Key: "id", Value: "7"}}, "/users/:id", decodersApp{decoders: decoders})
+
+		var out in
+		require.NoError(t, c.BindAny(&out, BindJSONOptions{WeaklyTypedInput: true}))
+		assert.Equal(t, in{ID: "7", Name: "Ada", Page: 2}, out)
+	})
+}
+
+func TestBindJSONUsesRegisteredDecoder(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		decoders := NewDecoders()
+		decoders.Register(mediaTypeJSON, DecoderFunc(func(data []byte, v any) (map[string]any, error) {
+			m, err := JSONDecoder{}.Decode(data, v)
+			if m != nil {
+				m["source"] = "custom"
+			}
+			return m, err
+		}))
+		app := decodersApp{decoders: decoders}
+		var out struct {
+			Name   string `json:"name"`
+			Source string `json:"source"`
+		}
+
+		c, _ := bodyContext(tr, http.MethodPost, "application/json", `{"name":"Ada"}`, app)
+		require.NoError(t, c.BindJSON(&out))
+		assert.Equal(t, "custom", out.Source)
+
+		// BindBody and BindAny use it for JSON and +json bodies too
+		out.Source = ""
+		c, _ = bodyContext(tr, http.MethodPost, "application/problem+json", `{"name":"Ada"}`, app)
+		require.NoError(t, c.BindBody(&out))
+		assert.Equal(t, "custom", out.Source)
+	})
+}
+
+func TestBindBodyMalformedContentType(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		c, rec := bodyContext(tr, http.MethodPost, "application/json; charset", `{"name":"Ada"}`)
+		var out struct {
+			Name string `json:"name"`
+		}
+		err := c.BindBody(&out)
+		var mte *MediaTypeError
+		require.ErrorAs(t, err, &mte)
+		assert.Equal(t, http.StatusUnsupportedMediaType, mte.Status)
+		assert.Equal(t, "application/json; charset", mte.MediaType)
+		tr.flush(c)
+		assert.NotEmpty(t, rec.Header().Get("Accept-Post"))
+
+		c, _ = bodyContext(tr, http.MethodPost, "not a type", `{"name":"Ada"}`)
+		assert.ErrorIs(t, c.BindAny(&out), ErrUnsupportedMediaType)
+	})
+}
+
+func TestBindBodyUnsupportedMediaType(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		c, rec := bodyContext(tr, http.MethodPost, "text/plain", "hello")
+		var out struct {
+			Name string `json:"name"`
+		}
+		err := c.BindAny(&out)
+		var mte *MediaTypeError
+		require.ErrorAs(t, err, &mte)
+		assert.Equal(t, http.StatusUnsupportedMediaType, mte.Status)
+		assert.Equal(t, "text/plain", mte.MediaType)
+		assert.NotErrorIs(t, err, ErrNotAcceptable)
+		tr.flush(c)
+		assert.Equal(t, "application/json, application/x-www-form-urlencoded, multipart/form-data, application/xml", rec.Header().Get("Accept-Post"))
+
+		c, rec = bodyContext(tr, http.MethodPatch, "text/plain", "hello")
+		assert.ErrorIs(t, c.BindBody(&out), ErrUnsupportedMediaType)
+		tr.flush(c)
+		assert.NotEmpty(t, rec.Header().Get("Accept-Patch"))
+		assert.Empty(t, rec.Header().Get("Accept-Post"))
+
+		// Without a body or a Content-Type there is nothing to decode
+		c, _ = bodyContext(tr, http.MethodPost, "text/plain", "")
+		assert.NoError(t, c.BindBody(&out))
+		c, _ = bodyContext(tr, http.MethodPost, "", "hello")
+		assert.NoError(t, c.BindBody(&out))
+	})
+}
+
+func TestXMLDecoderMap(t *testing.T) {
+	m, err := XMLDecoder{}.Decode([]byte(`<r a="1"><b>x</b><b>y</b><c><d> z </d></c><e/></r>`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"a": "1",
+		"b": []any{"x", "y"},
+		"c": map[string]any{"d": "z"},
+		"e": "",
+	}, m)
+
+	m, err = XMLDecoder{}.Decode([]byte(`<r>text</r>`), nil)
+	require.NoError(t, err)
+	assert.Empty(t, m)
+	assert.NotNil(t, m)
+	_, err = XMLDecoder{}.Decode(bytes.Repeat([]byte(" "), 3), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,440 @@
+package ctx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	router "github.com/julienschmidt/httprouter"
+	"github.com/valyala/fasthttp"
+)
+
+// Transport parity
+//
+// Handlers and middleware are written against net/http types: Request,
+// ResponseWriter and the http.Header of the response. On the fasthttp
+// transport DefaultContext provides the same views on demand:
+//
+//   - Request converts the fasthttp request into an *http.Request on first
+//     use; the conversion is cached for the rest of the request
+//   - ResponseWriter returns an http.ResponseWriter that writes into the
+//     fasthttp response, so middleware can wrap it with SetResponseWriter
+//   - Context returns a context of its own for each request, cancelled like
+//     the context of a net/http request (see context.go)
+//
+// As long as neither view of the response is used, the Ctx helpers write to
+// the fasthttp response directly and the fast path costs nothing extra.
+
+// fastResponseWriter is the http.ResponseWriter used on the fasthttp
+// transport. The net/http header map is built from the fasthttp response
+// headers the first time Header is called and copied back when the header is
+// written.
+type fastResponseWriter struct {
+	fctx   *fasthttp.RequestCtx
+	header http.Header
+	wrote  bool
+	stream *fastStream // set by Stream
+
+	hijacked bool // set by Hijack
+}
+
+// errDetachedWriter is returned by writes to the response writer of a
+// context cloned on fasthttp, which has no response to write to.
+var errDetachedWriter = errors.New("ctx: cloned context has no response writer; set one with SetResponseWriter")
+
+// reset points w at fctx for a new request. A nil fctx detaches w (see
+// DefaultContext.detach): writes then fail with errDetachedWriter.
+func (w *fastResponseWriter) reset(fctx *fasthttp.RequestCtx) {
+	w.fctx = fctx
+	w.header = nil
+	w.wrote = false
+	w.stream = nil
+	w.hijacked = false
+}
+
+// Header returns the response headers as an http.Header.
+func (w *fastResponseWriter) Header() http.Header {
+	if w.header == nil {
+		w.header = make(http.Header)
+		if w.fctx == nil {
+			return w.header
+		}
+		visitResponseHeaders(&w.fctx.Response.Header, func(k, v string) { w.header.Add(k, v) })
+	}
+	return w.header
+}
+
+// WriteHeader copies the header map to the fasthttp response and sets the
+// status code. Like net/http, calls after the first have no effect.
+func (w *fastResponseWriter) WriteHeader(code int) {
+	if w.wrote || w.fctx == nil {
+		return
+	}
+	w.wrote = true
+	w.flushHeader()
+	w.fctx.SetStatusCode(code)
+}
+
+// Write appends p to the fasthttp response body, writing a 200 header first
+// if none was written. As in net/http, the Content-Type is sniffed from the
+// first write when none was set.
+func (w *fastResponseWriter) Write(p []byte) (int, error) {
+	if w.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if w.fctx == nil {
+		return 0, errDetachedWriter
+	}
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.stream != nil {
+		return w.stream.write(w.fctx, p)
+	}
+	if len(p) > 0 && len(w.fctx.Response.Body()) == 0 && !hasContentType(&w.fctx.Response.Header) {
+		w.fctx.Response.Header.SetContentType(http.DetectContentType(p))
+	}
+	return w.fctx.Write(p)
+}
+
+// Stream makes w write the response to the connection as it is flushed,
+// for streaming responses such as Ctx.SSE. fasthttp only writes a response
+// once the handler returns (a body stream writer set with
+// SetBodyStreamWriter also runs after that, when the request context is
+// cancelled and the DefaultContext reused), so the status line, headers and
+// body are written by w itself and fasthttp is told not to respond. The
+// connection is closed when the request finishes.
+//
+// A RequestCtx that was not served from a connection, such as one prepared
+// with Init, has nothing to stream to: the response is then sent as usual
+// when the handler returns.
+func (w *fastResponseWriter) Stream() {
+	if w.stream != nil || w.hijacked || w.fctx == nil {
+		return
+	}
+	if conn := w.takeOver(); conn != nil {
+		w.stream = &fastStream{conn: conn, bw: bufio.NewWriter(conn)}
+	}
+}
+
+// Hijack lets the caller take over the connection, as http.Hijacker does on
+// net/http, e.g. for a WebSocket upgrade. fasthttp writes no response for
+// the request and closes the connection once the request finishes, so the
+// connection cannot outlive the handler. Closing the returned connection
+// only makes its reads and writes fail, since fasthttp still uses the
+// connection until then.
+func (w *fastResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.hijacked || w.stream != nil {
+		return nil, nil, http.ErrHijacked
+	}
+	conn := w.takeOver()
+	if conn == nil {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.hijacked = true
+	w.wrote = true
+	hc := &hijackedConn{Conn: conn}
+	return hc, bufio.NewReadWriter(bufio.NewReader(hc), bufio.NewWriter(hc)), nil
+}
+
+// takeOver tells fasthttp not to respond to the request and returns its
+// connection, or nil if the RequestCtx was not served from one (e.g. it was
+// prepared with Init). Deadlines the server set on the connection for
+// earlier requests are cleared.
+func (w *fastResponseWriter) takeOver() net.Conn {
+	if w.fctx == nil {
+		return nil
+	}
+	if w.fctx.ConnRequestNum() == 0 {
+		return nil
+	}
+	conn := w.fctx.Conn()
+	_ = conn.SetDeadline(time.Time{})
+	w.fctx.HijackSetNoResponse(true)
+	w.fctx.Hijack(func(net.Conn) {})
+	return conn
+}
+
+// hijackedConn is the connection returned by fastResponseWriter.Hijack.
+// fasthttp sets deadlines on the connection after the handler returns and
+// fails, or panics, if it is closed, so Close sets a past deadline instead:
+// pending and later reads and writes fail with net.ErrClosed and fasthttp
+// closes the connection afterwards.
+type hijackedConn struct {
+	net.Conn
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *hijackedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	return n, c.err(err)
+}
+
+func (c *hijackedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	return n, c.err(err)
+}
+
+func (c *hijackedConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	c.closed = true
+	return c.Conn.SetDeadline(time.Unix(1, 0))
+}
+
+func (c *hijackedConn) SetDeadline(t time.Time) error {
+	return c.setDeadline(t, c.Conn.SetDeadline)
+}
+
+func (c *hijackedConn) SetReadDeadline(t time.Time) error {
+	return c.setDeadline(t, c.Conn.SetReadDeadline)
+}
+
+func (c *hijackedConn) SetWriteDeadline(t time.Time) error {
+	return c.setDeadline(t, c.Conn.SetWriteDeadline)
+}
+
+// setDeadline calls set with t unless the connection is closed, which would
+// lift the deadline set by Close.
+func (c *hijackedConn) setDeadline(t time.Time, set func(time.Time) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return set(t)
+}
+
+// err returns net.ErrClosed for the error of a read or write failing after
+// Close.
+func (c *hijackedConn) err(err error) error {
+	if err == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return err
+}
+
+// Flush sends what was written so far when the response is streamed; it
+// does nothing otherwise.
+func (w *fastResponseWriter) Flush() { _ = w.FlushError() }
+
+// FlushError is Flush returning the write error, for http.ResponseController.
+func (w *fastResponseWriter) FlushError() error {
+	if w.stream == nil {
+		return nil
+	}
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.stream.flush(w.fctx)
+}
+
+// finishStream ends a streamed response.
+func (w *fastResponseWriter) finishStream() {
+	if w.stream != nil {
+		w.stream.close(w.fctx)
+	}
+}
+
+// fastStream writes a streamed fasthttp response to its connection.
+type fastStream struct {
+	conn    net.Conn
+	bw      *bufio.Writer
+	started bool // status line and headers written
+	chunked bool // body sent with chunked transfer encoding
+	err     error
+}
+
+// start writes the status line, the headers and the body written before the
+// response was streamed.
+func (s *fastStream) start(fctx *fasthttp.RequestCtx) {
+	if s.started {
+		return
+	}
+	s.started = true
+	h := &fctx.Response.Header
+	body := fctx.Response.Body()
+	if fctx.Request.Header.IsHTTP11() && !fctx.IsHead() {
+		s.chunked = true
+		h.SetContentLength(-1)
+	} else {
+		h.Del("Transfer-Encoding")
+		h.Del("Content-Length")
+	}
+	h.SetConnectionClose()
+	_, s.err = s.bw.Write(h.Header())
+	if len(body) > 0 {
+		s.write(fctx, body)
+		fctx.Response.ResetBody()
+	}
+}
+
+// write writes p as body, in a chunk when chunked.
+func (s *fastStream) write(fctx *fasthttp.RequestCtx, p []byte) (int, error) {
+	s.start(fctx)
+	if s.err != nil {
+		return 0, s.err
+	}
+	if fctx.IsHead() || len(p) == 0 {
+		return len(p), nil
+	}
+	if s.chunked {
+		s.bw.WriteString(strconv.FormatInt(int64(len(p)), 16))
+		s.bw.WriteString("\r\n")
+	}
+	n, err := s.bw.Write(p)
+	if err == nil && s.chunked {
+		_, err = s.bw.WriteString("\r\n")
+	}
+	s.err = err
+	return n, err
+}
+
+// flush sends the buffered data to the connection.
+func (s *fastStream) flush(fctx *fasthttp.RequestCtx) error {
+	s.start(fctx)
+	if s.err == nil {
+		s.err = s.bw.Flush()
+	}
+	return s.err
+}
+
+// close ends the body and flushes it.
+func (s *fastStream) close(fctx *fasthttp.RequestCtx) {
+	s.start(fctx)
+	if s.err == nil && s.chunked {
+		_, s.err = s.bw.WriteString("0\r\n\r\n")
+	}
+	_ = s.flush(fctx)
+}
+
+// flushHeader replaces the fasthttp response headers with the header map, if
+// it was built.
+func (w *fastResponseWriter) flushHeader() {
+	if w.header == nil {
+		return
+	}
+	rh := &w.fctx.Response.Header
+	var keys []string
+	visitResponseHeaders(rh, func(k, _ string) { keys = append(keys, k) })
+	for _, k := range keys {
+		rh.Del(k)
+	}
+	for k, vs := range w.header {
+		for _, v := range vs {
+			rh.Add(k, v)
+		}
+	}
+}
+
+// visitResponseHeaders calls f for every header set on h, without the default
+// Content-Type fasthttp reports when none was set.
+func visitResponseHeaders(h *fasthttp.ResponseHeader, f func(k, v string)) {
+	h.SetNoDefaultContentType(true)
+	h.VisitAll(func(k, v []byte) { f(string(k), string(v)) })
+	h.SetNoDefaultContentType(false)
+}
+
+// hasContentType reports whether a Content-Type was set on h.
+func hasContentType(h *fasthttp.ResponseHeader) bool {
+	h.SetNoDefaultContentType(true)
+	ok := len(h.ContentType()) > 0
+	h.SetNoDefaultContentType(false)
+	return ok
+}
+
+// newHTTPRequest converts the fasthttp request into an *http.Request. Header
+// values are copied; the body reads from the fasthttp request body until a
+// Clone detaches it (see DefaultContext.detach).
+func newHTTPRequest(fctx *fasthttp.RequestCtx) *http.Request {
+	body := fctx.PostBody()
+	r := &http.Request{
+		Method:        string(fctx.Method()),
+		Proto:         string(fctx.Request.Header.Protocol()),
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          fastBody{bytes.NewReader(body)},
+		ContentLength: int64(len(body)),
+		Host:          string(fctx.Host()),
+		RemoteAddr:    fctx.RemoteAddr().String(),
+		RequestURI:    string(fctx.RequestURI()),
+		TLS:           fctx.TLSConnectionState(),
+	}
+	if fctx.Request.Header.ContentLength() < 0 {
+		r.ContentLength = -1 // chunked, as on net/http
+	}
+	if r.Proto == "HTTP/2" {
+		r.ProtoMajor, r.ProtoMinor = 2, 0
+	}
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = &url.URL{Path: string(fctx.Path()), RawQuery: string(fctx.QueryArgs().QueryString())}
+	}
+	r.URL = u
+	fctx.Request.Header.VisitAll(func(k, v []byte) {
+		key := string(k)
+		if key == "Transfer-Encoding" {
+			r.TransferEncoding = append(r.TransferEncoding, string(v))
+			return
+		}
+		r.Header.Add(key, string(v))
+	})
+	return r
+}
+
+// fastBody is the Body of requests converted by newHTTPRequest.
+type fastBody struct{ *bytes.Reader }
+
+func (fastBody) Close() error { return nil }
+
+// detach makes c, a Clone of a fasthttp context, independent of the fasthttp
+// request, which is reused as soon as the request finishes. c then serves
+// the request like a net/http context, from a copy of the converted request
+// with its own copy of the unread body, and from copies of the params. Its
+// response writer is detached too, so writes through a clone that was not
+// given a writer with SetResponseWriter fail instead of reaching the
+// response of whichever request reuses the original context.
+func (c *DefaultContext) detach() {
+	r := c.Request()
+	r = r.Clone(r.Context())
+	if b, ok := r.Body.(fastBody); ok {
+		rest := make([]byte, b.Len())
+		_, _ = b.ReadAt(rest, b.Size()-int64(b.Len()))
+		r.Body = fastBody{bytes.NewReader(rest)}
+	}
+	c.r = r
+	if c.paramSlice != nil {
+		c.paramSlice = append(router.Params(nil), c.paramSlice...)
+		for i := range c.paramSlice {
+			c.paramSlice[i].Value = strings.Clone(c.paramSlice[i].Value)
+		}
+	}
+	for i := uint8(0); i < c.paramCount; i++ {
+		c.params[i].Value = strings.Clone(c.params[i].Value)
+	}
+	c.fctx = nil
+	c.fw.reset(nil)
+	c.w = &c.fw
+	c.rc = nil
+	c.ctx = nil
+	c.queryCache = nil
+	c.setHasQueryCache(false)
+	c.setFastHTTP(false)
+}
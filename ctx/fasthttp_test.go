@@ -0,0 +1,355 @@
+package ctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// ctxServer serves every request with a fresh DefaultContext on either
+// transport, the way the app does.
+type ctxServer func(c *DefaultContext) error
+
+func (f ctxServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var c DefaultContext
+	c.Reset(w, r, httprouter.Params{{Key: "id", Value: "42"}}, "/items/:id")
+	if err := f(&c); err != nil {
+		_ = c.String(http.StatusInternalServerError, err.Error())
+	}
+	c.Finish()
+}
+
+func (f ctxServer) ServeFastHTTP(fctx *fasthttp.RequestCtx) {
+	var c DefaultContext
+	c.ResetFastHTTP(fctx, httprouter.Params{{Key: "id", Value: "42"}}, "/items/:id")
+	if err := f(&c); err != nil {
+		_ = c.String(http.StatusInternalServerError, err.Error())
+	}
+	c.Finish()
+}
+
+// eachTransport calls fn once per transport as a subtest, for the tests that
+// drive a DefaultContext directly instead of serving a request. They reset
+// contexts with tr.reset and call tr.flush before inspecting the response.
+func eachTransport(t *testing.T, fn func(t *testing.T, tr *transport)) {
+	t.Helper()
+	for _, name := range transporttest.Transports {
+		t.Run(name, func(t *testing.T) { fn(t, &transport{fast: name == "fasthttp"}) })
+	}
+}
+
+// transport prepares contexts the way the app does on one transport.
+type transport struct {
+	fast  bool
+	resps map[*DefaultContext]fastResponse
+}
+
+// fastResponse is where flush copies the response of a fasthttp request.
+type fastResponse struct {
+	fctx *fasthttp.RequestCtx
+	w    http.ResponseWriter
+}
+
+// reset is c.Reset on net/http. On fasthttp r is copied into a
+// fasthttp.RequestCtx and c is reset with ResetFastHTTP; the response
+// reaches w when flush is called.
+func (tr *transport) reset(c *DefaultContext, w http.ResponseWriter, r *http.Request, ps httprouter.Params, route string, appLogger ...interface{ Logger() *slog.Logger }) {
+	if !tr.fast {
+		c.Reset(w, r, ps, route, appLogger...)
+		return
+	}
+	fctx := transporttest.NewRequestCtx(r)
+	c.ResetFastHTTP(fctx, ps, route, appLogger...)
+	if tr.resps == nil {
+		tr.resps = make(map[*DefaultContext]fastResponse)
+	}
+	tr.resps[c] = fastResponse{fctx: fctx, w: w}
+}
+
+// flush finishes the request of c and, on fasthttp, copies its response to
+// the writer passed to reset.
+func (tr *transport) flush(c *DefaultContext) {
+	c.Finish()
+	if resp, ok := tr.resps[c]; ok {
+		delete(tr.resps, c)
+		transporttest.WriteResponse(resp.w, &resp.fctx.Response)
+	}
+}
+
+type (
+	ctxKey   struct{}
+	otherKey struct{}
+)
+
+func TestCtxTransportMatrix(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		handler func(c *DefaultContext) error
+		check   func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "request data",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/items/42?sort=asc&page=2", strings.NewReader("payload"))
+				r.Header.Set("X-Trace", "abc")
+				r.Header.Add("Accept", "text/html")
+				r.Header.Add("Accept", "application/json")
+				r.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+				return r
+			},
+			handler: func(c *DefaultContext) error {
+				r := c.Request()
+				cookie, err := r.Cookie("sid")
+				if err != nil {
+					return err
+				}
+				return c.JSON(map[string]any{
+					"method": c.Method(), "path": c.Path(), "route": c.Route(), "id": c.ParamInt("id"),
+					"sort": c.Query("sort"), "page": c.QueryInt("page"), "trace": r.Header.Get("X-Trace"),
+					"accept": r.Header.Values("Accept"), "cookie": cookie.Value, "len": r.ContentLength,
+					"rawQuery": r.URL.RawQuery, "remote": r.RemoteAddr != "",
+				})
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"method":"POST","path":"/items/42","route":"/items/:id","id":42,
+					"sort":"asc","page":2,"trace":"abc","accept":["text/html","application/json"],
+					"cookie":"s1","len":7,"rawQuery":"sort=asc&page=2","remote":true}`, rec.Body.String())
+			},
+		},
+		{
+			name: "context values",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				c.Set(ctxKey{}, "v1")
+				ctx := context.WithValue(c.Context(), otherKey{}, "v2")
+				c.SetRequest(c.Request().WithContext(ctx))
+				return c.String(http.StatusOK, c.Get(ctxKey{}).(string)+","+c.Get(otherKey{}).(string)+","+c.Get("missing", "def").(string))
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "v1,v2,def", rec.Body.String())
+			},
+		},
+		{
+			name: "header helpers",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				c.Header("X-One", "1")
+				c.AddHeader("X-Multi", "a")
+				c.AddHeader("X-Multi", "b")
+				c.SetHeaders(map[string]string{"X-Two": "2"})
+				c.SetMaxAge(3600)
+				c.SetSecurityHeaders()
+				c.SetContentTypeJSON()
+				_, err := c.Send(http.StatusAccepted, "text/plain", []byte(`{"ok":true}`))
+				return err
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusAccepted, rec.Code)
+				assert.Equal(t, "1", rec.Header().Get("X-One"))
+				assert.Equal(t, []string{"a", "b"}, rec.Header().Values("X-Multi"))
+				assert.Equal(t, "2", rec.Header().Get("X-Two"))
+				assert.Equal(t, "max-age=3600", rec.Header().Get("Cache-Control"))
+				assert.Equal(t, "DENY", rec.Header().Get("X-Frame-Options"))
+				assert.Equal(t, headerContentTypeJSON, rec.Header().Get("Content-Type"))
+				assert.Equal(t, `{"ok":true}`, rec.Body.String())
+			},
+		},
+		{
+			name: "response writer header map and helpers agree",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				c.Header("X-Before", "1")
+				h := c.ResponseWriter().Header()
+				if h.Get("X-Before") != "1" {
+					return assert.AnError
+				}
+				h.Set("X-Map", "2")
+				c.Header("X-After", "3")
+				return c.Status(http.StatusCreated).JSON(map[string]int{"n": 1})
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, rec.Code)
+				assert.Equal(t, "1", rec.Header().Get("X-Before"))
+				assert.Equal(t, "2", rec.Header().Get("X-Map"))
+				assert.Equal(t, "3", rec.Header().Get("X-After"))
+				assert.Equal(t, headerContentTypeJSON, rec.Header().Get("Content-Type"))
+				assert.Equal(t, `{"n":1}`, rec.Body.String())
+			},
+		},
+		{
+			name: "headers set through the map without a body",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				c.ResponseWriter().Header().Set("X-Only", "yes")
+				c.ResponseWriter().WriteHeader(http.StatusNoContent)
+				return nil
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, rec.Code)
+				assert.Equal(t, "yes", rec.Header().Get("X-Only"))
+			},
+		},
+		{
+			name: "wrapped response writer",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				c.SetResponseWriter(&upperWriter{ResponseWriter: c.ResponseWriter()})
+				c.Header("X-Wrapped", "1")
+				return c.String(http.StatusOK, "hello")
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "1", rec.Header().Get("X-Wrapped"))
+				assert.Equal(t, "HELLO", rec.Body.String())
+			},
+		},
+		{
+			name: "repeated writes append",
+			req:  func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			handler: func(c *DefaultContext) error {
+				_ = c.String(http.StatusOK, "a")
+				_, err := c.Send(http.StatusTeapot, "", []byte("b"))
+				return err
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "ab", rec.Body.String())
+			},
+		},
+		{
+			name: "bind json",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Ada"}`))
+				r.Header.Set("Content-Type", "application/json")
+				return r
+			},
+			handler: func(c *DefaultContext) error {
+				var in struct {
+					Name string `json:"name"`
+				}
+				if err := c.BindJSON(&in); err != nil {
+					return err
+				}
+				return c.String(http.StatusOK, in.Name)
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "Ada", rec.Body.String())
+			},
+		},
+		{
+			name: "bind any",
+			req: func() *http.Request {
+				form := url.Values{"name": {"Ada"}}
+				r := httptest.NewRequest(http.MethodPost, "/items/42?active=true", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			},
+			handler: func(c *DefaultContext) error {
+				var in struct {
+					ID     int    `json:"id"`
+					Name   string `json:"name"`
+					Active bool   `json:"active"`
+				}
+				if err := c.BindAny(&in, BindJSONOptions{WeaklyTypedInput: true}); err != nil {
+					return err
+				}
+				return c.JSON(in)
+			},
+			check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"id":42,"name":"Ada","active":true}`, rec.Body.String())
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transporttest.Run(t, ctxServer(tt.handler), func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(tt.req())
+				require.NotEqual(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+				tt.check(t, rec)
+			})
+		})
+	}
+}
+
+// upperWriter upper-cases the body, standing in for middleware that wraps the
+// response writer.
+type upperWriter struct{ http.ResponseWriter }
+
+func (w *upperWriter) Write(b []byte) (int, error) {
+	return w.ResponseWriter.Write([]byte(strings.ToUpper(string(b))))
+}
+
+func TestFastHTTPHijack(t *testing.T) {
+	// A hijacked connection closed by the handler is still usable by the
+	// server, which sets its write deadline after the handler returns
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	errs := make(chan error, 1)
+	srv := &fasthttp.Server{WriteTimeout: time.Second, Handler: func(fctx *fasthttp.RequestCtx) {
+		var c DefaultContext
+		c.ResetFastHTTP(fctx, nil, "/")
+		defer c.Finish()
+		conn, brw, err := c.Hijack()
+		if err != nil {
+			errs <- err
+			return
+		}
+		_, _ = brw.WriteString("hijacked\n")
+		_ = brw.Flush()
+		_, _, again := c.Hijack()
+		_, werr := c.ResponseWriter().Write([]byte("x"))
+		_ = conn.Close()
+		_, rerr := conn.Read(make([]byte, 1))
+		errs <- errors.Join(
+			expectErr(again, http.ErrHijacked),
+			expectErr(werr, http.ErrHijacked),
+			expectErr(rerr, net.ErrClosed),
+			expectErr(conn.Close(), net.ErrClosed),
+		)
+	}}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	require.NoError(t, err)
+	b, err := io.ReadAll(conn) // the server closes the connection
+	require.NoError(t, err)
+	assert.Equal(t, "hijacked\n", string(b), "no response is written")
+	require.NoError(t, <-errs)
+}
+
+func expectErr(err, target error) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("got %v, want %v", err, target)
+	}
+	return nil
+}
+
+func TestFastHTTPHijackWithoutConnection(t *testing.T) {
+	var fctx fasthttp.RequestCtx
+	fctx.Init(&fasthttp.Request{}, nil, nil)
+	var c DefaultContext
+	c.ResetFastHTTP(&fctx, nil, "/")
+	_, _, err := c.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+	assert.False(t, c.WroteHeader())
+}
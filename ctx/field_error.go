@@ -106,7 +106,8 @@ func (e fieldError) Message() string { return e.message }
 func (e fieldError) Error() string   { return fmt.Sprintf("field %s: %s", e.field, e.message) }
 
 type fieldErrorsMap struct {
-	m map[string]string
+	m     map[string]string
+	rules map[string]fieldSentinel // validation rule that failed, by field (see validate.go)
 }
 
 func (f fieldErrorsMap) Error() string {
@@ -130,6 +131,11 @@ func (f fieldErrorsMap) Is(target error) bool {
 	if !ok {
 		return false
 	}
+	for _, r := range f.rules {
+		if r == s || s == ErrValidation.(fieldSentinel) {
+			return true
+		}
+	}
 	for _, msg := range f.m {
 		switch s {
 		case ErrFieldTypeExpected.(fieldSentinel):
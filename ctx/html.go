@@ -0,0 +1,44 @@
+package ctx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// Renderer renders named templates for Ctx.HTML. Set one on the app with
+// SetRenderer; app.NewHTMLRenderer provides an html/template implementation.
+type Renderer interface {
+	// Render writes the template name executed with data to w. c is the
+	// request being served, for request-dependent template functions.
+	Render(w io.Writer, name string, data any, c Ctx) error
+}
+
+// ErrNoRenderer is returned by HTML when the app has no Renderer.
+var ErrNoRenderer = errors.New("ctx: no renderer set, see SetRenderer")
+
+// HTML renders the template name with data using the app's Renderer and
+// writes it with the given status and Content-Type text/html. The template
+// is rendered into a buffer first, so a template error writes nothing and is
+// returned for the error handler.
+//
+// Example:
+//
+//	return c.HTML(http.StatusOK, "users/show", map[string]any{"User": u})
+func (c *DefaultContext) HTML(status int, name string, data any) error {
+	var r Renderer
+	if p, ok := c.appLogger.(interface{ Renderer() Renderer }); ok {
+		r = p.Renderer()
+	}
+	if r == nil {
+		return ErrNoRenderer
+	}
+	buf := jsonBufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer jsonBufPool.Put(buf)
+	if err := r.Render(buf, name, data, c); err != nil {
+		return err
+	}
+	_, err := c.Send(status, headerContentTypeHTML, buf.Bytes())
+	return err
+}
@@ -0,0 +1,60 @@
+package ctx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoRenderer struct{}
+
+func (echoRenderer) Render(w io.Writer, name string, data any, c Ctx) error {
+	if name == "broken" {
+		_, _ = io.WriteString(w, "partial output")
+		return errors.New("template failed")
+	}
+	_, err := fmt.Fprintf(w, "<%s>%v %s</%s>", name, data, c.Path(), name)
+	return err
+}
+
+// rendererApp stands in for an app with a Renderer.
+type rendererApp struct {
+	validatorApp
+	r Renderer
+}
+
+func (a rendererApp) Renderer() Renderer { return a.r }
+
+func TestHTML(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		html := func(app rendererApp, name string) (*httptest.ResponseRecorder, error) {
+			rec := httptest.NewRecorder()
+			var c DefaultContext
+			tr.reset(&c, rec, httptest.NewRequest(http.MethodGet, "/page", nil), nil, "/page", app)
+			err := c.HTML(http.StatusTeapot, name, 42)
+			tr.flush(&c)
+			return rec, err
+		}
+
+		rec, err := html(rendererApp{r: echoRenderer{}}, "b")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "<b>42 /page</b>", rec.Body.String())
+
+		// Failed renders write nothing
+		rec, err = html(rendererApp{r: echoRenderer{}}, "broken")
+		assert.EqualError(t, err, "template failed")
+		assert.Empty(t, rec.Body.String())
+		assert.False(t, rec.Flushed)
+
+		_, err = html(rendererApp{}, "b")
+		assert.ErrorIs(t, err, ErrNoRenderer)
+	})
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package ctx
+
+import "net"
+
+// canWaitPeerClosed reports false: closed connections are not detected on
+// this platform.
+func canWaitPeerClosed(conn net.Conn) bool { return false }
+
+// waitPeerClosed is not supported on this platform.
+func waitPeerClosed(conn net.Conn) bool { return false }
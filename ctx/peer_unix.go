@@ -0,0 +1,39 @@
+//go:build unix
+
+package ctx
+
+import (
+	"net"
+	"syscall"
+)
+
+// canWaitPeerClosed reports whether waitPeerClosed can watch conn.
+func canWaitPeerClosed(conn net.Conn) bool {
+	_, ok := conn.(syscall.Conn)
+	return ok
+}
+
+// waitPeerClosed blocks until the peer closes conn and reports true, or until
+// data arrives, the read deadline passes or conn fails and reports false. It
+// peeks at the socket, so nothing is consumed from the connection.
+func waitPeerClosed(conn net.Conn) bool {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var closed bool
+	err = raw.Read(func(fd uintptr) bool {
+		var b [1]byte
+		n, _, err := syscall.Recvfrom(int(fd), b[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			return false // wait until readable
+		}
+		closed = (n == 0 && err == nil) || err == syscall.ECONNRESET
+		return true
+	})
+	return err == nil && closed
+}
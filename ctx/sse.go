@@ -0,0 +1,288 @@
+package ctx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is a server-sent event written by SSEStream.Send.
+type Event struct {
+	// ID sets the event ID, which the browser sends back in the Last-Event-ID
+	// header when it reconnects.
+	ID string
+	// Event is the event type; clients receive events without one as
+	// "message".
+	Event string
+	// Data is the payload: a string or []byte is sent as is, one data line per
+	// line, and any other value is encoded as JSON.
+	Data any
+	// Retry asks the client to wait this long before reconnecting. Zero
+	// leaves the client's delay unchanged.
+	Retry time.Duration
+}
+
+// SSEStream writes server-sent events to the client of Ctx.SSE. Its methods
+// are safe for concurrent use.
+type SSEStream interface {
+	// Send writes e and flushes it to the client. Once the request context is
+	// cancelled it returns the context's error without writing.
+	Send(e Event) error
+	// Comment writes a comment line, which clients ignore.
+	Comment(text string) error
+	// LastEventID returns the Last-Event-ID header of a reconnecting client,
+	// or "" on the first connection.
+	LastEventID() string
+	// Context returns the request context, cancelled when the client goes
+	// away, the server shuts down or a Timeout middleware expires.
+	Context() context.Context
+}
+
+// SSEOptions configures Ctx.SSE.
+type SSEOptions struct {
+	// Heartbeat is the interval of the comments sent to keep proxies and
+	// clients from closing an idle stream. Default 15s; a negative value
+	// disables them.
+	Heartbeat time.Duration
+}
+
+// defaultSSEHeartbeat is the heartbeat interval used when none is set.
+const defaultSSEHeartbeat = 15 * time.Second
+
+// ErrSSEField is returned by Send when the ID or Event of an Event contains
+// a line break.
+var ErrSSEField = errors.New("ctx: SSE event field contains a line break")
+
+// Streamer is implemented by response writers that hold back what is written
+// to them, such as the Buffer and Timeout middleware. Streaming responses
+// (Ctx.SSE) call Stream through StreamWriter before writing: afterwards
+// writes must reach the client when the writer is flushed. A Streamer that
+// wraps another writer passes the call on with StreamWriter.
+type Streamer interface {
+	Stream()
+}
+
+// StreamWriter calls Stream on w, or on the first Streamer w wraps, following
+// the Unwrap() http.ResponseWriter methods used by http.ResponseController.
+func StreamWriter(w http.ResponseWriter) {
+	for w != nil {
+		if s, ok := w.(Streamer); ok {
+			s.Stream()
+			return
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// SSE responds with a stream of server-sent events (text/event-stream) and
+// calls fn to write them. Every event is flushed to the client as it is sent,
+// and a comment is sent every SSEOptions.Heartbeat. The response ends when fn
+// returns; fn should also return once stream.Context() is cancelled, when
+// Send fails with the context's error. That error is not returned by SSE.
+//
+// Writers that buffer the response are told to pass it through (see
+// Streamer). On fasthttp, which sends responses after the handler returns,
+// the stream is written to the connection itself and the connection is
+// closed when it ends.
+//
+// Example:
+//
+//	a.GET("/events", func(c app.Ctx) error {
+//		return c.SSE(func(s ctx.SSEStream) error {
+//			for msg := range feed.Since(s.LastEventID()) {
+//				if err := s.Send(ctx.Event{ID: msg.ID, Event: "message", Data: msg}); err != nil {
+//					return err
+//				}
+//			}
+//			return nil
+//		})
+//	})
+func (c *DefaultContext) SSE(fn func(stream SSEStream) error, opts ...SSEOptions) error {
+	heartbeat := defaultSSEHeartbeat
+	if len(opts) > 0 && opts[0].Heartbeat != 0 {
+		heartbeat = opts[0].Heartbeat
+	}
+
+	c.setHeader(headerContentType, []string{headerContentTypeSSE})
+	c.setHeader(headerCacheControl, []string{headerValueNoCache})
+	c.setHeader("X-Accel-Buffering", []string{"no"}) // nginx
+	StreamWriter(c.w)
+	c.status = http.StatusOK
+	c.w.WriteHeader(http.StatusOK)
+	c.setWroteHeader(true)
+
+	s := &sseStream{
+		w:      c.w,
+		rc:     http.NewResponseController(c.w),
+		ctx:    c.Context(),
+		lastID: c.requestHeader("Last-Event-ID"),
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if heartbeat > 0 {
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.heartbeat(heartbeat, stop)
+		}()
+		defer func() {
+			close(stop)
+			wg.Wait()
+		}()
+	}
+	defer func() { c.wroteBytes += s.written }()
+
+	err := fn(s)
+	if err != nil && s.ctx.Err() != nil && errors.Is(err, s.ctx.Err()) {
+		return nil
+	}
+	return err
+}
+
+// sseStream is the SSEStream of a response.
+type sseStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	ctx     context.Context
+	lastID  string
+	buf     []byte
+	written int
+}
+
+// Send encodes e and writes it.
+func (s *sseStream) Send(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := appendEvent(s.buf[:0], e)
+	if err != nil {
+		return err
+	}
+	s.buf = b
+	return s.write(b)
+}
+
+// Comment writes text as comment lines.
+func (s *sseStream) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := s.buf[:0]
+	for _, line := range splitLines(text) {
+		b = append(b, ": "...)
+		b = append(b, line...)
+		b = append(b, '\n')
+	}
+	s.buf = append(b, '\n')
+	return s.write(s.buf)
+}
+
+func (s *sseStream) LastEventID() string { return s.lastID }
+
+func (s *sseStream) Context() context.Context { return s.ctx }
+
+// write writes b and flushes it, unless the context is cancelled. s.mu must
+// be held.
+func (s *sseStream) write(b []byte) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	n, err := s.w.Write(b)
+	s.written += n
+	if err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// flush flushes the writer, if it can be.
+func (s *sseStream) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// heartbeat sends a comment every interval until stop is closed or the
+// context is cancelled.
+func (s *sseStream) heartbeat(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.mu.Lock()
+			_ = s.write(sseHeartbeat)
+			s.mu.Unlock()
+		case <-stop:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// sseHeartbeat is the comment sent by heartbeats.
+var sseHeartbeat = []byte(": ping\n\n")
+
+// appendEvent appends the wire format of e to dst.
+func appendEvent(dst []byte, e Event) ([]byte, error) {
+	if strings.ContainsAny(e.ID, "\r\n") || strings.ContainsAny(e.Event, "\r\n") {
+		return dst, ErrSSEField
+	}
+	if e.ID != "" {
+		dst = append(dst, "id: "...)
+		dst = append(dst, e.ID...)
+		dst = append(dst, '\n')
+	}
+	if e.Event != "" {
+		dst = append(dst, "event: "...)
+		dst = append(dst, e.Event...)
+		dst = append(dst, '\n')
+	}
+	if e.Retry > 0 {
+		dst = append(dst, "retry: "...)
+		dst = strconv.AppendInt(dst, e.Retry.Milliseconds(), 10)
+		dst = append(dst, '\n')
+	}
+	var data string
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := jsoniterEscape.Marshal(d)
+		if err != nil {
+			return dst, err
+		}
+		data = string(b)
+	}
+	if e.Data != nil {
+		for _, line := range splitLines(data) {
+			dst = append(dst, "data: "...)
+			dst = append(dst, line...)
+			dst = append(dst, '\n')
+		}
+	}
+	return append(dst, '\n'), nil
+}
+
+// splitLines splits s at CRLF, LF and CR line breaks, the line endings of
+// the event stream format.
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,176 @@
+package ctx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestAppendEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Event
+		want string
+	}{
+		{"data", Event{Data: "hello"}, "data: hello\n\n"},
+		{"all fields", Event{ID: "7", Event: "update", Data: "x", Retry: 1500 * time.Millisecond}, "id: 7\nevent: update\nretry: 1500\ndata: x\n\n"},
+		{"multiline", Event{Data: "a\nb\r\nc\rd"}, "data: a\ndata: b\ndata: c\ndata: d\n\n"},
+		{"bytes", Event{Data: []byte("raw")}, "data: raw\n\n"},
+		{"json", Event{Data: map[string]int{"n": 1}}, "data: {\"n\":1}\n\n"},
+		{"empty data", Event{Event: "ping", Data: ""}, "event: ping\ndata: \n\n"},
+		{"no data", Event{ID: "9"}, "id: 9\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := appendEvent(nil, tt.e)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(b))
+		})
+	}
+
+	_, err := appendEvent(nil, Event{ID: "1\n", Data: "x"})
+	assert.ErrorIs(t, err, ErrSSEField)
+	_, err = appendEvent(nil, Event{Event: "a\rb"})
+	assert.ErrorIs(t, err, ErrSSEField)
+	_, err = appendEvent(nil, Event{Data: func() {}})
+	assert.Error(t, err)
+}
+
+// sseServers serves h over both transports with real connections and
+// returns the URL of each server.
+func sseServers(t *testing.T, h func(c *DefaultContext)) map[string]string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c DefaultContext
+		c.Reset(w, r, nil, "/")
+		h(&c)
+	}))
+	t.Cleanup(srv.Close)
+	_, addr := serveFast(t, h)
+	return map[string]string{"net/http": srv.URL, "fasthttp": "http://" + addr}
+}
+
+func TestSSEStreamsEvents(t *testing.T) {
+	type result struct {
+		lastID string
+		ctxErr error
+		err    error
+	}
+	results := make(chan result, 1)
+	read := make(chan struct{})
+	urls := sseServers(t, func(c *DefaultContext) {
+		var res result
+		res.err = c.SSE(func(s SSEStream) error {
+			res.lastID = s.LastEventID()
+			if err := s.Send(Event{ID: "1", Event: "greeting", Data: "hello\nworld"}); err != nil {
+				return err
+			}
+			<-read // the client got the first event before fn returned
+			if err := s.Send(Event{ID: "2", Data: map[string]int{"n": 2}, Retry: time.Second}); err != nil {
+				return err
+			}
+			<-s.Context().Done()
+			res.ctxErr = s.Context().Err()
+			return s.Send(Event{Data: "too late"})
+		}, SSEOptions{Heartbeat: 10 * time.Millisecond})
+		results <- res
+	})
+
+	for _, name := range []string{"net/http", "fasthttp"} {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, urls[name], nil)
+			require.NoError(t, err)
+			req.Header.Set("Last-Event-ID", "41")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+			assert.Contains(t, resp.Header.Get("Cache-Control"), "no-cache")
+			assert.Equal(t, "no", resp.Header.Get("X-Accel-Buffering"))
+
+			br := bufio.NewReader(resp.Body)
+			readEvent := func() string {
+				var sb strings.Builder
+				for {
+					line, err := br.ReadString('\n')
+					require.NoError(t, err)
+					if line == "\n" {
+						if sb.Len() > 0 {
+							return sb.String()
+						}
+						continue
+					}
+					if !strings.HasPrefix(line, ":") { // skip heartbeats
+						sb.WriteString(line)
+					}
+				}
+			}
+			assert.Equal(t, "id: 1\nevent: greeting\ndata: hello\ndata: world\n", readEvent())
+			read <- struct{}{}
+			assert.Equal(t, "id: 2\nretry: 1000\ndata: {\"n\":2}\n", readEvent())
+
+			line, err := br.ReadString('\n')
+			for err == nil && line == "\n" {
+				line, err = br.ReadString('\n')
+			}
+			require.NoError(t, err)
+			assert.Equal(t, ": ping\n", line, "heartbeat")
+
+			require.NoError(t, resp.Body.Close())
+			select {
+			case res := <-results:
+				assert.NoError(t, res.err, "cancellation is not an error")
+				assert.Equal(t, "41", res.lastID)
+				assert.ErrorIs(t, res.ctxErr, context.Canceled)
+			case <-time.After(2 * time.Second):
+				t.Fatal("stream did not end when the client went away")
+			}
+		})
+	}
+}
+
+// net/http only: checks that the recorder is flushed; TestSSEStreamsEvents
+// streams on both transports.
+func TestSSEReturnsHandlerError(t *testing.T) {
+	boom := errors.New("boom")
+	rec := httptest.NewRecorder()
+	var c DefaultContext
+	c.Reset(rec, httptest.NewRequest(http.MethodGet, "/", nil), nil, "/")
+	err := c.SSE(func(s SSEStream) error {
+		if err := s.Comment("a\nb"); err != nil {
+			return err
+		}
+		_ = s.Send(Event{Data: "x"})
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.True(t, c.WroteHeader())
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, ": a\n: b\n\ndata: x\n\n", rec.Body.String())
+	assert.Equal(t, len(rec.Body.String()), c.BytesWritten())
+}
+
+func TestSSEFastHTTPWithoutConnection(t *testing.T) {
+	// A RequestCtx prepared with Init has no connection: events are sent
+	// with the response when the handler returns
+	var req fasthttp.Request
+	req.SetRequestURI("/")
+	var fctx fasthttp.RequestCtx
+	fctx.Init(&req, nil, nil)
+	var c DefaultContext
+	c.ResetFastHTTP(&fctx, nil, "/")
+	require.NoError(t, c.SSE(func(s SSEStream) error {
+		return s.Send(Event{Data: "x"})
+	}))
+	c.Finish()
+	assert.Equal(t, "text/event-stream", string(fctx.Response.Header.ContentType()))
+	assert.Equal(t, "data: x\n\n", string(fctx.Response.Body()))
+}
@@ -0,0 +1,492 @@
+package ctx
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Sentinel errors for validation failures, matched with errors.Is against the
+// FieldErrors returned by the Bind* helpers, like the binding sentinels above.
+//
+// ErrValidation matches any validation failure, including the errors of a
+// custom Validator, so it can be told apart from malformed input:
+//
+//	if errors.Is(err, ctx.ErrValidation) {
+//	    // 422: the input was well-formed but broke a rule
+//	}
+var (
+	// ErrValidation matches any validation failure.
+	ErrValidation error = fieldSentinel("validation failed")
+	// ErrFieldRequired matches fields tagged `validate:"required"` that were missing or zero.
+	ErrFieldRequired error = fieldSentinel("required")
+	// ErrFieldMin matches values, lengths or item counts below `min=N`.
+	ErrFieldMin error = fieldSentinel("min")
+	// ErrFieldMax matches values, lengths or item counts above `max=N`.
+	ErrFieldMax error = fieldSentinel("max")
+	// ErrFieldLen matches lengths or item counts other than `len=N`.
+	ErrFieldLen error = fieldSentinel("len")
+	// ErrFieldOneOf matches values not listed in `oneof=a b c`.
+	ErrFieldOneOf error = fieldSentinel("oneof")
+	// ErrFieldEmail matches values that are not an email address.
+	ErrFieldEmail error = fieldSentinel("email")
+	// ErrFieldURL matches values that are not an absolute URL.
+	ErrFieldURL error = fieldSentinel("url")
+)
+
+// Validator validates a value after it was bound. The Bind* helpers run the
+// app's Validator (see App.SetValidator), or DefaultValidator, after decoding
+// into a struct.
+//
+// Errors of a custom Validator are returned by Bind* so that errors.Is(err,
+// ErrValidation) reports true; return FieldErrors to report individual fields.
+//
+// Example (adapting another validation library):
+//
+//	a.SetValidator(ctx.ValidatorFunc(func(v any) error {
+//		return playground.Struct(v)
+//	}))
+type Validator interface {
+	Validate(v any) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(v any) error
+
+// Validate calls f(v).
+func (f ValidatorFunc) Validate(v any) error { return f(v) }
+
+// DefaultValidator is the Validator used unless the app sets another one. It
+// checks `validate` struct tags with ValidateStruct.
+var DefaultValidator Validator = ValidatorFunc(ValidateStruct)
+
+// ValidateStruct checks the `validate` tags of the struct v (or pointer to
+// struct) and returns the failures as FieldErrors, or nil.
+//
+// Rules are separated by commas and checked in order; a field reports its
+// first failure:
+//   - required: not the zero value (strings, slices and maps: not empty)
+//   - omitempty: skip the other rules when the value is zero
+//   - min=N, max=N: numbers by value, strings by characters, slices and maps
+//     by items
+//   - len=N: exact length of strings, slices and maps
+//   - oneof=a b c: one of the space-separated values
+//   - email: an address such as ada@example.com
+//   - url: an absolute URL such as https://example.com/path
+//
+// Field paths use the `json` names, as binding does, joined with dots; slice
+// items are addressed by index (e.g. "items.0.sku"). Nested structs, pointers
+// to structs and slices of structs are validated recursively; embedded
+// structs without a json name are validated as part of the parent.
+//
+// Rules of other validation libraries are ignored, so structs tagged for
+// them still bind: unknown names are skipped, and so are the rules after
+// `dive`, which apply to the items of a slice or map. A bad argument to one
+// of the rules above is a programming error and is returned as a plain error
+// rather than FieldErrors.
+//
+// Example:
+//
+//	type Item struct {
+//		SKU string `json:"sku" validate:"required"`
+//		Qty int    `json:"qty" validate:"min=1,max=99"`
+//	}
+//	type Order struct {
+//		Email string `json:"email" validate:"required,email"`
+//		Items []Item `json:"items" validate:"min=1"`
+//	}
+//	// {"email":"x","items":[{"qty":0}]} =>
+//	// email: "must be a valid email address", items.0.sku: "required",
+//	// items.0.qty: "must be at least 1"
+func ValidateStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	sr, err := rulesFor(rv.Type())
+	if err != nil {
+		return err
+	}
+	if !sr.deep {
+		return nil
+	}
+	fe := validationFailures{}
+	if err := fe.walkStruct(rv, sr, ""); err != nil {
+		return err
+	}
+	if len(fe) == 0 {
+		return nil
+	}
+	return fe.fieldErrors()
+}
+
+// validate runs the app's Validator, or DefaultValidator, on v.
+func (c *DefaultContext) validate(v any) error {
+	if p, ok := c.appLogger.(interface{ Validator() Validator }); ok {
+		if val := p.Validator(); val != nil {
+			err := val.Validate(v)
+			if err == nil || errors.Is(err, ErrValidation) {
+				return err
+			}
+			return validationError{err: err}
+		}
+	}
+	return DefaultValidator.Validate(v)
+}
+
+// validationError marks an error of a custom Validator as a validation
+// failure.
+type validationError struct{ err error }
+
+func (e validationError) Error() string        { return e.err.Error() }
+func (e validationError) Unwrap() error        { return e.err }
+func (e validationError) Is(target error) bool { return target == ErrValidation }
+
+// rule is a parsed validate tag entry.
+type rule struct {
+	kind fieldSentinel
+	n    float64  // min, max, len
+	opts []string // oneof
+}
+
+// fieldRules holds the rules of one struct field.
+type fieldRules struct {
+	index     int
+	name      string
+	rules     []rule
+	required  bool
+	omitempty bool
+	flatten   bool // embedded struct without a json name
+}
+
+// structRules holds the validation rules of a struct type.
+type structRules struct {
+	fields []fieldRules
+	deep   bool           // the type or a nested type has rules
+	nested []*structRules // rules of the struct types of fields, while parsing
+}
+
+// rulesCache maps struct types to their parsed *structRules.
+var rulesCache sync.Map
+
+// rulesFor returns the parsed rules of struct type t.
+func rulesFor(t reflect.Type) (*structRules, error) {
+	if sr, ok := rulesCache.Load(t); ok {
+		return sr.(*structRules), nil
+	}
+	seen := map[reflect.Type]*structRules{}
+	sr, err := parseRules(t, seen)
+	if err != nil {
+		return nil, err
+	}
+	// A type is deep if a type it contains is, which for recursive types is
+	// only known once the whole graph is parsed
+	for changed := true; changed; {
+		changed = false
+		for _, s := range seen {
+			for _, n := range s.nested {
+				if n.deep && !s.deep {
+					s.deep, changed = true, true
+				}
+			}
+		}
+	}
+	for st, s := range seen {
+		s.nested = nil
+		rulesCache.Store(st, s)
+	}
+	return sr, nil
+}
+
+// parseRules parses the rules of t and of the struct types it contains,
+// without computing deep from the nested types. seen holds the types parsed,
+// so recursive types terminate; rulesFor caches them once all are parsed.
+func parseRules(t reflect.Type, seen map[reflect.Type]*structRules) (*structRules, error) {
+	if sr, ok := rulesCache.Load(t); ok {
+		return sr.(*structRules), nil
+	}
+	if sr, ok := seen[t]; ok {
+		return sr, nil
+	}
+	sr := &structRules{}
+	seen[t] = sr
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// Like encoding/json, promote the fields of unexported embedded structs
+		if !f.IsExported() && !(f.Anonymous && nestedStruct(f.Type) != nil) {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fr := fieldRules{index: i, name: name}
+		if name == "" {
+			fr.name = f.Name
+			fr.flatten = f.Anonymous
+		}
+		if err := fr.parse(f.Tag.Get("validate"), f.Type); err != nil {
+			return nil, fmt.Errorf("ctx: field %s.%s: %w", t.Name(), f.Name, err)
+		}
+		if fr.required || len(fr.rules) > 0 {
+			sr.deep = true
+		}
+		if nt := nestedStruct(f.Type); nt != nil {
+			nested, err := parseRules(nt, seen)
+			if err != nil {
+				return nil, err
+			}
+			sr.nested = append(sr.nested, nested)
+		}
+		sr.fields = append(sr.fields, fr)
+	}
+	return sr, nil
+}
+
+// nestedStruct returns the struct type validated recursively for a field of
+// type t: a struct, pointer to struct or slice/array of (pointers to) structs.
+func nestedStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// parse parses a validate tag for a field of type t. Unknown rules are
+// skipped, see ValidateStruct.
+func (fr *fieldRules) parse(tag string, t reflect.Type) error {
+	if tag == "" {
+		return nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "required":
+			fr.required = true
+		case "omitempty":
+			fr.omitempty = true
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return fmt.Errorf("%s needs a number, got %q", name, arg)
+			}
+			if name == "len" && !hasLen(t) {
+				return fmt.Errorf("len does not apply to %s", t)
+			}
+			fr.rules = append(fr.rules, rule{kind: fieldSentinel(name), n: n})
+		case "oneof":
+			opts := strings.Fields(arg)
+			if len(opts) == 0 {
+				return errors.New("oneof needs values")
+			}
+			fr.rules = append(fr.rules, rule{kind: fieldSentinel(name), opts: opts})
+		case "email", "url":
+			fr.rules = append(fr.rules, rule{kind: fieldSentinel(name)})
+		case "dive":
+			// The remaining rules apply to the items, which this engine
+			// does not address
+			return nil
+		}
+	}
+	return nil
+}
+
+// hasLen reports whether values of t have a length.
+func hasLen(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// validationFailure is the message and rule of a failed field.
+type validationFailure struct {
+	message string
+	rule    fieldSentinel
+}
+
+// validationFailures collects failures by field path.
+type validationFailures map[string]validationFailure
+
+func (fe validationFailures) fieldErrors() FieldErrors {
+	m := make(map[string]string, len(fe))
+	rules := make(map[string]fieldSentinel, len(fe))
+	for field, f := range fe {
+		m[field] = f.message
+		rules[field] = f.rule
+	}
+	return fieldErrorsMap{m: m, rules: rules}
+}
+
+// walkStruct validates the fields of the struct value v at path.
+func (fe validationFailures) walkStruct(v reflect.Value, sr *structRules, path string) error {
+	for i := range sr.fields {
+		fr := &sr.fields[i]
+		fv := v.Field(fr.index)
+		p := path
+		if !fr.flatten {
+			p = joinFieldPath(path, fr.name)
+		}
+
+		zero := isEmptyValue(fv)
+		if zero && fr.required {
+			fe[p] = validationFailure{message: ErrFieldRequired.Error(), rule: ErrFieldRequired.(fieldSentinel)}
+			continue
+		}
+		if zero && fr.omitempty {
+			continue
+		}
+		if msg, kind, failed := checkRules(fv, fr.rules); failed {
+			fe[p] = validationFailure{message: msg, rule: kind}
+			continue
+		}
+		if err := fe.walk(fv, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walk validates nested structs in v (a field value at path).
+func (fe validationFailures) walk(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		sr, err := rulesFor(v.Type())
+		if err != nil {
+			return err
+		}
+		if sr.deep {
+			return fe.walkStruct(v, sr, path)
+		}
+	case reflect.Slice, reflect.Array:
+		if nestedStruct(v.Type()) == nil {
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := fe.walk(v.Index(i), joinFieldPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRules runs rules on v and returns the message and rule of the first
+// failure.
+func checkRules(v reflect.Value, rules []rule) (string, fieldSentinel, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", "", false
+		}
+		v = v.Elem()
+	}
+	for _, r := range rules {
+		if msg, ok := r.check(v); !ok {
+			return msg, r.kind, true
+		}
+	}
+	return "", "", false
+}
+
+// check reports whether v satisfies r, with the failure message if not.
+func (r rule) check(v reflect.Value) (string, bool) {
+	switch r.kind {
+	case "min", "max", "len":
+		n, unit, ok := measure(v)
+		if !ok {
+			return "", true
+		}
+		limit := strconv.FormatFloat(r.n, 'f', -1, 64)
+		switch {
+		case r.kind == "min" && n < r.n:
+			return "must be at least " + limit + unit, false
+		case r.kind == "max" && n > r.n:
+			return "must be at most " + limit + unit, false
+		case r.kind == "len" && n != r.n:
+			return "must be exactly " + limit + unit, false
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, o := range r.opts {
+			if s == o {
+				return "", true
+			}
+		}
+		return "must be one of: " + strings.Join(r.opts, ", "), false
+	case "email":
+		s := v.String()
+		if a, err := mail.ParseAddress(s); v.Kind() != reflect.String || err != nil || a.Address != s {
+			return "must be a valid email address", false
+		}
+	case "url":
+		u, err := url.ParseRequestURI(v.String())
+		if v.Kind() != reflect.String || err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be a valid URL", false
+		}
+	}
+	return "", true
+}
+
+// measure returns the value compared by min, max and len: the number itself,
+// or the length of strings (in characters), slices and maps with its unit.
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " items", true
+	}
+	return 0, "", false
+}
+
+// isEmptyValue reports whether v is zero, or empty for strings, slices and
+// maps.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// joinFieldPath joins a field name to a dotted path.
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
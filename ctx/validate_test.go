@@ -0,0 +1,200 @@
+package ctx
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type vAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"omitempty,len=5"`
+}
+
+type vItem struct {
+	SKU string `json:"sku" validate:"required"`
+	Qty int    `json:"qty" validate:"min=1,max=99"`
+}
+
+type vMeta struct {
+	Source string `json:"source" validate:"oneof=web app"`
+}
+
+type vOrder struct {
+	vMeta
+	Email    string    `json:"email" validate:"required,email"`
+	Name     string    `json:"name" validate:"min=2,max=5"`
+	Website  string    `json:"website" validate:"omitempty,url"`
+	Tags     []string  `json:"tags" validate:"max=2"`
+	Address  vAddress  `json:"address"`
+	Billing  *vAddress `json:"billing"`
+	Items    []vItem   `json:"items" validate:"required"`
+	Priority int       `json:"priority" validate:"oneof=1 2 3"`
+	internal string    `validate:"required"`
+}
+
+func fieldMap(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var fe FieldErrors
+	require.True(t, errors.As(err, &fe), "expected FieldErrors, got %T %v", err, err)
+	out := map[string]string{}
+	for _, e := range fe.All() {
+		out[e.Field()] = e.Message()
+	}
+	return out
+}
+
+func TestValidateStruct(t *testing.T) {
+	valid := vOrder{
+		vMeta: vMeta{Source: "web"}, Email: "ada@example.com", Name: "Ada", Tags: []string{"a"},
+		Address: vAddress{City: "London"}, Items: []vItem{{SKU: "x", Qty: 1}}, Priority: 2,
+	}
+	require.NoError(t, ValidateStruct(&valid))
+	require.NoError(t, ValidateStruct(valid))
+
+	bad := vOrder{
+		vMeta: vMeta{Source: "fax"}, Email: "not-an-email", Name: "Adalovelace", Website: "example.com",
+		Tags: []string{"a", "b", "c"}, Address: vAddress{Zip: "123"}, Billing: &vAddress{},
+		Items: []vItem{{SKU: "x", Qty: 1}, {Qty: 100}}, Priority: 7,
+	}
+	err := ValidateStruct(&bad)
+	assert.Equal(t, map[string]string{
+		"source":       "must be one of: web, app",
+		"email":        "must be a valid email address",
+		"name":         "must be at most 5 characters",
+		"website":      "must be a valid URL",
+		"tags":         "must be at most 2 items",
+		"address.city": "required",
+		"address.zip":  "must be exactly 5 characters",
+		"billing.city": "required",
+		"items.1.sku":  "required",
+		"items.1.qty":  "must be at most 99",
+		"priority":     "must be one of: 1, 2, 3",
+	}, fieldMap(t, err))
+
+	for _, sentinel := range []error{ErrValidation, ErrFieldRequired, ErrFieldMax, ErrFieldLen, ErrFieldOneOf, ErrFieldEmail, ErrFieldURL} {
+		assert.ErrorIs(t, err, sentinel)
+	}
+	assert.NotErrorIs(t, err, ErrFieldMin)
+	assert.NotErrorIs(t, err, ErrFieldUnexpected)
+
+	missing := fieldMap(t, ValidateStruct(&vOrder{}))
+	assert.Equal(t, "required", missing["email"])
+	assert.Equal(t, "required", missing["items"])
+	assert.Equal(t, "must be at least 2 characters", missing["name"])
+}
+
+func TestValidateStructBadTags(t *testing.T) {
+	var badLen struct {
+		N int `validate:"len=3"`
+	}
+	err := ValidateStruct(&badLen)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrValidation)
+
+	var badMin struct {
+		S string `validate:"min=x"`
+	}
+	assert.Error(t, ValidateStruct(&badMin))
+
+	// Types without rules, including recursive ones, are skipped
+	type node struct {
+		Next *node `json:"next"`
+	}
+	assert.NoError(t, ValidateStruct(&node{Next: &node{}}))
+	assert.NoError(t, ValidateStruct(42))
+}
+
+func TestValidateStructSkipsForeignRules(t *testing.T) {
+	// Tags written for go-playground/validator
+	type item struct {
+		ID string `json:"id" validate:"required,uuid"`
+	}
+	type order struct {
+		Qty   int      `json:"qty" validate:"gte=1,min=1"`
+		Tags  []string `json:"tags" validate:"max=2,dive,min=3"`
+		Items []item   `json:"items" validate:"required,dive"`
+	}
+
+	assert.NoError(t, ValidateStruct(&order{Qty: 1, Tags: []string{"a"}, Items: []item{{ID: "x"}}}))
+
+	err := ValidateStruct(&order{Tags: []string{"a", "b", "c"}, Items: []item{{}}})
+	assert.Equal(t, map[string]string{
+		"qty":        "must be at least 1",
+		"tags":       "must be at most 2 items",
+		"items.0.id": "required",
+	}, fieldMap(t, err))
+
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"qty":3,"tags":["go"],"items":[{"id":"1"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		var c DefaultContext
+		tr.reset(&c, httptest.NewRecorder(), req, nil, "/")
+		var in order
+		require.NoError(t, c.BindJSON(&in))
+		assert.Equal(t, 3, in.Qty)
+	})
+}
+
+// Mutually recursive types; only recA has rules of its own.
+type recA struct {
+	B *recB `json:"b"`
+	X int   `json:"x" validate:"min=1"`
+}
+
+type recB struct {
+	A *recA `json:"a"`
+}
+
+func TestValidateStructMutuallyRecursive(t *testing.T) {
+	err := ValidateStruct(&recA{X: 1, B: &recB{A: &recA{X: 0}}})
+	assert.Equal(t, map[string]string{"b.a.x": "must be at least 1"}, fieldMap(t, err))
+
+	// recB was parsed while recA was in progress; it is still deep
+	err = ValidateStruct(&recB{A: &recA{B: &recB{A: &recA{X: 5}}}})
+	assert.Equal(t, map[string]string{"a.x": "must be at least 1"}, fieldMap(t, err))
+	assert.NoError(t, ValidateStruct(&recB{}))
+}
+
+// validatorApp stands in for the app, which the context finds through its
+// logger reference.
+type validatorApp struct{ v Validator }
+
+func (a validatorApp) Logger() *slog.Logger { return slog.Default() }
+func (a validatorApp) Validator() Validator { return a.v }
+
+func TestBindValidates(t *testing.T) {
+	eachTransport(t, func(t *testing.T, tr *transport) {
+		type signup struct {
+			Email string `json:"email" validate:"required,email"`
+			Age   int    `json:"age" validate:"min=18"`
+		}
+		bind := func(body string, app validatorApp, opts ...BindJSONOptions) error {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			var c DefaultContext
+			tr.reset(&c, httptest.NewRecorder(), req, nil, "/", app)
+			var in signup
+			return c.BindJSON(&in, opts...)
+		}
+
+		err := bind(`{"email":"ada@example.com","age":12}`, validatorApp{})
+		assert.Equal(t, map[string]string{"age": "must be at least 18"}, fieldMap(t, err))
+		assert.ErrorIs(t, err, ErrFieldMin)
+
+		assert.NoError(t, bind(`{"age":12}`, validatorApp{}, BindJSONOptions{ErrorUnused: true, SkipValidation: true}))
+
+		// A custom Validator replaces the tag engine; its errors match ErrValidation
+		errTaken := errors.New("email taken")
+		custom := validatorApp{v: ValidatorFunc(func(v any) error { return errTaken })}
+		err = bind(`{"email":"ada@example.com","age":30}`, custom)
+		assert.ErrorIs(t, err, errTaken)
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
@@ -25,11 +25,129 @@ type Middleware = app.Middleware
 // ErrorHandler handles errors returned from handlers. Re-exported from app.ErrorHandler.
 type ErrorHandler = app.ErrorHandler
 
+// Route is a handle to a registered route, used to name it. Re-exported from app.Route.
+type Route = app.Route
+
+// RouteInfo describes a registered route. Re-exported from app.RouteInfo.
+type RouteInfo = app.RouteInfo
+
+// RouteCacheStats is a snapshot of the dynamic route cache counters. Re-exported from app.RouteCacheStats.
+type RouteCacheStats = app.RouteCacheStats
+
+// ServerConfig holds the timeouts for servers started by the app. Re-exported from app.ServerConfig.
+type ServerConfig = app.ServerConfig
+
+// DefaultServerConfig returns the configuration used unless the app overrides it with SetServerConfig,
+// the current value of app.DefaultServerConfig. Change app.DefaultServerConfig to change the default.
+func DefaultServerConfig() ServerConfig { return app.DefaultServerConfig }
+
+// ResponseInfo describes a finished request as passed to OnResponse hooks. Re-exported from app.ResponseInfo.
+type ResponseInfo = app.ResponseInfo
+
+// HTTPError is an error that carries its HTTP status, code, public message and
+// cause. Re-exported from app.HTTPError.
+type HTTPError = app.HTTPError
+
+// NewError returns an HTTPError with the given status, code and public message.
+// Re-exported from app.NewError.
+//
+// Example:
+//
+//	return flash.NewError(http.StatusNotFound, "user_not_found", "User not found")
+func NewError(status int, code string, message ...string) *HTTPError {
+	return app.NewError(status, code, message...)
+}
+
+// MapErrorAs makes the app respond to errors of type T (found with errors.As)
+// with the status and optional JSON body returned by fn. Re-exported from app.MapErrorAs.
+//
+// Example:
+//
+//	flash.MapErrorAs(a, func(e *store.ConflictError) (int, any) {
+//		return http.StatusConflict, map[string]string{"key": e.Key}
+//	})
+func MapErrorAs[T error](a App, fn func(T) (int, any)) { app.MapErrorAs(a, fn) }
+
+// StatusCoder is implemented by Typed results that choose their response
+// status. Re-exported from app.StatusCoder.
+type StatusCoder = app.StatusCoder
+
+// Typed adapts a function with a bound input and a JSON result into a Handler.
+// Re-exported from app.Typed.
+//
+// Example:
+//
+//	app.GET("/users/:id", flash.Typed(func(c flash.Ctx, in struct {
+//		ID int `json:"id"`
+//	}) (User, error) {
+//		return store.User(c.Context(), in.ID)
+//	}))
+func Typed[In, Out any](fn func(c Ctx, in In) (Out, error)) Handler { return app.Typed(fn) }
+
+// Problem is an RFC 9457 problem details object. Re-exported from app.Problem.
+type Problem = app.Problem
+
+// ProblemField is a field error listed in Problem.Errors. Re-exported from app.ProblemField.
+type ProblemField = app.ProblemField
+
+// ProblemErrorHandler writes errors as application/problem+json.
+// Re-exported from app.ProblemErrorHandler.
+//
+// Example:
+//
+//	app.SetErrorHandler(flash.ProblemErrorHandler)
+func ProblemErrorHandler(c Ctx, err error) { app.ProblemErrorHandler(c, err) }
+
+// Renderer renders templates for Ctx.HTML; see App.SetRenderer. Re-exported from ctx.Renderer.
+type Renderer = ctx.Renderer
+
+// HTMLConfig configures NewHTMLRenderer. Re-exported from app.HTMLConfig.
+type HTMLConfig = app.HTMLConfig
+
+// NewHTMLRenderer returns an html/template Renderer with layouts, partials and
+// optional hot reload. Re-exported from app.NewHTMLRenderer.
+//
+// Example:
+//
+//	r, err := flash.NewHTMLRenderer(flash.HTMLConfig{FS: os.DirFS("templates"), Layout: "layouts/base"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	app.SetRenderer(r)
+func NewHTMLRenderer(cfg HTMLConfig) (*app.HTMLRenderer, error) { return app.NewHTMLRenderer(cfg) }
+
 // Ctx is the request context interface, re-exported for convenience.
 type Ctx = ctx.Ctx
 
 // DefaultContext is the concrete context implementation used by the framework.
 type DefaultContext = ctx.DefaultContext
 
+// Validator validates bound input; see App.SetValidator. Re-exported from ctx.Validator.
+type Validator = ctx.Validator
+
+// ValidatorFunc adapts a function to a Validator. Re-exported from ctx.ValidatorFunc.
+type ValidatorFunc = ctx.ValidatorFunc
+
+// Codec encodes response bodies for Ctx.Render; see App.RegisterCodec. Re-exported from ctx.Codec.
+type Codec = ctx.Codec
+
+// Decoder decodes request bodies for the Bind* helpers; see App.RegisterDecoder. Re-exported from ctx.Decoder.
+type Decoder = ctx.Decoder
+
+// DecoderFunc adapts a function to a Decoder. Re-exported from ctx.DecoderFunc.
+type DecoderFunc = ctx.DecoderFunc
+
+// Event is a server-sent event sent with SSEStream.Send. Re-exported from ctx.Event.
+type Event = ctx.Event
+
+// SSEStream writes the events of Ctx.SSE. Re-exported from ctx.SSEStream.
+type SSEStream = ctx.SSEStream
+
+// SSEOptions configures Ctx.SSE. Re-exported from ctx.SSEOptions.
+type SSEOptions = ctx.SSEOptions
+
+// WSHandler handles the connections of App.WS routes. Re-exported from app.WSHandler.
+type WSHandler = app.WSHandler
+
 // New creates a new App with sensible defaults. Re-exported from app.New.
 func New() App { return app.New() }
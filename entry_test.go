@@ -1,9 +1,43 @@
 package flash
 
-import "testing"
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goflash/flash/v2/app"
+)
 
 func TestEntryNewReexport(t *testing.T) {
 	if New() == nil {
 		t.Fatalf("New returned nil")
 	}
 }
+
+func TestEntryNewErrorReexport(t *testing.T) {
+	if err := NewError(404, "not_found"); err.StatusCode() != 404 || err.Code != "not_found" {
+		t.Fatalf("NewError=%+v", err)
+	}
+}
+
+func TestEntryProblemErrorHandlerReexport(t *testing.T) {
+	a := New()
+	a.SetErrorHandler(ProblemErrorHandler)
+	a.GET("/", func(c Ctx) error { return NewError(409, "conflict") })
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != 409 || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestEntryDefaultServerConfigReexport(t *testing.T) {
+	orig := app.DefaultServerConfig
+	defer func() { app.DefaultServerConfig = orig }()
+	app.DefaultServerConfig.MaxHeaderBytes = 4096
+	if got := DefaultServerConfig(); got != app.DefaultServerConfig {
+		t.Fatalf("DefaultServerConfig()=%+v", got)
+	}
+	if got := New().ServerConfig().MaxHeaderBytes; got != 4096 {
+		t.Fatalf("new app MaxHeaderBytes=%d", got)
+	}
+}
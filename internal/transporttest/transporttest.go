@@ -0,0 +1,164 @@
+// Package transporttest runs handler tests against both transports supported
+// by flash: net/http and fasthttp.
+//
+// Tests build requests with httptest.NewRequest and inspect the response as
+// an *httptest.ResponseRecorder; on fasthttp the request is copied into a
+// fasthttp.RequestCtx and the fasthttp response is copied back into a
+// recorder, so the same assertions apply to both transports.
+//
+// Example:
+//
+//	transporttest.Run(t, app, func(t *testing.T, serve transporttest.ServeFunc) {
+//		rec := serve(httptest.NewRequest(http.MethodGet, "/ping", nil))
+//		if rec.Body.String() != "pong" {
+//			t.Fatalf("body=%q", rec.Body.String())
+//		}
+//	})
+//
+// Tests that build their app inside the test use Each, and call serve where
+// they would call ServeHTTP:
+//
+//	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+//		a := flash.New()
+//		a.GET("/ping", Ping)
+//		rec := httptest.NewRecorder()
+//		serve(a, rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+//	})
+package transporttest
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Server is implemented by apps that serve both transports, such as
+// *app.DefaultApp.
+type Server interface {
+	http.Handler
+	ServeFastHTTP(fctx *fasthttp.RequestCtx)
+}
+
+// ServeFunc serves a request on one transport and returns the response.
+type ServeFunc func(r *http.Request) *httptest.ResponseRecorder
+
+// ServeWith serves r with h on one transport and writes the response to w,
+// like h.ServeHTTP(w, r). On fasthttp h must be a Server.
+type ServeWith func(h http.Handler, w http.ResponseWriter, r *http.Request)
+
+// Transports lists the transports in the order Run uses them.
+var Transports = []string{"net/http", "fasthttp"}
+
+// Run calls fn once per transport as a subtest named after the transport.
+func Run(t *testing.T, s Server, fn func(t *testing.T, serve ServeFunc)) {
+	t.Helper()
+	RunNew(t, func() Server { return s }, fn)
+}
+
+// RunNew is like Run but serves each transport with a new Server, for tests
+// that depend on state kept by the server (rate limits, sessions, ...).
+func RunNew(t *testing.T, newServer func() Server, fn func(t *testing.T, serve ServeFunc)) {
+	t.Helper()
+	Each(t, func(t *testing.T, serve ServeWith) {
+		s := newServer()
+		fn(t, func(r *http.Request) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			serve(s, rec, r)
+			return rec
+		})
+	})
+}
+
+// Each calls fn once per transport as a subtest named after the transport,
+// for tests that build their server inside fn.
+func Each(t *testing.T, fn func(t *testing.T, serve ServeWith)) {
+	t.Helper()
+	for _, name := range Transports {
+		t.Run(name, func(t *testing.T) {
+			serve := ServeWith(func(h http.Handler, w http.ResponseWriter, r *http.Request) { h.ServeHTTP(w, r) })
+			if name == "fasthttp" {
+				serve = func(h http.Handler, w http.ResponseWriter, r *http.Request) {
+					t.Helper()
+					s, ok := h.(Server)
+					if !ok {
+						t.Fatalf("%T does not serve fasthttp", h)
+					}
+					fctx := NewRequestCtx(r)
+					s.ServeFastHTTP(fctx)
+					WriteResponse(w, &fctx.Response)
+				}
+			}
+			fn(t, serve)
+		})
+	}
+}
+
+// ServeFastHTTP serves r with h over fasthttp and returns the response.
+func ServeFastHTTP(h fasthttp.RequestHandler, r *http.Request) *httptest.ResponseRecorder {
+	fctx := NewRequestCtx(r)
+	h(fctx)
+	rec := httptest.NewRecorder()
+	WriteResponse(rec, &fctx.Response)
+	return rec
+}
+
+// NewRequestCtx returns a fasthttp.RequestCtx holding a copy of r, for
+// serving r on fasthttp. The remote address is taken from r.RemoteAddr.
+func NewRequestCtx(r *http.Request) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.Header.SetMethod(r.Method)
+	req.SetRequestURI(r.URL.RequestURI())
+	req.Header.SetHost(r.Host)
+	for k, vs := range r.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if r.Body != nil {
+		body, _ := io.ReadAll(r.Body)
+		req.SetBody(body)
+	}
+	if r.ContentLength < 0 {
+		req.Header.SetContentLength(-1) // sent chunked
+	}
+
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		addr = &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}
+	}
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Init(&req, addr, nil)
+	return fctx
+}
+
+// WriteResponse writes resp to w as a client receives it: resp is
+// serialized the way fasthttp sends it, which adds Content-Length and
+// encodes streamed bodies, and read back with http.ReadResponse.
+func WriteResponse(w http.ResponseWriter, resp *fasthttp.Response) {
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	_ = resp.Write(bw)
+	_ = bw.Flush()
+	req := &http.Request{Method: http.MethodGet}
+	if resp.SkipBody {
+		req.Method = http.MethodHead
+	}
+	res, err := http.ReadResponse(bufio.NewReader(&buf), req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, "transporttest: "+err.Error())
+		return
+	}
+	defer res.Body.Close()
+	for k, vs := range res.Header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
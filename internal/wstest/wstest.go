@@ -0,0 +1,160 @@
+// Package wstest is a minimal WebSocket client for testing WS routes over a
+// real connection. It sends masked, unfragmented frames and reads whole
+// frames, so tests can also check control frames and close codes.
+//
+// Example:
+//
+//	c, resp := wstest.Dial(t, srv.URL+"/echo", nil)
+//	if resp.StatusCode != http.StatusSwitchingProtocols {
+//		t.Fatalf("status=%d", resp.StatusCode)
+//	}
+//	c.Write(wstest.OpText, []byte("hi"))
+//	if op, p := c.Read(); op != wstest.OpText || string(p) != "hi" {
+//		t.Fatalf("got %d %q", op, p)
+//	}
+package wstest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Frame opcodes.
+const (
+	OpText   = 0x1
+	OpBinary = 0x2
+	OpClose  = 0x8
+	OpPing   = 0x9
+	OpPong   = 0xa
+)
+
+// Key is the Sec-WebSocket-Key sent by Dial.
+const Key = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// Client is a WebSocket client connection.
+type Client struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// Dial sends a handshake request for rawURL (http://host/path) with the
+// headers of a valid handshake and hdr, and returns the client and the
+// response. The connection is closed when the test ends; reads and writes
+// fail after 5 seconds.
+func Dial(t *testing.T, rawURL string, hdr http.Header) (*Client, *http.Response) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", Key)
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{t: t, conn: conn, br: br}, resp
+}
+
+// Write sends a masked frame with opcode op and payload p.
+func (c *Client) Write(op byte, p []byte) {
+	c.t.Helper()
+	b := []byte{0x80 | op}
+	switch {
+	case len(p) <= 125:
+		b = append(b, 0x80|byte(len(p)))
+	case len(p) <= 0xffff:
+		b = append(b, 0x80|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(p)))
+	default:
+		b = append(b, 0x80|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(len(p)))
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	b = append(b, mask[:]...)
+	for i, x := range p {
+		b = append(b, x^mask[i&3])
+	}
+	if _, err := c.conn.Write(b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// WriteClose sends a close frame with code.
+func (c *Client) WriteClose(code int) {
+	c.t.Helper()
+	c.Write(OpClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+}
+
+// Read reads the next frame and returns its opcode and payload.
+func (c *Client) Read() (op byte, p []byte) {
+	c.t.Helper()
+	var hdr [2]byte
+	if _, err := io.ReadFull(c.br, hdr[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	n := uint64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		var l [2]byte
+		c.readFull(l[:])
+		n = uint64(binary.BigEndian.Uint16(l[:]))
+	case 127:
+		var l [8]byte
+		c.readFull(l[:])
+		n = binary.BigEndian.Uint64(l[:])
+	}
+	p = make([]byte, n)
+	c.readFull(p)
+	return hdr[0] & 0x0f, p
+}
+
+// ReadClose reads frames up to a close frame and returns its status code,
+// or 1005 if it has none.
+func (c *Client) ReadClose() int {
+	c.t.Helper()
+	for {
+		op, p := c.Read()
+		if op != OpClose {
+			continue
+		}
+		if len(p) < 2 {
+			return 1005
+		}
+		return int(binary.BigEndian.Uint16(p))
+	}
+}
+
+func (c *Client) readFull(p []byte) {
+	c.t.Helper()
+	if _, err := io.ReadFull(c.br, p); err != nil {
+		c.t.Fatal(err)
+	}
+}
@@ -3,11 +3,13 @@ package middleware
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"net"
 	"net/http"
 	"sync"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/ctx"
 )
 
 // BufferConfig configures the write-buffering middleware.
@@ -22,8 +24,9 @@ import (
 // Notes and recommendations:
 //   - Set MaxSize to a sensible ceiling to avoid unbounded memory use for very
 //     large responses (MaxSize=0 means unbounded buffering).
-//   - This middleware is not suitable for server-sent events or long-lived
-//     streaming responses. Use it for bounded payloads (JSON, HTML, small files).
+//   - This middleware is not suitable for long-lived streaming responses. Use
+//     it for bounded payloads (JSON, HTML, small files). Ctx.SSE switches it
+//     to passthrough (see Stream), so event streams are not held back.
 //   - For HEAD responses where no body is written, no buffer is allocated at all.
 //
 // Example:
@@ -51,13 +54,14 @@ var bufPool = sync.Pool{
 
 // Buffer returns middleware that wraps the ResponseWriter with a pooled buffer
 // to reduce syscalls and to set an accurate Content-Length when possible.
-// Not recommended for streaming/SSE. Apply before handlers that generate
-// bounded payloads.
+// Not recommended for streaming responses. Apply before handlers that
+// generate bounded payloads.
 //
 // Behavior:
 //   - Buffers writes in-memory up to MaxSize; beyond that, switches to streaming
 //   - Sets Content-Length on close when safe (no Content-Encoding)
 //   - Supports Flush passthrough and zero-allocation HEAD responses
+//   - Passes responses through unbuffered once streaming starts (Ctx.SSE)
 //
 // Example:
 //
@@ -88,6 +92,7 @@ type bufferedRW struct {
 	status      int
 	headWritten bool // whether we've written header to underlying
 	streaming   bool // switched to passthrough
+	hijacked    bool // connection taken over, nothing more is written
 }
 
 // Header returns the underlying response headers map.
@@ -113,7 +118,13 @@ func (b *bufferedRW) ensureBuf() {
 
 // WriteHeader records the status code. The header is written lazily on the
 // first body write or during Close/Flush. If not set, defaults to 200 OK.
-func (b *bufferedRW) WriteHeader(status int) { b.status = status }
+// In streaming mode it is written at once.
+func (b *bufferedRW) WriteHeader(status int) {
+	b.status = status
+	if b.streaming {
+		b.writeHeaderIfNeeded()
+	}
+}
 
 // Write buffers the payload unless streaming mode has been enabled.
 // If MaxSize would be exceeded by this write, buffered content is flushed and
@@ -122,6 +133,9 @@ func (b *bufferedRW) WriteHeader(status int) { b.status = status }
 // Example (switching to streaming): if MaxSize is 1MB and the handler writes
 // 600KB then 600KB, the second write triggers a flush and streaming.
 func (b *bufferedRW) Write(p []byte) (int, error) {
+	if b.hijacked {
+		return 0, http.ErrHijacked
+	}
 	if b.streaming {
 		b.writeHeaderIfNeeded()
 		return b.rw.Write(p)
@@ -150,6 +164,9 @@ func (b *bufferedRW) Write(p []byte) (int, error) {
 // is set unless Content-Encoding is present. This is a key optimization for API
 // and static routes.
 func (b *bufferedRW) Close() error {
+	if b.hijacked {
+		return nil
+	}
 	if b.streaming {
 		b.release()
 		return nil
@@ -191,6 +208,7 @@ func (b *bufferedRW) writeHeaderIfNeeded() {
 func (b *bufferedRW) Flush() {
 	// Flush forces streaming and forwards to underlying if supported
 	if b.streaming {
+		b.writeHeaderIfNeeded()
 		if f, ok := b.rw.(http.Flusher); ok {
 			f.Flush()
 		}
@@ -208,14 +226,42 @@ func (b *bufferedRW) Flush() {
 	}
 }
 
-// Hijack delegates to the underlying ResponseWriter if it implements
-// http.Hijacker. This is necessary for WebSocket upgrades or raw TCP access.
-// If the underlying writer does not support hijacking, an error is returned.
+// Stream switches to streaming mode for a streaming response (Ctx.SSE):
+// buffered bytes are written to the underlying writer without a
+// Content-Length, later writes go straight through, and the underlying
+// writer is told to stream as well. Unlike Flush, it does not write the
+// header, so the handler can still set the status.
+func (b *bufferedRW) Stream() {
+	if !b.streaming {
+		if b.buf != nil && b.buf.Len() > 0 {
+			b.writeHeaderIfNeeded()
+			_, _ = b.rw.Write(b.buf.Bytes())
+		}
+		b.release()
+		b.streaming = true
+	}
+	ctx.StreamWriter(b.rw)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (b *bufferedRW) Unwrap() http.ResponseWriter { return b.rw }
+
+// Hijack delegates to the underlying ResponseWriter, or a writer it wraps,
+// if it implements http.Hijacker. This is necessary for WebSocket upgrades or
+// raw TCP access. Bytes buffered before are discarded, and Close writes
+// nothing once the connection is hijacked. If hijacking is not supported,
+// http.ErrNotSupported is returned.
 func (b *bufferedRW) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hj, ok := b.rw.(http.Hijacker); ok {
-		return hj.Hijack()
+	conn, brw, err := http.NewResponseController(b.rw).Hijack()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil, nil, http.ErrNotSupported
 	}
-	return nil, nil, http.ErrNotSupported
+	if err != nil {
+		return nil, nil, err
+	}
+	b.hijacked = true
+	b.release()
+	return conn, brw, nil
 }
 
 // Push delegates HTTP/2 server push to the underlying ResponseWriter if it
@@ -241,6 +287,7 @@ var _ http.ResponseWriter = (*bufferedRW)(nil)
 var _ http.Flusher = (*bufferedRW)(nil)
 var _ http.Hijacker = (*bufferedRW)(nil)
 var _ http.Pusher = (*bufferedRW)(nil)
+var _ ctx.Streamer = (*bufferedRW)(nil)
 
 // minimal itoa to avoid fmt in hot path
 func strconvItoa(i int) string {
@@ -6,88 +6,105 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/goflash/flash/v2/internal/wstest"
+	"github.com/goflash/flash/v2/websocket"
 )
 
 func TestBufferSetsContentLengthAndFlushes(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 128, MaxSize: 1024}))
-	a.GET("/", func(c flash.Ctx) error { return c.String(http.StatusOK, "hello") })
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 128, MaxSize: 1024}))
+		a.GET("/", func(c flash.Ctx) error { return c.String(http.StatusOK, "hello") })
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
-	if rec.Header().Get("Content-Length") != "5" {
-		t.Fatalf("want CL=5 got %s", rec.Header().Get("Content-Length"))
-	}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
+		}
+		if rec.Header().Get("Content-Length") != "5" {
+			t.Fatalf("want CL=5 got %s", rec.Header().Get("Content-Length"))
+		}
+	})
 }
 
 func TestBufferSwitchesToStreamingOnLargeResponse(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
-	big := make([]byte, 100)
-	for i := range big {
-		big[i] = 'x'
-	}
-	a.GET("/", func(c flash.Ctx) error { _, _ = c.Send(http.StatusOK, "text/plain", big); return nil })
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
+		big := make([]byte, 100)
+		for i := range big {
+			big[i] = 'x'
+		}
+		a.GET("/", func(c flash.Ctx) error { _, _ = c.Send(http.StatusOK, "text/plain", big); return nil })
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Header().Get("Content-Length") == "" {
-		// streaming path won't set Content-Length preemptively
-		_ = time.Second // no-op to avoid unused import on time if removed
-	}
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(a, rec, req)
+		if rec.Header().Get("Content-Length") == "" {
+			// streaming path won't set Content-Length preemptively
+			_ = time.Second // no-op to avoid unused import on time if removed
+		}
+	})
 }
 
 func TestBufferHEADNoBody(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 0}))
-	a.HEAD("/h", func(c flash.Ctx) error { return c.String(http.StatusOK, "") })
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodHead, "/h", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Body.Len() != 0 {
-		t.Fatalf("HEAD should have no body")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 0}))
+		a.HEAD("/h", func(c flash.Ctx) error { return c.String(http.StatusOK, "") })
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodHead, "/h", nil)
+		serve(a, rec, req)
+		if rec.Body.Len() != 0 {
+			t.Fatalf("HEAD should have no body")
+		}
+	})
 }
 
 func TestBufferFlushForcesStreaming(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
-	a.GET("/sse", func(c flash.Ctx) error {
-		c.ResponseWriter().(http.Flusher).Flush() // call flush early
-		_, _ = c.Send(http.StatusOK, "text/plain", []byte("data"))
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
+		a.GET("/sse", func(c flash.Ctx) error {
+			c.ResponseWriter().(http.Flusher).Flush() // call flush early
+			_, _ = c.Send(http.StatusOK, "text/plain", []byte("data"))
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/sse", nil)
+		serve(a, rec, req)
+		_ = time.Second
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/sse", nil)
-	a.ServeHTTP(rec, req)
-	_ = time.Second
 }
 
 // Exercise strconvItoa via buffer paths that set Content-Length
 func TestStrconvItoaCoverage(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer())
-	a.GET("/n", func(c flash.Ctx) error {
-		_, _ = c.ResponseWriter().Write([]byte("12345")) // no explicit Content-Length
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer())
+		a.GET("/n", func(c flash.Ctx) error {
+			_, _ = c.ResponseWriter().Write([]byte("12345")) // no explicit Content-Length
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/n", nil)
+		serve(a, rec, req)
+		if rec.Header().Get("Content-Length") != "5" {
+			t.Fatalf("bad content-length")
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/n", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Header().Get("Content-Length") != "5" {
-		t.Fatalf("bad content-length")
-	}
 }
 
+// net/http only: on fasthttp, Flush streams nothing unless the response was
+// marked as streamed (ctx.Streamer), so a Content-Length is always sent.
 func TestBufferFirstWriteExceedsMaxSizeStreamsImmediately(t *testing.T) {
 	a := flash.New()
 	a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 2})) // MaxSize smaller than first write
@@ -109,6 +126,7 @@ func TestBufferFirstWriteExceedsMaxSizeStreamsImmediately(t *testing.T) {
 	}
 }
 
+// net/http only: see TestBufferFirstWriteExceedsMaxSizeStreamsImmediately.
 func TestBufferBufferedThenOverflowFlushesAndStreams(t *testing.T) {
 	a := flash.New()
 	a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 3}))
@@ -133,39 +151,44 @@ func TestBufferBufferedThenOverflowFlushesAndStreams(t *testing.T) {
 }
 
 func TestBufferCloseNoWritesDefaultsTo200(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer())
-	a.GET("/nowrite", func(c flash.Ctx) error { return nil })
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/nowrite", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
-	if rec.Body.Len() != 0 {
-		t.Fatalf("expected empty body")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer())
+		a.GET("/nowrite", func(c flash.Ctx) error { return nil })
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/nowrite", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Fatalf("expected empty body")
+		}
+	})
 }
 
 func TestBufferCloseNoWritesWithPresetStatus(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer())
-	a.GET("/nostatusbody", func(c flash.Ctx) error {
-		// Set status on the buffered ResponseWriter so Buffer.Close() will honor it
-		c.ResponseWriter().WriteHeader(http.StatusNoContent)
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer())
+		a.GET("/nostatusbody", func(c flash.Ctx) error {
+			// Set status on the buffered ResponseWriter so Buffer.Close() will honor it
+			c.ResponseWriter().WriteHeader(http.StatusNoContent)
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/nostatusbody", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d", rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Fatalf("expected empty body")
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/nostatusbody", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusNoContent {
-		t.Fatalf("expected 204, got %d", rec.Code)
-	}
-	if rec.Body.Len() != 0 {
-		t.Fatalf("expected empty body")
-	}
 }
 
+// net/http only: see TestBufferFirstWriteExceedsMaxSizeStreamsImmediately.
 func TestBufferFlushWithBufferedDataWritesAndNoContentLength(t *testing.T) {
 	a := flash.New()
 	a.Use(Buffer())
@@ -190,40 +213,45 @@ func TestBufferFlushWithBufferedDataWritesAndNoContentLength(t *testing.T) {
 }
 
 func TestBufferFlushWithoutAnyWritesSetsHeaderAndStreams(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer())
-	a.GET("/flush-empty", func(c flash.Ctx) error {
-		c.ResponseWriter().(http.Flusher).Flush() // no prior writes, buf==nil path
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer())
+		a.GET("/flush-empty", func(c flash.Ctx) error {
+			c.ResponseWriter().(http.Flusher).Flush() // no prior writes, buf==nil path
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/flush-empty", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if rec.Body.Len() != 0 {
+			t.Fatalf("expected empty body after empty Flush")
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/flush-empty", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
-	if rec.Body.Len() != 0 {
-		t.Fatalf("expected empty body after empty Flush")
-	}
 }
 
 func TestBufferEnsureBufEarlyReturn(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 0}))
-	a.GET("/twowrites", func(c flash.Ctx) error {
-		w := c.ResponseWriter()
-		_, _ = w.Write([]byte("hi"))
-		_, _ = w.Write([]byte("there"))
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 0, MaxSize: 0}))
+		a.GET("/twowrites", func(c flash.Ctx) error {
+			w := c.ResponseWriter()
+			_, _ = w.Write([]byte("hi"))
+			_, _ = w.Write([]byte("there"))
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/twowrites", nil)
+		serve(a, rec, req)
+		if rec.Header().Get("Content-Length") != "7" {
+			t.Fatalf("want CL=7 got %s", rec.Header().Get("Content-Length"))
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/twowrites", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Header().Get("Content-Length") != "7" {
-		t.Fatalf("want CL=7 got %s", rec.Header().Get("Content-Length"))
-	}
 }
 
+// net/http only: fasthttp always sends the Content-Length of buffered bodies.
 func TestBufferNoContentLengthWhenEncodingPreset(t *testing.T) {
 	a := flash.New()
 	a.Use(Buffer())
@@ -241,36 +269,40 @@ func TestBufferNoContentLengthWhenEncodingPreset(t *testing.T) {
 }
 
 func TestBufferFlushTwiceCoversStreamingBranch(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
-	a.GET("/flush2", func(c flash.Ctx) error {
-		f := c.ResponseWriter().(http.Flusher)
-		f.Flush()
-		f.Flush()
-		_, _ = c.ResponseWriter().Write([]byte("ok"))
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer(BufferConfig{InitialSize: 4, MaxSize: 8}))
+		a.GET("/flush2", func(c flash.Ctx) error {
+			f := c.ResponseWriter().(http.Flusher)
+			f.Flush()
+			f.Flush()
+			_, _ = c.ResponseWriter().Write([]byte("ok"))
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/flush2", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/flush2", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
 }
 
 func TestBufferZeroLengthSetsCLZero(t *testing.T) {
-	a := flash.New()
-	a.Use(Buffer())
-	a.GET("/zero", func(c flash.Ctx) error {
-		_, _ = c.ResponseWriter().Write([]byte{})
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(Buffer())
+		a.GET("/zero", func(c flash.Ctx) error {
+			_, _ = c.ResponseWriter().Write([]byte{})
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/zero", nil)
+		serve(a, rec, req)
+		if rec.Header().Get("Content-Length") != "0" {
+			t.Fatalf("want CL=0 got %s", rec.Header().Get("Content-Length"))
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/zero", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Header().Get("Content-Length") != "0" {
-		t.Fatalf("want CL=0 got %s", rec.Header().Get("Content-Length"))
-	}
 }
 
 // failOnFirstWriteRW wraps a ResponseRecorder and fails the first Write call.
@@ -287,6 +319,7 @@ func (w *failOnFirstWriteRW) Write(p []byte) (int, error) {
 	return w.ResponseRecorder.Write(p)
 }
 
+// net/http only: the failing writer stands in for the net/http ResponseWriter.
 func TestBufferSwitchToStreamingFlushBufferedWriteError(t *testing.T) {
 	a := flash.New()
 	called := false
@@ -311,6 +344,7 @@ func TestBufferSwitchToStreamingFlushBufferedWriteError(t *testing.T) {
 	}
 }
 
+// net/http only: fasthttp always sends the Content-Length of buffered bodies.
 func TestBufferRespectsPreSetContentLength(t *testing.T) {
 	a := flash.New()
 	a.Use(Buffer())
@@ -353,6 +387,8 @@ func (p *pusherRecorder) Push(target string, opts *http.PushOptions) error {
 	return nil
 }
 
+// net/http only: the recorders stand in for the net/http ResponseWriter.
+// Hijacking on fasthttp is covered by the WebSocket tests.
 func TestBufferHijackDelegationAndUnsupported(t *testing.T) {
 	t.Run("delegates when underlying supports hijack", func(t *testing.T) {
 		a := flash.New()
@@ -396,6 +432,7 @@ func TestBufferHijackDelegationAndUnsupported(t *testing.T) {
 	})
 }
 
+// net/http only: fasthttp has no server push.
 func TestBufferPushDelegationAndUnsupported(t *testing.T) {
 	t.Run("delegates to underlying Pusher", func(t *testing.T) {
 		a := flash.New()
@@ -430,3 +467,88 @@ func TestBufferPushDelegationAndUnsupported(t *testing.T) {
 		}
 	})
 }
+
+func TestBufferPassesSSEThrough(t *testing.T) {
+	a := flash.New()
+	a.Use(Buffer())
+	read := make(chan struct{})
+	a.GET("/events", func(c flash.Ctx) error {
+		// Written before the stream starts, sent ahead of the events
+		_, _ = c.ResponseWriter().Write([]byte(": hello\n\n"))
+		return c.SSE(func(s ctx.SSEStream) error {
+			if err := s.Send(ctx.Event{Data: "1"}); err != nil {
+				return err
+			}
+			<-read // only reached if the event was not buffered
+			return s.Send(ctx.Event{Data: "2"})
+		})
+	})
+
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(url + "/events")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.Header.Get("Content-Length") != "" || resp.Header.Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("headers=%v", resp.Header)
+			}
+			br := bufio.NewReader(resp.Body)
+			var got []string
+			for {
+				line, err := br.ReadString('\n')
+				if err != nil {
+					break
+				}
+				if line != "\n" {
+					got = append(got, line)
+				}
+				if line == "data: 1\n" {
+					read <- struct{}{}
+				}
+			}
+			if want := ": hello\n,data: 1\n,data: 2\n"; strings.Join(got, ",") != want {
+				t.Fatalf("got %q", got)
+			}
+		})
+	}
+}
+
+func TestBufferPassesWebSocketThrough(t *testing.T) {
+	a := flash.New()
+	a.Use(Buffer())
+	a.WS("/ws", func(c flash.Ctx, conn *websocket.Conn) error {
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(typ, msg); err != nil {
+				return err
+			}
+		}
+	}, func(next flash.Handler) flash.Handler {
+		return func(c flash.Ctx) error {
+			c.Header("X-Route", "ws")
+			return next(c)
+		}
+	})
+
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			client, resp := wstest.Dial(t, url+"/ws", nil)
+			if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("X-Route") != "ws" {
+				t.Fatalf("got %d %v", resp.StatusCode, resp.Header)
+			}
+			client.Write(wstest.OpText, []byte("ping"))
+			if op, p := client.Read(); op != wstest.OpText || string(p) != "ping" {
+				t.Fatalf("got %d %q", op, p)
+			}
+			client.WriteClose(websocket.CloseNormalClosure)
+			if code := client.ReadClose(); code != websocket.CloseNormalClosure {
+				t.Fatalf("close code %d", code)
+			}
+		})
+	}
+}
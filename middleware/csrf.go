@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/ctx"
 )
 
 // CSRFConfig configures the CSRF middleware.
@@ -99,6 +100,8 @@ func DefaultCSRFConfig() CSRFConfig {
 // Behavior:
 //   - For safe methods (GET, HEAD, OPTIONS): sets CSRF cookie if missing, then continues
 //   - For unsafe methods (POST, PUT, PATCH, DELETE): validates token in both cookie and header
+//   - The request's token is stored in its context for ctx.CSRFTokenFromContext and
+//     the csrfToken function of app.NewHTMLRenderer templates
 //   - Returns 403 Forbidden if token is missing or invalid
 //   - Uses constant-time comparison to prevent timing attacks
 //
@@ -148,7 +151,7 @@ func CSRF(cfgs ...CSRFConfig) flash.Middleware {
 		return func(c flash.Ctx) error {
 			// Only protect unsafe methods
 			if c.Method() == http.MethodGet || c.Method() == http.MethodHead || c.Method() == http.MethodOptions {
-				ensureCSRFCookie(c, cfg)
+				withCSRFToken(c, ensureCSRFCookie(c, cfg))
 				return next(c)
 			}
 			cookie, err := c.Request().Cookie(cfg.CookieName)
@@ -159,17 +162,19 @@ func CSRF(cfgs ...CSRFConfig) flash.Middleware {
 			if headertok == "" || !compareTokens(cookie.Value, headertok) {
 				return c.Status(http.StatusForbidden).String(http.StatusForbidden, "CSRF token invalid")
 			}
+			withCSRFToken(c, cookie.Value)
 			return next(c)
 		}
 	}
 }
 
-// ensureCSRFCookie sets a CSRF cookie if one doesn't already exist.
+// ensureCSRFCookie sets a CSRF cookie if one doesn't already exist and
+// returns the token of the request.
 // Called for safe methods to ensure the token is available for subsequent unsafe requests.
-func ensureCSRFCookie(c flash.Ctx, cfg CSRFConfig) {
+func ensureCSRFCookie(c flash.Ctx, cfg CSRFConfig) string {
 	cookie, err := c.Request().Cookie(cfg.CookieName)
 	if err == nil && cookie.Value != "" {
-		return
+		return cookie.Value
 	}
 	tok := generateCSRFToken(cfg.TokenLength)
 	http.SetCookie(c.ResponseWriter(), &http.Cookie{
@@ -182,6 +187,13 @@ func ensureCSRFCookie(c flash.Ctx, cfg CSRFConfig) {
 		SameSite: cfg.CookieSameSite,
 		Expires:  time.Now().Add(cfg.TTL),
 	})
+	return tok
+}
+
+// withCSRFToken stores the request's token in its context, for
+// ctx.CSRFTokenFromContext and the csrfToken template function.
+func withCSRFToken(c flash.Ctx, tok string) {
+	c.SetRequest(c.Request().WithContext(ctx.ContextWithCSRFToken(c.Context(), tok)))
 }
 
 // generateCSRFToken creates a cryptographically secure random token.
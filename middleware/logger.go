@@ -236,9 +236,9 @@ func WithMessage(message string) LoggerOption {
 //
 //	// The middleware will log the request even if the handler returns an error
 //	app.Get("/error", func(c flash.Ctx) error {
-//	    return flash.NewError(500, "Internal server error")
+//	    return flash.NewError(http.StatusServiceUnavailable, "maintenance", "Try again later")
 //	})
-//	// Log output will show status: 500 and the error will be returned to the client
+//	// Log output will show status: 503 and the error will be returned to the client
 //
 // Performance considerations:
 //
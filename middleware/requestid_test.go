@@ -7,51 +7,58 @@ import (
 	"testing"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/internal/transporttest"
 )
 
 func TestRequestIDSetsHeaderAndContext(t *testing.T) {
-	a := flash.New()
-	a.Use(RequestID())
-	a.GET("/", func(c flash.Ctx) error {
-		if _, ok := RequestIDFromContext(c.Context()); !ok {
-			t.Fatalf("request id missing")
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(RequestID())
+		a.GET("/", func(c flash.Ctx) error {
+			if _, ok := RequestIDFromContext(c.Context()); !ok {
+				t.Fatalf("request id missing")
+			}
+			return c.String(http.StatusOK, "ok")
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
+		}
+		if rec.Header().Get("X-Request-ID") == "" {
+			t.Fatalf("header missing")
 		}
-		return c.String(http.StatusOK, "ok")
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
-	if rec.Header().Get("X-Request-ID") == "" {
-		t.Fatalf("header missing")
-	}
 }
 
 func TestRequestIDCustomHeader(t *testing.T) {
-	a := flash.New()
-	a.Use(RequestID(RequestIDConfig{Header: "X-CID"}))
-	a.GET("/", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") })
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Header().Get("X-CID") == "" {
-		t.Fatalf("custom header missing")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.Use(RequestID(RequestIDConfig{Header: "X-CID"}))
+		a.GET("/", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") })
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(a, rec, req)
+		if rec.Header().Get("X-CID") == "" {
+			t.Fatalf("custom header missing")
+		}
+	})
 }
 
 func TestRequestIDFromContextMissing(t *testing.T) {
-	a := flash.New()
-	a.GET("/", func(c flash.Ctx) error {
-		if _, ok := RequestIDFromContext(c.Context()); ok {
-			t.Fatalf("expected no request id")
-		}
-		return c.String(http.StatusOK, "ok")
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.GET("/", func(c flash.Ctx) error {
+			if _, ok := RequestIDFromContext(c.Context()); ok {
+				t.Fatalf("expected no request id")
+			}
+			return c.String(http.StatusOK, "ok")
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		serve(a, rec, req)
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
-	a.ServeHTTP(rec, req)
 }
 
 func TestRequestIDFromContextTypeMismatch(t *testing.T) {
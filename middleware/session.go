@@ -930,3 +930,6 @@ func (h *headerWriteInterceptor) Write(p []byte) (int, error) {
 	}
 	return h.rw.Write(p)
 }
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (h *headerWriteInterceptor) Unwrap() http.ResponseWriter { return h.rw }
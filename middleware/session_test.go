@@ -9,50 +9,53 @@ import (
 	"time"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/internal/transporttest"
 )
 
 func TestSessionsCookieAndHeader(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid", HeaderName: "X-Session-ID"}))
-
-	// set route writes a session value, causing save and cookie/header set
-	a.GET("/set", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("k", "v")
-		return c.String(http.StatusOK, "ok")
-	})
-	// get route reads session
-	a.GET("/get", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		if v, ok := s.Get("k"); ok {
-			return c.String(http.StatusOK, v.(string))
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid", HeaderName: "X-Session-ID"}))
+
+		// set route writes a session value, causing save and cookie/header set
+		a.GET("/set", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("k", "v")
+			return c.String(http.StatusOK, "ok")
+		})
+		// get route reads session
+		a.GET("/get", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			if v, ok := s.Get("k"); ok {
+				return c.String(http.StatusOK, v.(string))
+			}
+			return c.String(http.StatusNotFound, "missing")
+		})
+
+		// First request sets cookie via Sessions middleware
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/set", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
+		}
+		ck := rec.Result().Cookies()
+		if len(ck) == 0 {
+			t.Fatalf("no cookie")
 		}
-		return c.String(http.StatusNotFound, "missing")
-	})
-
-	// First request sets cookie via Sessions middleware
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/set", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
-	ck := rec.Result().Cookies()
-	if len(ck) == 0 {
-		t.Fatalf("no cookie")
-	}
 
-	// Send cookie back to read value
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/get", nil)
-	for _, c := range ck {
-		req.AddCookie(c)
-	}
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK || rec.Body.String() != "v" {
-		t.Fatalf("unexpected: code=%d body=%q", rec.Code, rec.Body.String())
-	}
+		// Send cookie back to read value
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/get", nil)
+		for _, c := range ck {
+			req.AddCookie(c)
+		}
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "v" {
+			t.Fatalf("unexpected: code=%d body=%q", rec.Code, rec.Body.String())
+		}
+	})
 }
 
 func TestMemoryStoreSaveGetDelete(t *testing.T) {
@@ -103,276 +106,294 @@ func TestMemoryStoreSaveEmptyIDErrorAndNilData(t *testing.T) {
 }
 
 func TestSessionDeleteBranches(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
-	a.GET("/set", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("k", "v")
-		return c.String(http.StatusOK, "ok")
-	})
-	a.GET("/del", func(c flash.Ctx) error { s := SessionFromCtx(c); s.Delete("k"); return c.String(http.StatusOK, "ok") })
-	// read returns missing after delete
-	a.GET("/get", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		if _, ok := s.Get("k"); ok {
-			return c.String(http.StatusOK, "has")
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
+		a.GET("/set", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("k", "v")
+			return c.String(http.StatusOK, "ok")
+		})
+		a.GET("/del", func(c flash.Ctx) error { s := SessionFromCtx(c); s.Delete("k"); return c.String(http.StatusOK, "ok") })
+		// read returns missing after delete
+		a.GET("/get", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			if _, ok := s.Get("k"); ok {
+				return c.String(http.StatusOK, "has")
+			}
+			return c.String(http.StatusNotFound, "missing")
+		})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/set", nil)
+		serve(a, rec, req)
+		cks := rec.Result().Cookies()
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/del", nil)
+		for _, ck := range cks {
+			req.AddCookie(ck)
 		}
-		return c.String(http.StatusNotFound, "missing")
-	})
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/set", nil)
-	a.ServeHTTP(rec, req)
-	cks := rec.Result().Cookies()
+		serve(a, rec, req)
 
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/del", nil)
-	for _, ck := range cks {
-		req.AddCookie(ck)
-	}
-	a.ServeHTTP(rec, req)
-
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/get", nil)
-	for _, ck := range cks {
-		req.AddCookie(ck)
-	}
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusNotFound {
-		t.Fatalf("expected missing after delete")
-	}
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/get", nil)
+		for _, ck := range cks {
+			req.AddCookie(ck)
+		}
+		serve(a, rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("expected missing after delete")
+		}
+	})
 }
 
 func TestSessionsHeaderBasedID(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, HeaderName: "X-SID"}))
-
-	// set
-	a.GET("/set", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("k", "v")
-		return c.String(http.StatusOK, "ok")
-	})
-	// get
-	a.GET("/get", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		if v, ok := s.Get("k"); ok {
-			return c.String(http.StatusOK, v.(string))
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, HeaderName: "X-SID"}))
+
+		// set
+		a.GET("/set", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("k", "v")
+			return c.String(http.StatusOK, "ok")
+		})
+		// get
+		a.GET("/get", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			if v, ok := s.Get("k"); ok {
+				return c.String(http.StatusOK, v.(string))
+			}
+			return c.String(http.StatusNotFound, "missing")
+		})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/set", nil)
+		serve(a, rec, req)
+		sid := rec.Header().Get("X-SID")
+		if sid == "" {
+			t.Fatalf("missing session id header")
 		}
-		return c.String(http.StatusNotFound, "missing")
-	})
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/set", nil)
-	a.ServeHTTP(rec, req)
-	sid := rec.Header().Get("X-SID")
-	if sid == "" {
-		t.Fatalf("missing session id header")
-	}
-
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/get", nil)
-	req.Header.Set("X-SID", sid)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK || rec.Body.String() != "v" {
-		t.Fatalf("bad get: %d %q", rec.Code, rec.Body.String())
-	}
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/get", nil)
+		req.Header.Set("X-SID", sid)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "v" {
+			t.Fatalf("bad get: %d %q", rec.Code, rec.Body.String())
+		}
+	})
 }
 
 func TestSessionsExternalIDNoChangesFlushAtEnd(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	// Provide only Store and HeaderName to exercise defaults and the (new && ID != "") branch
-	a.Use(Sessions(SessionConfig{Store: store, HeaderName: "X-SID"}))
-	// Handler does not write headers/body and does not change session
-	a.GET("/noop", func(c flash.Ctx) error { return nil })
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/noop", nil)
-	req.Header.Set("X-SID", "abc123") // external id provided
-	a.ServeHTTP(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
-	// Expect header with session id written
-	if rec.Header().Get("X-SID") == "" {
-		t.Fatalf("expected X-SID header to be written")
-	}
-	// Expect cookie written with default cookie name
-	if len(rec.Result().Cookies()) == 0 {
-		t.Fatalf("expected Set-Cookie written")
-	}
-	// Store should contain the id with empty map persisted
-	if v, ok := store.Get("abc123"); !ok || len(v) != 0 {
-		t.Fatalf("expected store to have empty session for abc123, ok=%v v=%v", ok, v)
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		// Provide only Store and HeaderName to exercise defaults and the (new && ID != "") branch
+		a.Use(Sessions(SessionConfig{Store: store, HeaderName: "X-SID"}))
+		// Handler does not write headers/body and does not change session
+		a.GET("/noop", func(c flash.Ctx) error { return nil })
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/noop", nil)
+		req.Header.Set("X-SID", "abc123") // external id provided
+		serve(a, rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		// Expect header with session id written
+		if rec.Header().Get("X-SID") == "" {
+			t.Fatalf("expected X-SID header to be written")
+		}
+		// Expect cookie written with default cookie name
+		if len(rec.Result().Cookies()) == 0 {
+			t.Fatalf("expected Set-Cookie written")
+		}
+		// Store should contain the id with empty map persisted
+		if v, ok := store.Get("abc123"); !ok || len(v) != 0 {
+			t.Fatalf("expected store to have empty session for abc123, ok=%v v=%v", ok, v)
+		}
+	})
 }
 
 func TestSessionsNoIDNoChangesNoSetCookie(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	// Default config (no HeaderName). No incoming cookie/id and handler makes no changes
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
-	a.GET("/noop2", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") })
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/noop2", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
-		t.Fatalf("bad response: %d %q", rec.Code, rec.Body.String())
-	}
-	if len(rec.Result().Cookies()) != 0 {
-		t.Fatalf("did not expect Set-Cookie when no id and no changes")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		// Default config (no HeaderName). No incoming cookie/id and handler makes no changes
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
+		a.GET("/noop2", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") })
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/noop2", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Fatalf("bad response: %d %q", rec.Code, rec.Body.String())
+		}
+		if len(rec.Result().Cookies()) != 0 {
+			t.Fatalf("did not expect Set-Cookie when no id and no changes")
+		}
+	})
 }
 
 func TestSessionHeaderWriteInterceptorWriteCallsBefore(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
-	// Write to ResponseWriter directly without calling c.String to trigger headerWriteInterceptor.Write
-	a.GET("/w", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("k", "v")
-		_, _ = c.ResponseWriter().Write([]byte("ok"))
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
+		// Write to ResponseWriter directly without calling c.String to trigger headerWriteInterceptor.Write
+		a.GET("/w", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("k", "v")
+			_, _ = c.ResponseWriter().Write([]byte("ok"))
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/w", nil)
+		serve(a, rec, req)
+		if len(rec.Result().Cookies()) == 0 {
+			t.Fatalf("expected Set-Cookie written before headers")
+		}
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Fatalf("unexpected response: %d %q", rec.Code, rec.Body.String())
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/w", nil)
-	a.ServeHTTP(rec, req)
-	if len(rec.Result().Cookies()) == 0 {
-		t.Fatalf("expected Set-Cookie written before headers")
-	}
-	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
-		t.Fatalf("unexpected response: %d %q", rec.Code, rec.Body.String())
-	}
 }
 
 func TestSessionFromCtxNil(t *testing.T) {
-	// Without Sessions middleware, SessionFromCtx should return empty session
-	a := flash.New()
-	a.GET("/x", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		if _, ok := s.Get("k"); ok {
-			t.Fatalf("expected empty session values")
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		// Without Sessions middleware, SessionFromCtx should return empty session
+		a := flash.New()
+		a.GET("/x", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			if _, ok := s.Get("k"); ok {
+				t.Fatalf("expected empty session values")
+			}
+			return c.String(http.StatusOK, "ok")
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
 		}
-		return c.String(http.StatusOK, "ok")
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/x", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
 }
 
 func TestHeaderWriteInterceptorWriteHeaderPath(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
-	a.GET("/h", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("x", "y")
-		// trigger WriteHeader path (without body)
-		c.ResponseWriter().WriteHeader(http.StatusCreated)
-		return nil
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
+		a.GET("/h", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("x", "y")
+			// trigger WriteHeader path (without body)
+			c.ResponseWriter().WriteHeader(http.StatusCreated)
+			return nil
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/h", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("code=%d", rec.Code)
+		}
+		if len(rec.Result().Cookies()) == 0 {
+			t.Fatalf("expected Set-Cookie written before header")
+		}
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/h", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusCreated {
-		t.Fatalf("code=%d", rec.Code)
-	}
-	if len(rec.Result().Cookies()) == 0 {
-		t.Fatalf("expected Set-Cookie written before header")
-	}
 }
 
 func TestSessionFromCtxWrongTypeValue(t *testing.T) {
-	a := flash.New()
-	a.GET("/wt", func(c flash.Ctx) error {
-		// Inject a wrong-typed value under sessionContextKey{}
-		r := c.Request().WithContext(context.WithValue(c.Context(), sessionContextKey{}, "bad"))
-		c.SetRequest(r)
-		s := SessionFromCtx(c)
-		if s == nil || len(s.Values) != 0 || s.ID != "" {
-			t.Fatalf("expected empty session on wrong type, got: %+v", s)
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.GET("/wt", func(c flash.Ctx) error {
+			// Inject a wrong-typed value under sessionContextKey{}
+			r := c.Request().WithContext(context.WithValue(c.Context(), sessionContextKey{}, "bad"))
+			c.SetRequest(r)
+			s := SessionFromCtx(c)
+			if s == nil || len(s.Values) != 0 || s.ID != "" {
+				t.Fatalf("expected empty session on wrong type, got: %+v", s)
+			}
+			return c.String(http.StatusOK, "ok")
+		})
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/wt", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("code=%d", rec.Code)
 		}
-		return c.String(http.StatusOK, "ok")
 	})
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/wt", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK {
-		t.Fatalf("code=%d", rec.Code)
-	}
 }
 
 // Test new Session methods
 func TestSessionClearAndRegenerate(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
-
-	// Set some initial data
-	a.GET("/set", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("user_id", "123")
-		s.Set("role", "admin")
-		return c.String(http.StatusOK, "ok")
-	})
-
-	// Clear session data
-	a.GET("/clear", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Clear()
-		return c.String(http.StatusOK, "cleared")
-	})
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
+
+		// Set some initial data
+		a.GET("/set", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("user_id", "123")
+			s.Set("role", "admin")
+			return c.String(http.StatusOK, "ok")
+		})
+
+		// Clear session data
+		a.GET("/clear", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Clear()
+			return c.String(http.StatusOK, "cleared")
+		})
+
+		// Regenerate session ID
+		a.GET("/regenerate", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			oldID := s.ID
+			s.Regenerate()
+			if s.ID == oldID {
+				t.Error("session ID should have changed")
+			}
+			if !s.IsRegenerated() {
+				t.Error("session should be marked as regenerated")
+			}
+			s.Set("new_data", "after_regen")
+			return c.String(http.StatusOK, s.ID)
+		})
+
+		// First request - set data
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/set", nil)
+		serve(a, rec, req)
+		cookies := rec.Result().Cookies()
+
+		// Second request - clear data
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/clear", nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		serve(a, rec, req)
 
-	// Regenerate session ID
-	a.GET("/regenerate", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		oldID := s.ID
-		s.Regenerate()
-		if s.ID == oldID {
-			t.Error("session ID should have changed")
+		// Third request - regenerate session
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/regenerate", nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
 		}
-		if !s.IsRegenerated() {
-			t.Error("session should be marked as regenerated")
+		serve(a, rec, req)
+		newSessionID := rec.Body.String()
+
+		if newSessionID == "" {
+			t.Fatal("expected new session ID")
 		}
-		s.Set("new_data", "after_regen")
-		return c.String(http.StatusOK, s.ID)
 	})
-
-	// First request - set data
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/set", nil)
-	a.ServeHTTP(rec, req)
-	cookies := rec.Result().Cookies()
-
-	// Second request - clear data
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/clear", nil)
-	for _, ck := range cookies {
-		req.AddCookie(ck)
-	}
-	a.ServeHTTP(rec, req)
-
-	// Third request - regenerate session
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/regenerate", nil)
-	for _, ck := range cookies {
-		req.AddCookie(ck)
-	}
-	a.ServeHTTP(rec, req)
-	newSessionID := rec.Body.String()
-
-	if newSessionID == "" {
-		t.Fatal("expected new session ID")
-	}
 }
 
 // Test MemoryStore cleanup functionality
@@ -482,75 +503,77 @@ func TestMemoryStoreTimingAttackProtection(t *testing.T) {
 
 // Test session regeneration cleans up old session
 func TestSessionRegenerationCleanup(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
-
-	var oldSessionID string
-
-	// Set initial session data
-	a.GET("/login", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("user_id", "123")
-		// Session ID is generated when first saved, so we return a placeholder
-		return c.String(http.StatusOK, "login_ok")
-	})
-
-	// Regenerate session after "authentication"
-	a.GET("/auth", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Regenerate() // This should clean up the old session
-		s.Set("authenticated", true)
-		return c.String(http.StatusOK, s.ID)
-	})
-
-	// First request - create session
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/login", nil)
-	a.ServeHTTP(rec, req)
-	cookies := rec.Result().Cookies()
-
-	// Get the session ID from the cookie
-	if len(cookies) == 0 {
-		t.Fatal("expected cookie to be set")
-	}
-	oldSessionID = cookies[0].Value
-
-	// Verify old session exists in store
-	if _, ok := store.Get(oldSessionID); !ok {
-		t.Fatal("old session should exist before regeneration")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour, CookieName: "sid"}))
+
+		var oldSessionID string
+
+		// Set initial session data
+		a.GET("/login", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("user_id", "123")
+			// Session ID is generated when first saved, so we return a placeholder
+			return c.String(http.StatusOK, "login_ok")
+		})
+
+		// Regenerate session after "authentication"
+		a.GET("/auth", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Regenerate() // This should clean up the old session
+			s.Set("authenticated", true)
+			return c.String(http.StatusOK, s.ID)
+		})
+
+		// First request - create session
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/login", nil)
+		serve(a, rec, req)
+		cookies := rec.Result().Cookies()
+
+		// Get the session ID from the cookie
+		if len(cookies) == 0 {
+			t.Fatal("expected cookie to be set")
+		}
+		oldSessionID = cookies[0].Value
 
-	// Second request - regenerate session
-	rec = httptest.NewRecorder()
-	req = httptest.NewRequest(http.MethodGet, "/auth", nil)
-	for _, ck := range cookies {
-		req.AddCookie(ck)
-	}
-	a.ServeHTTP(rec, req)
-	newSessionID := rec.Body.String()
+		// Verify old session exists in store
+		if _, ok := store.Get(oldSessionID); !ok {
+			t.Fatal("old session should exist before regeneration")
+		}
 
-	// Verify old session was cleaned up
-	if _, ok := store.Get(oldSessionID); ok {
-		t.Fatal("old session should be cleaned up after regeneration")
-	}
+		// Second request - regenerate session
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/auth", nil)
+		for _, ck := range cookies {
+			req.AddCookie(ck)
+		}
+		serve(a, rec, req)
+		newSessionID := rec.Body.String()
 
-	// Verify new session exists
-	if _, ok := store.Get(newSessionID); !ok {
-		t.Fatal("new session should exist after regeneration")
-	}
+		// Verify old session was cleaned up
+		if _, ok := store.Get(oldSessionID); ok {
+			t.Fatal("old session should be cleaned up after regeneration")
+		}
 
-	// Verify session data was preserved
-	if data, ok := store.Get(newSessionID); ok {
-		if data["user_id"] != "123" {
-			t.Fatal("session data should be preserved during regeneration")
+		// Verify new session exists
+		if _, ok := store.Get(newSessionID); !ok {
+			t.Fatal("new session should exist after regeneration")
 		}
-		if data["authenticated"] != true {
-			t.Fatal("new data should be present after regeneration")
+
+		// Verify session data was preserved
+		if data, ok := store.Get(newSessionID); ok {
+			if data["user_id"] != "123" {
+				t.Fatal("session data should be preserved during regeneration")
+			}
+			if data["authenticated"] != true {
+				t.Fatal("new data should be present after regeneration")
+			}
+		} else {
+			t.Fatal("new session data should be accessible")
 		}
-	} else {
-		t.Fatal("new session data should be accessible")
-	}
+	})
 }
 
 // Test session helper methods
@@ -718,72 +741,76 @@ func TestCopyMap(t *testing.T) {
 }
 
 func TestSessionGetSetDeleteWithNilValues(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
 
-	a.GET("/test", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
+		a.GET("/test", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
 
-		// Test setting nil value
-		s.Set("nil_key", nil)
+			// Test setting nil value
+			s.Set("nil_key", nil)
 
-		// Test getting nil value
-		val, ok := s.Get("nil_key")
-		if !ok {
-			t.Error("expected nil value to be retrievable")
-		}
-		if val != nil {
-			t.Errorf("expected nil, got %v", val)
-		}
+			// Test getting nil value
+			val, ok := s.Get("nil_key")
+			if !ok {
+				t.Error("expected nil value to be retrievable")
+			}
+			if val != nil {
+				t.Errorf("expected nil, got %v", val)
+			}
 
-		// Test deleting nil value
-		s.Delete("nil_key")
-		_, ok = s.Get("nil_key")
-		if ok {
-			t.Error("expected key to be deleted")
-		}
+			// Test deleting nil value
+			s.Delete("nil_key")
+			_, ok = s.Get("nil_key")
+			if ok {
+				t.Error("expected key to be deleted")
+			}
 
-		return c.String(http.StatusOK, "ok")
-	})
+			return c.String(http.StatusOK, "ok")
+		})
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	a.ServeHTTP(rec, req)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		serve(a, rec, req)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	})
 }
 
 func TestSessionClearWithEmptySession(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
+
+		a.GET("/test", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+
+			// Clear empty session
+			s.Clear()
+
+			// Should still work
+			s.Set("key", "value")
+			val, ok := s.Get("key")
+			if !ok || val != "value" {
+				t.Error("session should work after clearing empty session")
+			}
 
-	a.GET("/test", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
+			return c.String(http.StatusOK, "ok")
+		})
 
-		// Clear empty session
-		s.Clear()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		serve(a, rec, req)
 
-		// Should still work
-		s.Set("key", "value")
-		val, ok := s.Get("key")
-		if !ok || val != "value" {
-			t.Error("session should work after clearing empty session")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
 		}
-
-		return c.String(http.StatusOK, "ok")
 	})
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	a.ServeHTTP(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
 }
 
 func TestSessionStartCleanupError(t *testing.T) {
@@ -815,92 +842,96 @@ func TestNewSessionIDWithShortLength(t *testing.T) {
 }
 
 func TestSessionEdgeCases(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		a.Use(Sessions(SessionConfig{Store: store, TTL: time.Hour}))
 
-	a.GET("/test", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
+		a.GET("/test", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
 
-		// Test session operations on new session
-		if !s.IsNew() {
-			t.Error("expected new session to be marked as new")
-		}
+			// Test session operations on new session
+			if !s.IsNew() {
+				t.Error("expected new session to be marked as new")
+			}
 
-		// Test getting non-existent key
-		val, ok := s.Get("nonexistent")
-		if ok {
-			t.Error("expected false for non-existent key")
-		}
-		if val != nil {
-			t.Errorf("expected nil for non-existent key, got %v", val)
-		}
+			// Test getting non-existent key
+			val, ok := s.Get("nonexistent")
+			if ok {
+				t.Error("expected false for non-existent key")
+			}
+			if val != nil {
+				t.Errorf("expected nil for non-existent key, got %v", val)
+			}
 
-		// Test deleting non-existent key
-		s.Delete("nonexistent") // Should not panic
+			// Test deleting non-existent key
+			s.Delete("nonexistent") // Should not panic
 
-		return c.String(http.StatusOK, "ok")
-	})
+			return c.String(http.StatusOK, "ok")
+		})
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/test", nil)
-	a.ServeHTTP(rec, req)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		serve(a, rec, req)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	})
 }
 
 func TestSessionWithLongTTL(t *testing.T) {
-	store := NewMemoryStore()
-	a := flash.New()
-	// Use very short TTL for testing
-	a.Use(Sessions(SessionConfig{Store: store, TTL: 1 * time.Millisecond}))
-
-	a.GET("/set", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		s.Set("key", "value")
-		return c.String(http.StatusOK, "set")
-	})
-
-	a.GET("/get", func(c flash.Ctx) error {
-		s := SessionFromCtx(c)
-		val, ok := s.Get("key")
-		if ok {
-			return c.String(http.StatusOK, val.(string))
-		}
-		return c.String(http.StatusNotFound, "not found")
-	})
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		store := NewMemoryStore()
+		a := flash.New()
+		// Use very short TTL for testing
+		a.Use(Sessions(SessionConfig{Store: store, TTL: 1 * time.Millisecond}))
+
+		a.GET("/set", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			s.Set("key", "value")
+			return c.String(http.StatusOK, "set")
+		})
+
+		a.GET("/get", func(c flash.Ctx) error {
+			s := SessionFromCtx(c)
+			val, ok := s.Get("key")
+			if ok {
+				return c.String(http.StatusOK, val.(string))
+			}
+			return c.String(http.StatusNotFound, "not found")
+		})
 
-	// Set session
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/set", nil)
-	a.ServeHTTP(rec, req)
+		// Set session
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/set", nil)
+		serve(a, rec, req)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected 200, got %d", rec.Code)
-	}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
 
-	cookies := rec.Result().Cookies()
-	if len(cookies) == 0 {
-		t.Fatal("expected session cookie")
-	}
+		cookies := rec.Result().Cookies()
+		if len(cookies) == 0 {
+			t.Fatal("expected session cookie")
+		}
 
-	// Wait for session to expire
-	time.Sleep(2 * time.Millisecond)
+		// Wait for session to expire
+		time.Sleep(2 * time.Millisecond)
 
-	// Try to get expired session
-	rec2 := httptest.NewRecorder()
-	req2 := httptest.NewRequest(http.MethodGet, "/get", nil)
-	for _, cookie := range cookies {
-		req2.AddCookie(cookie)
-	}
-	a.ServeHTTP(rec2, req2)
+		// Try to get expired session
+		rec2 := httptest.NewRecorder()
+		req2 := httptest.NewRequest(http.MethodGet, "/get", nil)
+		for _, cookie := range cookies {
+			req2.AddCookie(cookie)
+		}
+		serve(a, rec2, req2)
 
-	// Should not find the expired session
-	if rec2.Code != http.StatusNotFound {
-		t.Errorf("expected 404 for expired session, got %d", rec2.Code)
-	}
+		// Should not find the expired session
+		if rec2.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for expired session, got %d", rec2.Code)
+		}
+	})
 }
 
 func TestSessionStartCleanupEdgeCase(t *testing.T) {
@@ -1,13 +1,16 @@
 package middleware
 
 import (
+	"bufio"
 	"context"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/ctx"
 )
 
 // TimeoutConfig configures the timeout middleware.
@@ -19,6 +22,11 @@ import (
 //
 // Note: For request size limiting, use the dedicated RequestSize middleware instead.
 //
+// Streaming responses (Ctx.SSE) are passed through as they are written, and
+// connections can be hijacked (WebSocket routes). When the timeout expires
+// after that, the request context is cancelled, which ends the stream or
+// closes the WebSocket; no timeout response is written.
+//
 // Example:
 //
 //	cfg := middleware.TimeoutConfig{
@@ -42,10 +50,13 @@ type TimeoutConfig struct {
 
 // timeoutWriter buffers header mutations locally and writes to the real writer under a mutex.
 // After a timeout occurs, all handler writes are dropped, while the timeout path writes exclusively.
+// Once the handler streams (Stream) or hijacks the connection, a timeout no longer takes over the response.
 type timeoutWriter struct {
 	w           http.ResponseWriter
 	mu          sync.Mutex
 	timedOut    bool
+	streaming   bool
+	hijacked    bool
 	header      http.Header
 	wroteHeader bool
 	status      int
@@ -119,6 +130,49 @@ func (tw *timeoutWriter) Flush() {
 	}
 }
 
+// Stream marks the response as streamed, unless the timeout already took it
+// over, and passes the call on to the underlying writer.
+func (tw *timeoutWriter) Stream() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.streaming = true
+	ctx.StreamWriter(tw.w)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter { return tw.w }
+
+// Hijack passes the hijack on to the underlying writer, unless the timeout
+// already took over the response, in which case http.ErrHandlerTimeout is
+// returned.
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, brw, err := http.NewResponseController(tw.w).Hijack()
+	if err == nil {
+		tw.hijacked = true
+	}
+	return conn, brw, err
+}
+
+// timeout drops further handler writes and reports true, unless the handler
+// is streaming or hijacked the connection.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.streaming || tw.hijacked {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
 // timeoutResponder has its own header map to be used by the timeout path only.
 // It serializes writes to the underlying writer using the timeoutWriter mutex.
 type timeoutResponder struct {
@@ -214,17 +268,18 @@ func Timeout(cfg TimeoutConfig) flash.Middleware {
 
 	return func(next flash.Handler) flash.Handler {
 		return func(c flash.Ctx) error {
-			ctx, cancel := context.WithTimeout(c.Context(), cfg.Duration)
+			tctx, cancel := context.WithTimeout(c.Context(), cfg.Duration)
 			defer cancel()
 
 			// Update the original request context for any downstream usage in timeout path
-			c.SetRequest(c.Request().WithContext(ctx))
+			c.SetRequest(c.Request().WithContext(tctx))
 
-			// Prepare a shallow copy of the context for the handler goroutine to avoid races
+			// Prepare a copy of the context for the handler goroutine to avoid races.
+			// On fasthttp the copy no longer reads the fasthttp request, which is
+			// reused once a timed out request returns while the handler still runs
 			copyCtx := c.Clone()
 			tw := newTimeoutWriter(c.ResponseWriter())
 			copyCtx.SetResponseWriter(tw)
-			copyCtx.SetRequest(c.Request())
 
 			done := make(chan error, 1)
 			go func() {
@@ -240,13 +295,18 @@ func Timeout(cfg TimeoutConfig) flash.Middleware {
 			select {
 			case err := <-done:
 				return err
-			case <-ctx.Done():
+			case <-tctx.Done():
 				// If handler completed concurrently, prefer it to avoid double writes
 				select {
 				case err := <-done:
 					return err
 				default:
 				}
+				// A streaming or hijacking handler already sent its
+				// response and ends with the cancelled context
+				if !tw.timeout() {
+					return <-done
+				}
 				// Route timeout response through timeoutResponder to serialize writes
 				tr := newTimeoutResponder(tw)
 				c.SetResponseWriter(tr)
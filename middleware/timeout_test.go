@@ -1,59 +1,72 @@
 package middleware
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/goflash/flash/v2/internal/wstest"
+	"github.com/goflash/flash/v2/websocket"
 )
 
 func TestTimeoutMiddleware(t *testing.T) {
-	a := flash.New()
-	a.GET("/slow", func(c flash.Ctx) error {
-		time.Sleep(50 * time.Millisecond)
-		return c.String(http.StatusOK, "ok")
-	}, Timeout(TimeoutConfig{Duration: 10 * time.Millisecond}))
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusGatewayTimeout {
-		t.Fatalf("expected 504, got %d", rec.Code)
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		a.GET("/slow", func(c flash.Ctx) error {
+			time.Sleep(50 * time.Millisecond)
+			return c.String(http.StatusOK, "ok")
+		}, Timeout(TimeoutConfig{Duration: 10 * time.Millisecond}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusGatewayTimeout {
+			t.Fatalf("expected 504, got %d", rec.Code)
+		}
+	})
 }
 
 func TestTimeoutOnTimeoutAndCustomErrorResponse(t *testing.T) {
-	called := false
-	a := flash.New()
-	a.GET("/slow2", func(c flash.Ctx) error {
-		time.Sleep(20 * time.Millisecond)
-		return c.String(http.StatusOK, "ok")
-	}, Timeout(TimeoutConfig{Duration: 5 * time.Millisecond, OnTimeout: func(c flash.Ctx) { called = true }, ErrorResponse: func(c flash.Ctx) error { return c.String(599, "custom") }}))
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/slow2", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != 599 || rec.Body.String() != "custom" {
-		t.Fatalf("expected custom 599, got %d %q", rec.Code, rec.Body.String())
-	}
-	if !called {
-		t.Fatalf("OnTimeout not called")
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		called := false
+		a := flash.New()
+		a.GET("/slow2", func(c flash.Ctx) error {
+			time.Sleep(20 * time.Millisecond)
+			return c.String(http.StatusOK, "ok")
+		}, Timeout(TimeoutConfig{Duration: 5 * time.Millisecond, OnTimeout: func(c flash.Ctx) { called = true }, ErrorResponse: func(c flash.Ctx) error { return c.String(599, "custom") }}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/slow2", nil)
+		serve(a, rec, req)
+		if rec.Code != 599 || rec.Body.String() != "custom" {
+			t.Fatalf("expected custom 599, got %d %q", rec.Code, rec.Body.String())
+		}
+		if !called {
+			t.Fatalf("OnTimeout not called")
+		}
+	})
 }
 
 func TestTimeoutDefaultDurationNoTimeout(t *testing.T) {
-	a := flash.New()
-	// Duration is zero -> defaults internally to 5s; handler returns immediately so no timeout
-	a.GET("/fast", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") }, Timeout(TimeoutConfig{}))
-
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/fast", nil)
-	a.ServeHTTP(rec, req)
-	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
-		t.Fatalf("expected 200 ok, got %d %q", rec.Code, rec.Body.String())
-	}
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		a := flash.New()
+		// Duration is zero -> defaults internally to 5s; handler returns immediately so no timeout
+		a.GET("/fast", func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") }, Timeout(TimeoutConfig{}))
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+		serve(a, rec, req)
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Fatalf("expected 200 ok, got %d %q", rec.Code, rec.Body.String())
+		}
+	})
 }
 
 // simpleWriter is a minimal http.ResponseWriter that does NOT implement http.Flusher
@@ -315,53 +328,211 @@ func TestTimeoutResponderWriteHeaderAlreadyWritten(t *testing.T) {
 }
 
 func TestTimeoutMiddlewareWithPanicInHandler(t *testing.T) {
-	// Test timeout middleware when handler panics
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		// Test timeout middleware when handler panics
+		a := flash.New()
+		a.Use(Timeout(TimeoutConfig{Duration: 100 * time.Millisecond}))
+
+		a.GET("/panic", func(c flash.Ctx) error {
+			panic("test panic")
+		})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		serve(a, rec, req)
+
+		// Should handle panic gracefully
+		if rec.Code == 0 {
+			t.Error("expected some status code to be set")
+		}
+	})
+}
+
+func TestTimeoutMiddlewareWithCustomErrorResponse(t *testing.T) {
+	transporttest.Each(t, func(t *testing.T, serve transporttest.ServeWith) {
+		// Test timeout middleware with custom error response
+		a := flash.New()
+		customCalled := false
+
+		a.Use(Timeout(TimeoutConfig{
+			Duration: 1 * time.Millisecond,
+			ErrorResponse: func(c flash.Ctx) error {
+				customCalled = true
+				return c.String(http.StatusRequestTimeout, "Custom timeout")
+			},
+		}))
+
+		a.GET("/slow", func(c flash.Ctx) error {
+			time.Sleep(10 * time.Millisecond) // Longer than timeout
+			return c.String(http.StatusOK, "success")
+		})
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+		serve(a, rec, req)
+
+		if !customCalled {
+			t.Error("expected custom error response to be called")
+		}
+		if rec.Code != http.StatusRequestTimeout {
+			t.Errorf("expected status 408, got %d", rec.Code)
+		}
+		if rec.Body.String() != "Custom timeout" {
+			t.Errorf("expected custom timeout message, got %s", rec.Body.String())
+		}
+	})
+}
+
+func TestTimeoutEndsSSEStream(t *testing.T) {
 	a := flash.New()
 	a.Use(Timeout(TimeoutConfig{Duration: 100 * time.Millisecond}))
-
-	a.GET("/panic", func(c flash.Ctx) error {
-		panic("test panic")
+	a.GET("/events", func(c flash.Ctx) error {
+		return c.SSE(func(s ctx.SSEStream) error {
+			for {
+				if err := s.Send(ctx.Event{Data: "tick"}); err != nil {
+					return err
+				}
+				select {
+				case <-s.Context().Done():
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		})
 	})
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
-	a.ServeHTTP(rec, req)
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Get(url + "/events")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status=%d", resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body) // returns once the timeout ends the stream
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(body), "Gateway Timeout") || strings.Count(string(body), "data: tick\n\n") < 2 {
+				t.Fatalf("body=%q", body)
+			}
+		})
+	}
+}
 
-	// Should handle panic gracefully
-	if rec.Code == 0 {
-		t.Error("expected some status code to be set")
+func TestTimeoutWriterStream(t *testing.T) {
+	tw := newTimeoutWriter(httptest.NewRecorder())
+	tw.Stream()
+	if tw.timeout() {
+		t.Fatal("a streaming response must not time out")
+	}
+	if tw.Unwrap() != tw.w {
+		t.Fatal("Unwrap must return the wrapped writer")
+	}
+
+	tw = newTimeoutWriter(httptest.NewRecorder())
+	if !tw.timeout() {
+		t.Fatal("expected timeout")
+	}
+	tw.Stream()
+	if tw.streaming {
+		t.Fatal("Stream after a timeout must not stream")
 	}
 }
 
-func TestTimeoutMiddlewareWithCustomErrorResponse(t *testing.T) {
-	// Test timeout middleware with custom error response
+func TestTimeoutClosesWebSocket(t *testing.T) {
 	a := flash.New()
-	customCalled := false
-
-	a.Use(Timeout(TimeoutConfig{
-		Duration: 1 * time.Millisecond,
-		ErrorResponse: func(c flash.Ctx) error {
-			customCalled = true
-			return c.String(http.StatusRequestTimeout, "Custom timeout")
-		},
-	}))
-
-	a.GET("/slow", func(c flash.Ctx) error {
-		time.Sleep(10 * time.Millisecond) // Longer than timeout
-		return c.String(http.StatusOK, "success")
+	a.Use(Timeout(TimeoutConfig{Duration: 100 * time.Millisecond}))
+	a.WS("/ws", func(c flash.Ctx, conn *websocket.Conn) error {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return err
+			}
+		}
 	})
 
-	rec := httptest.NewRecorder()
-	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
-	a.ServeHTTP(rec, req)
+	for name, url := range liveServers(t, a) {
+		t.Run(name, func(t *testing.T) {
+			client, resp := wstest.Dial(t, url+"/ws", nil)
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("status=%d", resp.StatusCode)
+			}
+			client.Write(wstest.OpText, []byte("still here"))
+			if code := client.ReadClose(); code != websocket.CloseGoingAway {
+				t.Fatalf("close code %d", code)
+			}
+		})
+	}
+}
+
+func TestTimeoutWriterHijack(t *testing.T) {
+	tw := newTimeoutWriter(&hijackableRecorder{ResponseRecorder: httptest.NewRecorder()})
+	conn, _, err := tw.Hijack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if tw.timeout() {
+		t.Fatal("a hijacked connection must not time out")
+	}
 
-	if !customCalled {
-		t.Error("expected custom error response to be called")
+	tw = newTimeoutWriter(&hijackableRecorder{ResponseRecorder: httptest.NewRecorder()})
+	if !tw.timeout() {
+		t.Fatal("expected timeout")
 	}
-	if rec.Code != http.StatusRequestTimeout {
-		t.Errorf("expected status 408, got %d", rec.Code)
+	if _, _, err := tw.Hijack(); err != http.ErrHandlerTimeout {
+		t.Fatalf("Hijack after a timeout: %v", err)
 	}
-	if rec.Body.String() != "Custom timeout" {
-		t.Errorf("expected custom timeout message, got %s", rec.Body.String())
+
+	tw = newTimeoutWriter(httptest.NewRecorder())
+	if _, _, err := tw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Fatalf("Hijack without a Hijacker: %v", err)
+	}
+}
+
+func TestTimeoutOverrunningHandlerOnFastHTTP(t *testing.T) {
+	release := make(chan struct{})
+	seen := make(chan string, 1)
+	a := flash.New()
+	a.POST("/slow/:id", func(c flash.Ctx) error {
+		<-release
+		// The fasthttp request has been reused by the next request by now
+		body, _ := io.ReadAll(c.Request().Body)
+		seen <- strings.Join([]string{c.Method(), c.Path(), c.Param("id"), c.Query("q"), c.Request().Header.Get("X-Req"), string(body)}, " ")
+		return c.String(http.StatusOK, "late")
+	}, Timeout(TimeoutConfig{Duration: 20 * time.Millisecond}))
+	a.PUT("/other/:name", func(c flash.Ctx) error { return c.String(http.StatusOK, "other") })
+
+	url := liveServers(t, a)["fasthttp"]
+	client := &http.Client{Transport: &http.Transport{MaxConnsPerHost: 1}}
+	defer client.CloseIdleConnections()
+	send := func(method, path, body, hdr string) *http.Response {
+		req, _ := http.NewRequest(method, url+path, strings.NewReader(body))
+		req.Header.Set("X-Req", hdr)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := send(http.MethodPost, "/slow/first?q=one", "first body", "a"); resp.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", resp.StatusCode)
+	}
+	// Same connection, so fasthttp reuses the request of the timed out handler
+	if resp := send(http.MethodPut, "/other/XXXXX?q=two", "overwritten body", "b"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	close(release)
+	select {
+	case got := <-seen:
+		if want := "POST /slow/first first one a first body"; got != want {
+			t.Fatalf("handler saw %q, want %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not finish")
 	}
 }
@@ -0,0 +1,285 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2"
+	"github.com/goflash/flash/v2/internal/transporttest"
+	"github.com/valyala/fasthttp"
+)
+
+// Every bundled middleware must behave the same on net/http and fasthttp.
+// Each case builds a fresh app per transport and drives it through
+// transporttest, so the assertions are shared.
+
+func serverOf(t *testing.T, a flash.App) transporttest.Server {
+	t.Helper()
+	s, ok := a.(transporttest.Server)
+	if !ok {
+		t.Fatal("app does not serve fasthttp")
+	}
+	return s
+}
+
+// liveServers serves a on both transports over real connections, for
+// streaming responses that transporttest cannot observe, and returns the
+// URL of each server by transport name.
+func liveServers(t *testing.T, a flash.App) map[string]string {
+	t.Helper()
+	s := serverOf(t, a)
+	hs := httptest.NewServer(s)
+	t.Cleanup(hs.Close)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := &fasthttp.Server{Handler: s.ServeFastHTTP}
+	go func() { _ = fs.Serve(ln) }()
+	t.Cleanup(func() { _ = fs.Shutdown() })
+	return map[string]string{"net/http": hs.URL, "fasthttp": "http://" + ln.Addr().String()}
+}
+
+func TestMiddlewareTransportMatrix(t *testing.T) {
+	ok := func(c flash.Ctx) error { return c.String(http.StatusOK, "ok") }
+
+	tests := []struct {
+		name string
+		app  func() flash.App
+		run  func(t *testing.T, serve transporttest.ServeFunc)
+	}{
+		{
+			name: "Buffer",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(Buffer())
+				a.GET("/", func(c flash.Ctx) error {
+					c.Header("X-Handler", "1")
+					return c.JSON(map[string]string{"hello": "world"})
+				})
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code != http.StatusOK || rec.Body.String() != `{"hello":"world"}` {
+					t.Fatalf("got %d %q", rec.Code, rec.Body.String())
+				}
+				if rec.Header().Get("Content-Length") != "17" || rec.Header().Get("X-Handler") != "1" {
+					t.Fatalf("headers=%v", rec.Header())
+				}
+			},
+		},
+		{
+			name: "CORS",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(CORS(CORSConfig{Origins: []string{"https://app.example"}, Credentials: true}))
+				a.GET("/", ok)
+				a.OPTIONS("/", ok)
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Origin", "https://app.example")
+				rec := serve(req)
+				if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+					t.Fatalf("Allow-Origin=%q", got)
+				}
+
+				req = httptest.NewRequest(http.MethodOptions, "/", nil)
+				req.Header.Set("Origin", "https://app.example")
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				rec = serve(req)
+				if rec.Header().Get("Access-Control-Allow-Methods") == "" {
+					t.Fatalf("preflight headers=%v", rec.Header())
+				}
+			},
+		},
+		{
+			name: "CSRF",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(CSRF())
+				a.GET("/", ok)
+				a.POST("/", ok)
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+				cookies := rec.Result().Cookies()
+				if len(cookies) != 1 || cookies[0].Value == "" {
+					t.Fatalf("expected CSRF cookie, got %v", rec.Header())
+				}
+
+				if rec := serve(httptest.NewRequest(http.MethodPost, "/", nil)); rec.Code != http.StatusForbidden {
+					t.Fatalf("POST without token: %d", rec.Code)
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/", nil)
+				req.AddCookie(cookies[0])
+				req.Header.Set(DefaultCSRFConfig().HeaderName, cookies[0].Value)
+				if rec := serve(req); rec.Code != http.StatusOK {
+					t.Fatalf("POST with token: %d %q", rec.Code, rec.Body.String())
+				}
+			},
+		},
+		{
+			name: "RateLimit",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(RateLimit(WithStrategy(NewFixedWindowStrategy(1, time.Minute))))
+				a.GET("/", ok)
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				if rec := serve(httptest.NewRequest(http.MethodGet, "/", nil)); rec.Code != http.StatusOK {
+					t.Fatalf("first request: %d", rec.Code)
+				}
+				rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+					t.Fatalf("second request: %d %v", rec.Code, rec.Header())
+				}
+			},
+		},
+		{
+			name: "Recover",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(Recover())
+				a.GET("/", func(c flash.Ctx) error { panic("boom") })
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code != http.StatusInternalServerError || rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+					t.Fatalf("got %d %v", rec.Code, rec.Header())
+				}
+			},
+		},
+		{
+			name: "RequestID",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(RequestID())
+				a.GET("/", func(c flash.Ctx) error {
+					id, _ := RequestIDFromContext(c.Context())
+					return c.String(http.StatusOK, id)
+				})
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+				if id := rec.Header().Get("X-Request-ID"); id == "" || rec.Body.String() != id {
+					t.Fatalf("header=%q body=%q", id, rec.Body.String())
+				}
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("X-Request-ID", "given")
+				if rec := serve(req); rec.Body.String() != "given" {
+					t.Fatalf("body=%q", rec.Body.String())
+				}
+			},
+		},
+		{
+			name: "RequestSize",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(RequestSize(RequestSizeConfig{MaxSize: 10}))
+				a.POST("/", ok)
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				if rec := serve(httptest.NewRequest(http.MethodPost, "/", strings.NewReader("small"))); rec.Code != http.StatusOK {
+					t.Fatalf("small body: %d", rec.Code)
+				}
+				rec := serve(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", 100))))
+				if rec.Code != http.StatusRequestEntityTooLarge {
+					t.Fatalf("large body: %d", rec.Code)
+				}
+			},
+		},
+		{
+			name: "Sessions",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(Sessions(SessionConfig{}))
+				a.POST("/login", func(c flash.Ctx) error {
+					SessionFromCtx(c).Set("user", "ada")
+					return c.String(http.StatusOK, "ok")
+				})
+				a.GET("/me", func(c flash.Ctx) error {
+					v, _ := SessionFromCtx(c).Get("user")
+					s, _ := v.(string)
+					return c.String(http.StatusOK, s)
+				})
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				rec := serve(httptest.NewRequest(http.MethodPost, "/login", nil))
+				cookies := rec.Result().Cookies()
+				if len(cookies) != 1 {
+					t.Fatalf("expected session cookie, got %v", rec.Header())
+				}
+				req := httptest.NewRequest(http.MethodGet, "/me", nil)
+				req.AddCookie(cookies[0])
+				if rec := serve(req); rec.Body.String() != "ada" {
+					t.Fatalf("body=%q", rec.Body.String())
+				}
+			},
+		},
+		{
+			name: "Timeout",
+			app: func() flash.App {
+				a := flash.New()
+				a.Use(Timeout(TimeoutConfig{Duration: 20 * time.Millisecond}))
+				a.GET("/fast", ok)
+				a.GET("/slow", func(c flash.Ctx) error {
+					time.Sleep(200 * time.Millisecond)
+					return c.String(http.StatusOK, "late")
+				})
+				return a
+			},
+			run: func(t *testing.T, serve transporttest.ServeFunc) {
+				if rec := serve(httptest.NewRequest(http.MethodGet, "/fast", nil)); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+					t.Fatalf("fast: %d %q", rec.Code, rec.Body.String())
+				}
+				if rec := serve(httptest.NewRequest(http.MethodGet, "/slow", nil)); rec.Code != http.StatusGatewayTimeout {
+					t.Fatalf("slow: %d", rec.Code)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transporttest.RunNew(t, func() transporttest.Server { return serverOf(t, tt.app()) }, tt.run)
+		})
+	}
+}
+
+func TestLoggerTransportMatrix(t *testing.T) {
+	var buf bytes.Buffer
+	a := flash.New()
+	a.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	a.Use(Logger())
+	a.GET("/", func(c flash.Ctx) error { return c.String(http.StatusCreated, "made") })
+
+	transporttest.Run(t, serverOf(t, a), func(t *testing.T, serve transporttest.ServeFunc) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", "matrix")
+		serve(req)
+		out := buf.String()
+		for _, want := range []string{`"status":201`, `"user_agent":"matrix"`, `"route":"/"`, `"remote":"192.0.2.1:1234"`} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("log %q missing %s", out, want)
+			}
+		}
+	})
+}
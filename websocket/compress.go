@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// permessage-deflate (RFC 7692) without context takeover: every message is
+// compressed on its own, so writers and readers are pooled instead of being
+// kept per connection.
+
+// deflateTail ends the deflate data of a message: the empty stored block a
+// sender removes from each message, followed by a final empty block so the
+// reader stops at the end of the message.
+const deflateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+var (
+	flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	flateReaderPool  sync.Pool
+)
+
+// offersDeflate reports whether the client offers permessage-deflate with
+// parameters the server can accept.
+func offersDeflate(h http.Header) bool {
+	for _, ext := range headerTokens(h, "Sec-WebSocket-Extensions") {
+		params := strings.Split(ext, ";")
+		if strings.TrimSpace(params[0]) != "permessage-deflate" {
+			continue
+		}
+		ok := true
+		for _, p := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(p), "=")
+			switch strings.TrimSpace(name) {
+			case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+			case "server_max_window_bits":
+				// compress/flate always uses a 32 KiB window
+				ok = ok && strings.Trim(strings.TrimSpace(value), `"`) == "15"
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compress returns p deflated at level, without the trailing empty block.
+func compress(p []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	pool := &flateWriterPools[level-flate.HuffmanOnly]
+	fw, _ := pool.Get().(*flate.Writer)
+	if fw == nil {
+		var err error
+		if fw, err = flate.NewWriter(&buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		fw.Reset(&buf)
+	}
+	defer pool.Put(fw)
+	if _, err := fw.Write(p); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte(deflateTail[:4]))
+	if len(b) == 0 {
+		// An empty message is sent as a single empty block header
+		b = []byte{0}
+	}
+	return b, nil
+}
+
+// decompress inflates the payload p of a compressed message. More than
+// limit bytes (if limit >= 0) fail with ErrReadLimit.
+func decompress(p []byte, limit int64) ([]byte, error) {
+	src := io.MultiReader(bytes.NewReader(p), strings.NewReader(deflateTail))
+	fr, _ := flateReaderPool.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(src)
+	} else if err := fr.(flate.Resetter).Reset(src, nil); err != nil {
+		return nil, err
+	}
+	defer flateReaderPool.Put(fr)
+	var r io.Reader = fr
+	if limit >= 0 {
+		r = io.LimitReader(fr, limit+1)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 && int64(len(b)) > limit {
+		return nil, ErrReadLimit
+	}
+	return b, nil
+}
@@ -0,0 +1,39 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	for _, level := range []int{flate.HuffmanOnly, flate.BestSpeed, flate.BestCompression} {
+		for _, msg := range [][]byte{{}, []byte("x"), bytes.Repeat([]byte("abc"), 10000)} {
+			p, err := compress(msg, level)
+			require.NoError(t, err)
+			assert.NotEmpty(t, p)
+			out, err := decompress(p, -1)
+			require.NoError(t, err)
+			assert.Equal(t, msg, out)
+			// again, with pooled writers and readers
+			p2, err := compress(msg, level)
+			require.NoError(t, err)
+			assert.Equal(t, p, p2)
+		}
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	p, err := compress(bytes.Repeat([]byte("a"), 100), flate.BestSpeed)
+	require.NoError(t, err)
+	out, err := decompress(p, 100)
+	require.NoError(t, err)
+	assert.Len(t, out, 100)
+	_, err = decompress(p, 99)
+	assert.ErrorIs(t, err, ErrReadLimit)
+	_, err = decompress([]byte{0xff, 0xff, 0xff}, -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,516 @@
+package websocket
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// MessageType is the type of a data message.
+type MessageType int
+
+// Message types (RFC 6455 section 5.6).
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+// Close status codes (RFC 6455 section 7.4.1).
+const (
+	CloseNormalClosure       = 1000
+	CloseGoingAway           = 1001
+	CloseProtocolError       = 1002
+	CloseUnsupportedData     = 1003
+	CloseNoStatusReceived    = 1005
+	CloseAbnormalClosure     = 1006
+	CloseInvalidPayload      = 1007
+	ClosePolicyViolation     = 1008
+	CloseMessageTooBig       = 1009
+	CloseInternalServerError = 1011
+)
+
+// Frame opcodes.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// Frame header bits.
+const (
+	finBit  = 0x80
+	rsv1Bit = 0x40
+	rsv2Bit = 0x20
+	rsv3Bit = 0x10
+	maskBit = 0x80
+)
+
+// maxControlPayload is the largest payload of a control frame.
+const maxControlPayload = 125
+
+var (
+	// ErrReadLimit is returned by ReadMessage for a message larger than the
+	// read limit. The connection is closed with status 1009.
+	ErrReadLimit = errors.New("websocket: message exceeds the read limit")
+	// ErrClosed is returned when writing after the connection was closed.
+	ErrClosed = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by ReadMessage when the peer closes the
+// connection, with the status code and reason it sent.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Text)
+}
+
+// IsCloseError reports whether err is a *CloseError with one of codes.
+//
+// Example:
+//
+//	if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+//		return nil // the client left
+//	}
+func IsCloseError(err error, codes ...int) bool {
+	var ce *CloseError
+	if !errors.As(err, &ce) {
+		return false
+	}
+	for _, code := range codes {
+		if ce.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// protocolError is a violation of the protocol by the peer, answered with a
+// close frame carrying code.
+type protocolError struct {
+	code int
+	msg  string
+}
+
+func (e *protocolError) Error() string { return "websocket: " + e.msg }
+
+// Conn is a WebSocket connection returned by Upgrade.
+//
+// Reading is done by one goroutine at a time, with ReadMessage or ReadJSON;
+// pings are answered and closes are handled while reading. The write
+// methods, Ping and Close may be called concurrently with each other and
+// with reading.
+type Conn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+	compress    bool
+	level       int
+
+	readLimit    int64
+	pingInterval time.Duration
+	writeTimeout time.Duration
+	readErr      error // sticky error of ReadMessage
+
+	wmu       sync.Mutex // serializes frames
+	wbuf      []byte
+	closeSent bool
+
+	done      chan struct{} // closed by Close, stops keepalive
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// newConn returns the Conn for a connection upgraded with cfg, which has
+// its defaults applied, reading from br, and starts keepalive.
+func newConn(conn net.Conn, br *bufio.Reader, cfg Config, subprotocol string, compress bool) *Conn {
+	c := &Conn{
+		conn:         conn,
+		br:           br,
+		subprotocol:  subprotocol,
+		compress:     compress,
+		level:        cfg.CompressionLevel,
+		readLimit:    cfg.ReadLimit,
+		pingInterval: cfg.PingInterval,
+		writeTimeout: cfg.WriteTimeout,
+		done:         make(chan struct{}),
+	}
+	if c.pingInterval > 0 {
+		go c.keepalive()
+	}
+	return c
+}
+
+// Subprotocol returns the subprotocol selected during the handshake, or "".
+func (c *Conn) Subprotocol() string { return c.subprotocol }
+
+// Compressed reports whether permessage-deflate was negotiated.
+func (c *Conn) Compressed() bool { return c.compress }
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// LocalAddr returns the local address of the connection.
+func (c *Conn) LocalAddr() net.Addr { return c.conn.LocalAddr() }
+
+// SetReadLimit sets the maximum size of messages read; negative means no
+// limit. It replaces Config.ReadLimit for the connection.
+func (c *Conn) SetReadLimit(n int64) { c.readLimit = n }
+
+// ReadMessage reads the next data message. Pings received meanwhile are
+// answered and pongs are dropped. When the peer closes the connection, the
+// close is answered and a *CloseError is returned. Once ReadMessage fails,
+// it returns the same error on every call.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	typ, msg, err := c.readMessage()
+	if err != nil {
+		c.readErr = err
+		c.failRead(err)
+		return 0, nil, err
+	}
+	return typ, msg, nil
+}
+
+// ReadJSON reads the next data message and decodes it as JSON into v.
+func (c *Conn) ReadJSON(v any) error {
+	_, msg, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return jsoniter.ConfigDefault.Unmarshal(msg, v)
+}
+
+// readMessage reads the frames of the next data message.
+func (c *Conn) readMessage() (MessageType, []byte, error) {
+	var (
+		typ        MessageType
+		msg        []byte
+		compressed bool
+	)
+	for {
+		h, err := c.readFrame(len(msg), compressed)
+		if err != nil {
+			return 0, nil, err
+		}
+		switch h.op {
+		case opPing:
+			if err := c.writeFrame(opPong, false, h.payload); err != nil && !errors.Is(err, ErrClosed) {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, c.handleClose(h.payload)
+		case opText, opBinary:
+			if typ != 0 {
+				return 0, nil, &protocolError{CloseProtocolError, "new message before the previous one ended"}
+			}
+			typ, compressed = MessageType(h.op), h.rsv1
+		case opContinuation:
+			if typ == 0 {
+				return 0, nil, &protocolError{CloseProtocolError, "continuation frame without a message"}
+			}
+			if h.rsv1 {
+				return 0, nil, &protocolError{CloseProtocolError, "RSV1 set on a continuation frame"}
+			}
+		default:
+			return 0, nil, &protocolError{CloseProtocolError, fmt.Sprintf("unknown opcode %#x", h.op)}
+		}
+		msg = append(msg, h.payload...)
+		if h.fin {
+			break
+		}
+	}
+	if compressed {
+		var err error
+		if msg, err = decompress(msg, c.readLimit); err != nil {
+			if errors.Is(err, ErrReadLimit) {
+				return 0, nil, err
+			}
+			return 0, nil, &protocolError{CloseInvalidPayload, "invalid compressed message"}
+		}
+	}
+	if typ == TextMessage && !utf8.Valid(msg) {
+		return 0, nil, &protocolError{CloseInvalidPayload, "text message is not valid UTF-8"}
+	}
+	return typ, msg, nil
+}
+
+// frame is a frame read from the peer.
+type frame struct {
+	fin     bool
+	rsv1    bool
+	op      byte
+	payload []byte
+}
+
+// readFrame reads the next frame; read is the size of the data message read
+// so far and compressed whether it is compressed, for the read limit.
+func (c *Conn) readFrame(read int, compressed bool) (frame, error) {
+	if c.pingInterval > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+	}
+	var hdr [14]byte
+	if _, err := io.ReadFull(c.br, hdr[:2]); err != nil {
+		return frame{}, err
+	}
+	f := frame{fin: hdr[0]&finBit != 0, rsv1: hdr[0]&rsv1Bit != 0, op: hdr[0] & 0x0f}
+	if hdr[0]&(rsv2Bit|rsv3Bit) != 0 || (f.rsv1 && !c.compress) {
+		return f, &protocolError{CloseProtocolError, "reserved bits set"}
+	}
+	if hdr[1]&maskBit == 0 {
+		return f, &protocolError{CloseProtocolError, "client frame is not masked"}
+	}
+	n := int64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		if _, err := io.ReadFull(c.br, hdr[2:4]); err != nil {
+			return f, err
+		}
+		n = int64(binary.BigEndian.Uint16(hdr[2:4]))
+	case 127:
+		if _, err := io.ReadFull(c.br, hdr[2:10]); err != nil {
+			return f, err
+		}
+		u := binary.BigEndian.Uint64(hdr[2:10])
+		if u>>63 != 0 {
+			return f, &protocolError{CloseProtocolError, "invalid payload length"}
+		}
+		n = int64(u)
+	}
+	if f.op >= opClose {
+		if n > maxControlPayload || !f.fin {
+			return f, &protocolError{CloseProtocolError, "invalid control frame"}
+		}
+		if f.rsv1 {
+			return f, &protocolError{CloseProtocolError, "RSV1 set on a control frame"}
+		}
+	} else if c.readLimit >= 0 {
+		limit := c.readLimit
+		if compressed || f.rsv1 {
+			// Deflate stores incompressible data in blocks of up to 64 KiB
+			// with 5 bytes of overhead each; the decompressed size is checked
+			// by decompress
+			limit += 5 * (limit>>16 + 1)
+		}
+		if int64(read)+n > limit {
+			return f, ErrReadLimit
+		}
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return f, err
+	}
+	f.payload = make([]byte, n)
+	if _, err := io.ReadFull(c.br, f.payload); err != nil {
+		return f, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i&3]
+	}
+	return f, nil
+}
+
+// handleClose answers a close frame with payload p and returns the
+// CloseError to report, or the protocol error the frame contains.
+func (c *Conn) handleClose(p []byte) error {
+	ce := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(p) == 1:
+		return &protocolError{CloseProtocolError, "invalid close frame"}
+	case len(p) >= 2:
+		ce.Code = int(binary.BigEndian.Uint16(p))
+		ce.Text = string(p[2:])
+		if !validCloseCode(ce.Code) || !utf8.ValidString(ce.Text) {
+			return &protocolError{CloseProtocolError, "invalid close frame"}
+		}
+	}
+	code := ce.Code
+	if code == CloseNoStatusReceived {
+		code = CloseNormalClosure
+	}
+	_ = c.writeClose(code, "")
+	c.closeConn()
+	return ce
+}
+
+// validCloseCode reports whether code may be sent in a close frame.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1014:
+		return code != 1004 && code != CloseNoStatusReceived && code != CloseAbnormalClosure
+	}
+	return false
+}
+
+// failRead closes the connection after err was read, telling the peer why
+// when it broke the protocol or sent too large a message.
+func (c *Conn) failRead(err error) {
+	var pe *protocolError
+	switch {
+	case errors.As(err, &pe):
+		_ = c.writeClose(pe.code, "")
+	case errors.Is(err, ErrReadLimit):
+		_ = c.writeClose(CloseMessageTooBig, "")
+	}
+	c.closeConn()
+}
+
+// WriteMessage writes data as a single message of type typ, compressed if
+// compression was negotiated.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	if typ != TextMessage && typ != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+	if !c.compress {
+		return c.writeFrame(byte(typ), false, data)
+	}
+	b, err := compress(data, c.level)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(byte(typ), true, b)
+}
+
+// WriteJSON writes v encoded as JSON in a text message.
+func (c *Conn) WriteJSON(v any) error {
+	b, err := jsoniter.ConfigDefault.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, b)
+}
+
+// Ping sends a ping with data, at most 125 bytes, which the peer answers
+// with a pong.
+func (c *Conn) Ping(data []byte) error {
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: ping payload too long")
+	}
+	return c.writeFrame(opPing, false, data)
+}
+
+// Close sends a close frame with status 1000 (normal closure), unless one
+// was sent, and closes the connection.
+func (c *Conn) Close() error { return c.CloseWithStatus(CloseNormalClosure, "") }
+
+// CloseWithStatus sends a close frame with code and reason, unless one was
+// sent, and closes the connection. reason is truncated to fit a control
+// frame.
+func (c *Conn) CloseWithStatus(code int, reason string) error {
+	if err := c.writeClose(code, reason); err != nil && !errors.Is(err, ErrClosed) {
+		c.closeConn()
+		return err
+	}
+	return c.closeConn()
+}
+
+// writeClose sends a close frame.
+func (c *Conn) writeClose(code int, reason string) error {
+	if len(reason) > maxControlPayload-2 {
+		reason = reason[:maxControlPayload-2]
+	}
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	return c.writeFrame(opClose, false, append(p, reason...))
+}
+
+// closeConn closes the network connection once.
+func (c *Conn) closeConn() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.closeErr = c.conn.Close()
+	})
+	return c.closeErr
+}
+
+// writeFrame writes a single unmasked frame. Nothing is written after a
+// close frame.
+func (c *Conn) writeFrame(op byte, rsv1 bool, p []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return ErrClosed
+	}
+	if op == opClose {
+		c.closeSent = true
+	}
+	b := c.wbuf[:0]
+	b0 := finBit | op
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	b = append(b, b0)
+	switch n := len(p); {
+	case n <= 125:
+		b = append(b, byte(n))
+	case n <= 0xffff:
+		b = append(b, 126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, 127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+	c.wbuf = b
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	bufs := net.Buffers{b, p}
+	_, err := bufs.WriteTo(c.conn)
+	return err
+}
+
+// keepalive pings the peer every pingInterval until the connection is
+// closed.
+func (c *Conn) keepalive() {
+	t := time.NewTicker(c.pingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := c.writeFrame(opPing, false, nil); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// withDefaults returns cfg with defaults for zero fields.
+func (cfg Config) withDefaults() Config {
+	if cfg.CompressionLevel == 0 || cfg.CompressionLevel < flate.HuffmanOnly || cfg.CompressionLevel > flate.BestCompression {
+		cfg.CompressionLevel = flate.BestSpeed
+	}
+	if cfg.ReadLimit == 0 {
+		cfg.ReadLimit = defaultReadLimit
+	}
+	if cfg.PingInterval == 0 {
+		cfg.PingInterval = defaultPingInterval
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = defaultWriteTimeout
+	}
+	return cfg
+}
@@ -0,0 +1,232 @@
+package websocket
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echo returns the messages it reads until reading fails.
+func echo(conn *Conn) error {
+	for {
+		typ, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := conn.WriteMessage(typ, msg); err != nil {
+			return err
+		}
+	}
+}
+
+func TestConnEcho(t *testing.T) {
+	addr, errs := serve(t, Config{}, echo)
+	tc, _ := dial(t, addr, nil)
+
+	tc.writeFrame(true, false, opText, []byte("hello"))
+	op, _, p := tc.readFrame()
+	assert.Equal(t, byte(opText), op)
+	assert.Equal(t, "hello", string(p))
+
+	big := bytes.Repeat([]byte{7}, 70000) // 64-bit length
+	tc.writeFrame(true, false, opBinary, big)
+	op, _, p = tc.readFrame()
+	assert.Equal(t, byte(opBinary), op)
+	assert.Equal(t, big, p)
+
+	// A fragmented message with a ping in between
+	tc.writeFrame(false, false, opText, []byte("frag"))
+	tc.writeFrame(true, false, opPing, []byte("are you there"))
+	tc.writeFrame(false, false, opContinuation, []byte("men"))
+	tc.writeFrame(true, false, opContinuation, []byte("ted"))
+	op, _, p = tc.readFrame()
+	assert.Equal(t, byte(opPong), op)
+	assert.Equal(t, "are you there", string(p))
+	op, _, p = tc.readFrame()
+	assert.Equal(t, byte(opText), op)
+	assert.Equal(t, "fragmented", string(p))
+
+	tc.writeFrame(true, false, opClose, closePayload(CloseGoingAway, "bye"))
+	assert.Equal(t, CloseGoingAway, tc.readClose(), "close is echoed")
+	err := <-errs
+	var ce *CloseError
+	require.ErrorAs(t, err, &ce)
+	assert.Equal(t, CloseGoingAway, ce.Code)
+	assert.Equal(t, "bye", ce.Text)
+	assert.True(t, IsCloseError(err, CloseNormalClosure, CloseGoingAway))
+	assert.False(t, IsCloseError(err, CloseNormalClosure))
+}
+
+func TestConnJSON(t *testing.T) {
+	addr, errs := serve(t, Config{}, func(conn *Conn) error {
+		var in struct{ N int }
+		if err := conn.ReadJSON(&in); err != nil {
+			return err
+		}
+		return conn.WriteJSON(map[string]int{"n": in.N + 1})
+	})
+	tc, _ := dial(t, addr, nil)
+	tc.writeFrame(true, false, opText, []byte(`{"N":41}`))
+	op, _, p := tc.readFrame()
+	assert.Equal(t, byte(opText), op)
+	assert.JSONEq(t, `{"n":42}`, string(p))
+	require.NoError(t, <-errs)
+	assert.Equal(t, CloseNormalClosure, tc.readClose())
+}
+
+func TestConnCloseWithoutStatus(t *testing.T) {
+	addr, errs := serve(t, Config{}, echo)
+	tc, _ := dial(t, addr, nil)
+	tc.writeFrame(true, false, opClose, nil)
+	assert.Equal(t, CloseNormalClosure, tc.readClose())
+	assert.True(t, IsCloseError(<-errs, CloseNoStatusReceived))
+}
+
+func TestConnProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		write func(tc *testClient)
+		code  int
+		err   error
+	}{
+		{"unmasked", Config{}, func(tc *testClient) { tc.writeRaw(true, false, opText, []byte("x"), false) }, CloseProtocolError, nil},
+		{"reserved bit", Config{}, func(tc *testClient) { tc.writeFrame(true, true, opText, []byte("x")) }, CloseProtocolError, nil},
+		{"unknown opcode", Config{}, func(tc *testClient) { tc.writeFrame(true, false, 0x3, nil) }, CloseProtocolError, nil},
+		{"fragmented ping", Config{}, func(tc *testClient) { tc.writeFrame(false, false, opPing, nil) }, CloseProtocolError, nil},
+		{"long ping", Config{}, func(tc *testClient) { tc.writeFrame(true, false, opPing, make([]byte, 126)) }, CloseProtocolError, nil},
+		{"lone continuation", Config{}, func(tc *testClient) { tc.writeFrame(true, false, opContinuation, []byte("x")) }, CloseProtocolError, nil},
+		{"interleaved message", Config{}, func(tc *testClient) {
+			tc.writeFrame(false, false, opText, []byte("a"))
+			tc.writeFrame(true, false, opText, []byte("b"))
+		}, CloseProtocolError, nil},
+		{"invalid close code", Config{}, func(tc *testClient) { tc.writeFrame(true, false, opClose, closePayload(1005, "")) }, CloseProtocolError, nil},
+		{"invalid UTF-8", Config{}, func(tc *testClient) { tc.writeFrame(true, false, opText, []byte{0xff, 0xfe}) }, CloseInvalidPayload, nil},
+		{"read limit", Config{ReadLimit: 8}, func(tc *testClient) { tc.writeFrame(true, false, opBinary, make([]byte, 9)) }, CloseMessageTooBig, ErrReadLimit},
+		{"read limit across fragments", Config{ReadLimit: 8}, func(tc *testClient) {
+			tc.writeFrame(false, false, opBinary, make([]byte, 5))
+			tc.writeFrame(true, false, opContinuation, make([]byte, 5))
+		}, CloseMessageTooBig, ErrReadLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, errs := serve(t, tt.cfg, echo)
+			tc, _ := dial(t, addr, nil)
+			tt.write(tc)
+			assert.Equal(t, tt.code, tc.readClose())
+			err := <-errs
+			require.Error(t, err)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestConnReadErrorIsSticky(t *testing.T) {
+	addr, errs := serve(t, Config{ReadLimit: 1}, func(conn *Conn) error {
+		_, _, err := conn.ReadMessage()
+		_, _, again := conn.ReadMessage()
+		if again != err {
+			return errors.New("read error changed")
+		}
+		if werr := conn.WriteMessage(TextMessage, []byte("x")); !errors.Is(werr, ErrClosed) {
+			return errors.New("wrote after close")
+		}
+		return err
+	})
+	tc, _ := dial(t, addr, nil)
+	tc.writeFrame(true, false, opText, []byte("xy"))
+	assert.Equal(t, CloseMessageTooBig, tc.readClose())
+	assert.ErrorIs(t, <-errs, ErrReadLimit)
+}
+
+func TestConnSetReadLimit(t *testing.T) {
+	addr, errs := serve(t, Config{ReadLimit: 1}, func(conn *Conn) error {
+		conn.SetReadLimit(-1)
+		return echo(conn)
+	})
+	tc, _ := dial(t, addr, nil)
+	tc.writeFrame(true, false, opText, []byte("no limit"))
+	_, _, p := tc.readFrame()
+	assert.Equal(t, "no limit", string(p))
+	tc.writeFrame(true, false, opClose, closePayload(CloseNormalClosure, ""))
+	tc.readClose()
+	assert.True(t, IsCloseError(<-errs, CloseNormalClosure))
+}
+
+func TestConnCompression(t *testing.T) {
+	addr, errs := serve(t, Config{EnableCompression: true, ReadLimit: 1024}, echo)
+	tc, resp := dial(t, addr, http.Header{"Sec-Websocket-Extensions": {"permessage-deflate"}})
+	require.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+
+	msg := strings.Repeat("compress me ", 50)
+	tc.writeFrame(true, true, opText, deflate(t, []byte(msg)))
+	op, rsv1, p := tc.readFrame()
+	assert.Equal(t, byte(opText), op)
+	assert.True(t, rsv1, "reply is compressed")
+	assert.Less(t, len(p), len(msg))
+	out, err := decompress(p, -1)
+	require.NoError(t, err)
+	assert.Equal(t, msg, string(out))
+
+	// Uncompressed messages are still accepted
+	tc.writeFrame(true, false, opText, []byte("plain"))
+	_, _, p = tc.readFrame()
+	out, err = decompress(p, -1)
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(out))
+
+	// The read limit applies to the decompressed size
+	tc.writeFrame(true, true, opText, deflate(t, bytes.Repeat([]byte("a"), 1025)))
+	assert.Equal(t, CloseMessageTooBig, tc.readClose())
+	assert.ErrorIs(t, <-errs, ErrReadLimit)
+}
+
+func TestConnKeepalive(t *testing.T) {
+	addr, errs := serve(t, Config{PingInterval: 20 * time.Millisecond}, echo)
+	tc, _ := dial(t, addr, nil)
+	op, _, _ := tc.readFrame()
+	assert.Equal(t, byte(opPing), op)
+
+	// A client that stops answering is dropped after two intervals
+	start := time.Now()
+	err := <-errs
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestConnCloseWithStatus(t *testing.T) {
+	addr, errs := serve(t, Config{}, func(conn *Conn) error {
+		return conn.CloseWithStatus(ClosePolicyViolation, strings.Repeat("r", 200))
+	})
+	tc, _ := dial(t, addr, nil)
+	op, _, p := tc.readFrame()
+	assert.Equal(t, byte(opClose), op)
+	assert.Len(t, p, maxControlPayload, "reason is truncated")
+	assert.Equal(t, closePayload(ClosePolicyViolation, strings.Repeat("r", maxControlPayload-2)), p)
+	require.NoError(t, <-errs)
+}
+
+func TestConnPing(t *testing.T) {
+	addr, errs := serve(t, Config{}, func(conn *Conn) error {
+		if err := conn.Ping(make([]byte, 126)); err == nil {
+			return errors.New("long ping was sent")
+		}
+		if err := conn.WriteMessage(MessageType(opPing), nil); err == nil {
+			return errors.New("control frame written as message")
+		}
+		return conn.Ping([]byte("p"))
+	})
+	tc, _ := dial(t, addr, nil)
+	op, _, p := tc.readFrame()
+	assert.Equal(t, byte(opPing), op)
+	assert.Equal(t, "p", string(p))
+	require.NoError(t, <-errs)
+}
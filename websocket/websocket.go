@@ -0,0 +1,236 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455), with the permessage-deflate extension (RFC 7692), for flash
+// handlers. Connections are upgraded from a Ctx on both the net/http and the
+// fasthttp transport.
+//
+// Most applications register WebSocket routes with App.WS, which upgrades
+// the request with the app's Config before calling the handler:
+//
+//	a.WS("/chat", func(c app.Ctx, conn *websocket.Conn) error {
+//		for {
+//			typ, msg, err := conn.ReadMessage()
+//			if err != nil {
+//				return err
+//			}
+//			if err := conn.WriteMessage(typ, msg); err != nil {
+//				return err
+//			}
+//		}
+//	})
+//
+// Upgrade can also be called from any GET handler, e.g. to choose the Config
+// per route.
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goflash/flash/v2/ctx"
+)
+
+// Config configures the handshake and the connections of Upgrade.
+type Config struct {
+	// CheckOrigin reports whether a handshake request may open a connection.
+	// By default a request is accepted when it has no Origin header
+	// (non-browser clients) or when the host of its Origin is the Host of the
+	// request, which keeps other sites from connecting with the user's
+	// cookies.
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols lists the supported subprotocols in order of preference.
+	// The first one also offered by the client in Sec-WebSocket-Protocol is
+	// selected; see Conn.Subprotocol.
+	Subprotocols []string
+	// EnableCompression negotiates permessage-deflate when the client offers
+	// it. Messages are then compressed without context takeover, so each one
+	// is compressed on its own.
+	EnableCompression bool
+	// CompressionLevel is the compress/flate level of written messages.
+	// Default (zero or an invalid level) flate.BestSpeed.
+	CompressionLevel int
+	// ReadLimit is the maximum size of a message read, after decompression.
+	// Reading a larger message fails with ErrReadLimit and closes the
+	// connection with status 1009. Default 1 MiB; negative means no limit.
+	ReadLimit int64
+	// PingInterval is the interval of the pings sent to keep the connection
+	// alive. Reading fails with a timeout when nothing, not even a pong, was
+	// received for two intervals. Default 30s; negative disables keepalive.
+	PingInterval time.Duration
+	// WriteTimeout bounds each write to the connection. Default 10s.
+	WriteTimeout time.Duration
+}
+
+// Defaults used for zero Config fields.
+const (
+	defaultReadLimit    = 1 << 20
+	defaultPingInterval = 30 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// HandshakeError is returned by Upgrade when the request is not a valid
+// WebSocket handshake or is refused. Nothing has been written to the client:
+// App.WS responds with Status, Reason and Header through the error handler.
+type HandshakeError struct {
+	// Status is the HTTP status to respond with.
+	Status int
+	// Reason describes the problem, e.g. "missing Sec-WebSocket-Key".
+	Reason string
+	// Header holds headers for the response, e.g. Sec-WebSocket-Version for
+	// 426 Upgrade Required.
+	Header http.Header
+}
+
+func (e *HandshakeError) Error() string { return "websocket: " + e.Reason }
+
+// keyGUID is appended to Sec-WebSocket-Key to compute Sec-WebSocket-Accept.
+const keyGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// IsUpgrade reports whether r asks to be upgraded to the WebSocket protocol.
+func IsUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// Upgrade completes the WebSocket handshake of the request of c and returns
+// the connection. It checks the request, the origin and the protocol
+// version, selects a subprotocol and negotiates compression, then hijacks
+// the connection (Ctx.Hijack) and writes the 101 Switching Protocols
+// response, with the headers already set on c.
+//
+// A request that cannot be upgraded gets a *HandshakeError and nothing is
+// written. The caller must Close the connection; on fasthttp it is closed
+// when the request finishes anyway.
+//
+// Example:
+//
+//	a.GET("/feed", func(c app.Ctx) error {
+//		conn, err := websocket.Upgrade(c, websocket.Config{Subprotocols: []string{"feed.v2"}})
+//		if err != nil {
+//			return err
+//		}
+//		defer conn.Close()
+//		return conn.WriteJSON(snapshot())
+//	})
+func Upgrade(c ctx.Ctx, cfg Config) (*Conn, error) {
+	r := c.Request()
+	if r.Method != http.MethodGet {
+		return nil, &HandshakeError{Status: http.StatusMethodNotAllowed, Reason: "handshake must be a GET request", Header: http.Header{"Allow": {http.MethodGet}}}
+	}
+	if !IsUpgrade(r) {
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Reason: "not a WebSocket upgrade request", Header: http.Header{"Upgrade": {"websocket"}, "Connection": {"Upgrade"}}}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, &HandshakeError{Status: http.StatusUpgradeRequired, Reason: "unsupported Sec-WebSocket-Version", Header: http.Header{"Sec-Websocket-Version": {"13"}}}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return nil, &HandshakeError{Status: http.StatusBadRequest, Reason: "missing or invalid Sec-WebSocket-Key"}
+	}
+	checkOrigin := cfg.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return nil, &HandshakeError{Status: http.StatusForbidden, Reason: "origin not allowed"}
+	}
+
+	subprotocol := selectSubprotocol(r, cfg.Subprotocols)
+	compress := cfg.EnableCompression && offersDeflate(r.Header)
+
+	// Headers set by middleware, e.g. Set-Cookie, go with the 101 response
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	b.WriteString(acceptKey(key))
+	b.WriteString("\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	for k, vs := range c.ResponseWriter().Header() {
+		switch http.CanonicalHeaderKey(k) {
+		case "Connection", "Upgrade", "Content-Length", "Content-Type", "Transfer-Encoding":
+			continue
+		}
+		for _, v := range vs {
+			b.WriteString(k + ": " + strings.NewReplacer("\r", " ", "\n", " ").Replace(v) + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+
+	c.Status(http.StatusSwitchingProtocols)
+	netConn, brw, err := c.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack: %w", err)
+	}
+	// Deadlines of the server's HTTP handling do not apply to the WebSocket
+	_ = netConn.SetDeadline(time.Time{})
+	cfg = cfg.withDefaults()
+	_ = netConn.SetWriteDeadline(time.Now().Add(cfg.WriteTimeout))
+	if _, err := brw.WriteString(b.String()); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return newConn(netConn, brw.Reader, cfg, subprotocol, compress), nil
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value for key.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + keyGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// sameOrigin is the default Config.CheckOrigin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// selectSubprotocol returns the first of supported offered by the client.
+func selectSubprotocol(r *http.Request, supported []string) string {
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, p := range supported {
+		for _, o := range offered {
+			if p == o {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// headerTokens returns the comma-separated values of header name.
+func headerTokens(h http.Header, name string) []string {
+	var out []string
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				out = append(out, t)
+			}
+		}
+	}
+	return out
+}
+
+// headerHasToken reports whether header name lists token, ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, t := range headerTokens(h, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goflash/flash/v2/ctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey is the sample Sec-WebSocket-Key of RFC 6455 section 1.3.
+const testKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+// handshakeRequest returns a valid handshake request for target.
+func handshakeRequest(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", testKey)
+	return r
+}
+
+// serve upgrades the requests of a test server with cfg and calls fn with
+// the connection, closing it afterwards. fn's errors are sent on the
+// returned channel.
+func serve(t *testing.T, cfg Config, fn func(conn *Conn) error) (string, <-chan error) {
+	errs := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var c ctx.DefaultContext
+		c.Reset(w, r, nil, "/")
+		c.Header("Set-Cookie", "sid=1")
+		conn, err := Upgrade(&c, cfg)
+		if err != nil {
+			http.Error(w, err.Error(), err.(*HandshakeError).Status)
+			return
+		}
+		defer conn.Close()
+		errs <- fn(conn)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), errs
+}
+
+// testClient is a minimal WebSocket client.
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dial opens a connection to addr with the headers of a valid handshake and
+// hdr, and returns the client and the handshake response.
+func dial(t *testing.T, addr string, hdr http.Header) (*testClient, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	req := handshakeRequest("http://" + addr + "/")
+	req.RequestURI = ""
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	require.NoError(t, req.Write(conn))
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	require.NoError(t, err)
+	return &testClient{t: t, conn: conn, br: br}, resp
+}
+
+// writeFrame writes a masked frame.
+func (tc *testClient) writeFrame(fin, rsv1 bool, op byte, p []byte) {
+	tc.writeRaw(fin, rsv1, op, p, true)
+}
+
+// writeRaw writes a frame, masked or not.
+func (tc *testClient) writeRaw(fin, rsv1 bool, op byte, p []byte, masked bool) {
+	var b0 byte = op
+	if fin {
+		b0 |= finBit
+	}
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	b := []byte{b0}
+	var m byte
+	if masked {
+		m = maskBit
+	}
+	switch {
+	case len(p) <= 125:
+		b = append(b, m|byte(len(p)))
+	case len(p) <= 0xffff:
+		b = append(b, m|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(p)))
+	default:
+		b = append(b, m|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(len(p)))
+	}
+	if masked {
+		mask := []byte{0x12, 0x34, 0x56, 0x78}
+		b = append(b, mask...)
+		for i, c := range p {
+			b = append(b, c^mask[i&3])
+		}
+	} else {
+		b = append(b, p...)
+	}
+	_, err := tc.conn.Write(b)
+	require.NoError(tc.t, err)
+}
+
+// readFrame reads an unmasked frame.
+func (tc *testClient) readFrame() (op byte, rsv1 bool, p []byte) {
+	var hdr [2]byte
+	_, err := io.ReadFull(tc.br, hdr[:])
+	require.NoError(tc.t, err)
+	require.NotZero(tc.t, hdr[0]&finBit, "server frames are not fragmented")
+	require.Zero(tc.t, hdr[1]&maskBit, "server frames are not masked")
+	n := uint64(hdr[1] & 0x7f)
+	switch n {
+	case 126:
+		var l [2]byte
+		_, err = io.ReadFull(tc.br, l[:])
+		n = uint64(binary.BigEndian.Uint16(l[:]))
+	case 127:
+		var l [8]byte
+		_, err = io.ReadFull(tc.br, l[:])
+		n = binary.BigEndian.Uint64(l[:])
+	}
+	require.NoError(tc.t, err)
+	p = make([]byte, n)
+	_, err = io.ReadFull(tc.br, p)
+	require.NoError(tc.t, err)
+	return hdr[0] & 0x0f, hdr[0]&rsv1Bit != 0, p
+}
+
+// readClose reads frames up to a close frame and returns its status code.
+func (tc *testClient) readClose() int {
+	for {
+		op, _, p := tc.readFrame()
+		if op == opClose {
+			require.Len(tc.t, p[:2], 2)
+			return int(binary.BigEndian.Uint16(p))
+		}
+	}
+}
+
+// closePayload returns the payload of a close frame.
+func closePayload(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+func TestAcceptKey(t *testing.T) {
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", acceptKey(testKey))
+}
+
+func TestUpgradeRejectsInvalidHandshakes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		cfg    Config
+		status int
+		header string
+	}{
+		{"method", func(r *http.Request) { r.Method = http.MethodPost }, Config{}, http.StatusMethodNotAllowed, "Allow"},
+		{"no upgrade", func(r *http.Request) { r.Header.Del("Upgrade") }, Config{}, http.StatusUpgradeRequired, "Upgrade"},
+		{"no connection upgrade", func(r *http.Request) { r.Header.Set("Connection", "keep-alive") }, Config{}, http.StatusUpgradeRequired, "Upgrade"},
+		{"version", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") }, Config{}, http.StatusUpgradeRequired, "Sec-WebSocket-Version"},
+		{"no key", func(r *http.Request) { r.Header.Del("Sec-WebSocket-Key") }, Config{}, http.StatusBadRequest, ""},
+		{"short key", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Key", "c2hvcnQ=") }, Config{}, http.StatusBadRequest, ""},
+		{"cross origin", func(r *http.Request) { r.Header.Set("Origin", "https://evil.example") }, Config{}, http.StatusForbidden, ""},
+		{"check origin", func(r *http.Request) {}, Config{CheckOrigin: func(*http.Request) bool { return false }}, http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := handshakeRequest("/ws")
+			tt.modify(r)
+			rec := httptest.NewRecorder()
+			var c ctx.DefaultContext
+			c.Reset(rec, r, nil, "/ws")
+			conn, err := Upgrade(&c, tt.cfg)
+			assert.Nil(t, conn)
+			var he *HandshakeError
+			require.ErrorAs(t, err, &he)
+			assert.Equal(t, tt.status, he.Status)
+			if tt.header != "" {
+				assert.NotEmpty(t, he.Header.Get(tt.header))
+			}
+			assert.False(t, c.WroteHeader(), "nothing is written")
+		})
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	r := handshakeRequest("http://example.com/ws")
+	assert.True(t, sameOrigin(r), "no Origin")
+	r.Header.Set("Origin", "https://EXAMPLE.com")
+	assert.True(t, sameOrigin(r))
+	r.Header.Set("Origin", "https://example.com:8443")
+	assert.False(t, sameOrigin(r))
+	r.Header.Set("Origin", "null")
+	assert.False(t, sameOrigin(r))
+}
+
+func TestSelectSubprotocol(t *testing.T) {
+	r := handshakeRequest("/")
+	r.Header.Add("Sec-WebSocket-Protocol", "chat.v1, chat.v2")
+	r.Header.Add("Sec-WebSocket-Protocol", "mqtt")
+	assert.Equal(t, "chat.v2", selectSubprotocol(r, []string{"chat.v2", "chat.v1"}), "server preference")
+	assert.Equal(t, "mqtt", selectSubprotocol(r, []string{"mqtt"}))
+	assert.Equal(t, "", selectSubprotocol(r, []string{"stomp"}))
+	assert.Equal(t, "", selectSubprotocol(r, nil))
+}
+
+func TestOffersDeflate(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want bool
+	}{
+		{"", false},
+		{"x-webkit-deflate-frame", false},
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_max_window_bits", true},
+		{"permessage-deflate; server_no_context_takeover; client_no_context_takeover", true},
+		{"permessage-deflate; server_max_window_bits=10", false},
+		{`permessage-deflate; server_max_window_bits="15"`, true},
+		{"permessage-deflate; unknown", false},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", true},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.ext != "" {
+			h.Set("Sec-WebSocket-Extensions", tt.ext)
+		}
+		assert.Equal(t, tt.want, offersDeflate(h), tt.ext)
+	}
+}
+
+func TestUpgradeHandshake(t *testing.T) {
+	addr, errs := serve(t, Config{Subprotocols: []string{"chat.v2"}, EnableCompression: true}, func(conn *Conn) error {
+		if conn.Subprotocol() != "chat.v2" || !conn.Compressed() {
+			return errors.New("handshake not negotiated")
+		}
+		return nil
+	})
+	_, resp := dial(t, addr, http.Header{
+		"Sec-Websocket-Protocol":   {"chat.v1, chat.v2"},
+		"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"},
+	})
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "websocket", resp.Header.Get("Upgrade"))
+	assert.Equal(t, "Upgrade", resp.Header.Get("Connection"))
+	assert.Equal(t, acceptKey(testKey), resp.Header.Get("Sec-WebSocket-Accept"))
+	assert.Equal(t, "chat.v2", resp.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Contains(t, resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	assert.Equal(t, "sid=1", resp.Header.Get("Set-Cookie"), "headers set before the upgrade are sent")
+	require.NoError(t, <-errs)
+}
+
+func TestUpgradeWithoutOffers(t *testing.T) {
+	addr, errs := serve(t, Config{Subprotocols: []string{"chat.v2"}, EnableCompression: true}, func(conn *Conn) error {
+		if conn.Subprotocol() != "" || conn.Compressed() {
+			return errors.New("nothing was offered")
+		}
+		return nil
+	})
+	_, resp := dial(t, addr, nil)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Sec-WebSocket-Protocol"))
+	assert.Empty(t, resp.Header.Get("Sec-WebSocket-Extensions"))
+	require.NoError(t, <-errs)
+}
+
+// deflate compresses p like a permessage-deflate client.
+func deflate(t *testing.T, p []byte) []byte {
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	require.NoError(t, err)
+	_, err = fw.Write(p)
+	require.NoError(t, err)
+	require.NoError(t, fw.Flush())
+	return bytes.TrimSuffix(buf.Bytes(), []byte{0, 0, 0xff, 0xff})
+}